@@ -0,0 +1,112 @@
+package simplecache
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type AuditOp string
+
+const (
+	AuditSet       AuditOp = "set"
+	AuditDelete    AuditOp = "delete"
+	AuditDeleteAll AuditOp = "delete_all"
+	AuditExpire    AuditOp = "expire"
+)
+
+// AuditRecord describes a single mutation, values are left out so the trail doesn't duplicate cached data
+type AuditRecord struct {
+	Time  time.Time `json:"time"`
+	Actor string    `json:"actor,omitempty"`
+	Op    AuditOp   `json:"op"`
+	Key   string    `json:"key,omitempty"`
+}
+
+type AuditSink interface {
+	Audit(AuditRecord) error
+}
+
+type AuditFunc func(AuditRecord) error
+
+func (f AuditFunc) Audit(r AuditRecord) error {
+	return f(r)
+}
+
+type auditActorKey struct{}
+
+// WithActor returns a context recording actor as the author of mutations made with it
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+
+	return actor
+}
+
+// WithAudit records every Set, Delete, DeleteAll and expiry to sink, sink errors are reported to OnError
+func (c *Cache[K, T]) WithAudit(sink AuditSink) *Cache[K, T] {
+	c.auditSink = sink
+
+	return c
+}
+
+func (c *Cache[K, T]) audit(ctx context.Context, op AuditOp, key K) {
+	if c.auditSink == nil {
+		return
+	}
+
+	c.writeAudit(AuditRecord{
+		Time:  time.Now(),
+		Actor: ActorFromContext(ctx),
+		Op:    op,
+		Key:   keyString(key),
+	})
+}
+
+func (c *Cache[K, T]) writeAudit(r AuditRecord) {
+	if err := c.auditSink.Audit(r); err != nil {
+		c.reportError(err)
+	}
+}
+
+type auditWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// AuditWriter writes records to w as JSON lines
+func AuditWriter(w io.Writer) AuditSink {
+	return &auditWriter{enc: json.NewEncoder(w)}
+}
+
+func (a *auditWriter) Audit(r AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.enc.Encode(r)
+}
+
+// AuditFile appends JSON lines to a file, Close it once the cache is no longer used
+type AuditFile struct {
+	AuditSink
+	file *os.File
+}
+
+func OpenAuditFile(path string) (*AuditFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditFile{AuditSink: AuditWriter(file), file: file}, nil
+}
+
+func (a *AuditFile) Close() error {
+	return a.file.Close()
+}
@@ -0,0 +1,72 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	records := make([]cache.AuditRecord, 0)
+
+	c := cache.New[string, TestStruct]().
+		WithAudit(cache.AuditFunc(func(r cache.AuditRecord) error {
+			records = append(records, r)
+			return nil
+		}))
+
+	ctx := cache.WithActor(context.Background(), "alice@example.com")
+
+	assert.NoError(t, c.SetContext(ctx, "item1", TestStruct{Name: "Alice", Age: 30}))
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.DeleteContext(ctx, "item1")
+	c.Delete("nonexistent")
+	c.DeleteAll()
+
+	assert.Len(t, records, 4)
+
+	assert.Equal(t, cache.AuditSet, records[0].Op)
+	assert.Equal(t, "item1", records[0].Key)
+	assert.Equal(t, "alice@example.com", records[0].Actor)
+	assert.False(t, records[0].Time.IsZero())
+
+	assert.Equal(t, cache.AuditSet, records[1].Op)
+	assert.Empty(t, records[1].Actor)
+
+	assert.Equal(t, cache.AuditDelete, records[2].Op)
+	assert.Equal(t, "alice@example.com", records[2].Actor)
+
+	assert.Equal(t, cache.AuditDeleteAll, records[3].Op)
+}
+
+func TestAuditWriterAndFile(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]().WithAudit(cache.AuditWriter(&buf))
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	var record cache.AuditRecord
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, cache.AuditSet, record.Op)
+	assert.Equal(t, "item1", record.Key)
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	file, err := cache.OpenAuditFile(path)
+	assert.NoError(t, err)
+
+	c = cache.New[string, TestStruct]().WithAudit(file)
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Delete("item1")
+	assert.NoError(t, file.Close())
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(content, []byte("\n")))
+}
@@ -0,0 +1,49 @@
+package simplecache
+
+import (
+	"time"
+)
+
+// WithAutoSave saves a snapshot to target every interval while Maintain runs, failed saves are reported to OnError
+func (c *Cache[K, T]) WithAutoSave(interval time.Duration, target SnapshotTarget) *Cache[K, T] {
+	c.autoSaveInterval = interval
+	c.snapshotTarget = target
+
+	return c
+}
+
+// WithPersistence restores the cache from target on Open, with every other option applied, and saves it back when Maintain is stopped.
+// A missing snapshot starts the cache empty, other restore errors are returned by Open or reported to OnError by Maintain.
+func (c *Cache[K, T]) WithPersistence(target SnapshotTarget) *Cache[K, T] {
+	c.snapshotTarget = target
+	c.persistOnStop = true
+
+	return c
+}
+
+func (c *Cache[K, T]) saveOnStop() {
+	if !c.persistOnStop {
+		return
+	}
+
+	if err := c.SaveTo(c.snapshotTarget); err != nil {
+		c.reportError(err)
+	}
+}
+
+func (c *Cache[K, T]) runAutoSave(done <-chan struct{}) {
+	ticker := time.NewTicker(c.autoSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.SaveTo(c.snapshotTarget); err != nil {
+				c.reportError(err)
+			}
+
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package simplecache_test
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingTarget struct{}
+
+func (failingTarget) Write(func(io.Writer) error) error { return errors.New("disk full") }
+func (failingTarget) Read(func(io.Reader) error) error  { return errors.New("disk full") }
+
+func TestAutoSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).
+		WithAutoSave(50*time.Millisecond, cache.FileTarget(path))
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	time.Sleep(150 * time.Millisecond)
+
+	c.Stop()
+
+	restored := cache.New[string, TestStruct]()
+	assert.NoError(t, restored.LoadFile(path))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
+
+func TestAutoSaveError(t *testing.T) {
+	errs := make(chan error, 16)
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).
+		WithAutoSave(20*time.Millisecond, failingTarget{}).
+		OnError(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+
+	go c.Maintain()
+	defer c.Stop()
+
+	assert.EqualError(t, <-errs, "disk full")
+}
+
+func TestPersistence(t *testing.T) {
+	target := cache.FileTarget(filepath.Join(t.TempDir(), "cache.gob"))
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).WithPersistence(target)
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Stop()
+
+	restarted := cache.New[string, TestStruct]().WithInterval(time.Second).WithPersistence(target)
+
+	// Only restored once every option is applied
+	_, ok := restarted.Get("item1")
+	assert.False(t, ok)
+	assert.NoError(t, restarted.Open())
+
+	item1, ok := restarted.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
+
+func TestPersistenceAppliesLaterOptions(t *testing.T) {
+	target := cache.FileTarget(filepath.Join(t.TempDir(), "cache.gob"))
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).WithPersistence(target).WithEncryption(testKey)
+	assert.NoError(t, c.Open())
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	go c.Maintain()
+	c.Stop()
+
+	restarted := cache.New[string, TestStruct]().WithPersistence(target).WithEncryption(testKey)
+	assert.NoError(t, restarted.Open())
+
+	item1, ok := restarted.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
+
+func TestPersistenceRestoreError(t *testing.T) {
+	errs := make(chan error, 1)
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).
+		WithPersistence(failingTarget{}).
+		OnError(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+
+	go c.Maintain()
+
+	assert.EqualError(t, <-errs, "disk full")
+	c.Stop()
+}
@@ -0,0 +1,115 @@
+package simplecache
+
+import (
+	"sync"
+	"time"
+)
+
+// BatchingBus merges the invalidations published within an interval into one message per origin, for WAN links between
+// regions where a message per key is too chatty. Combine it with the Compression of the inner bus to shrink the batches.
+// Subscribing goes straight to the inner bus. Invalidations are delayed by up to the interval.
+type BatchingBus[K comparable] struct {
+	// OnError receives the errors of background flushes
+	OnError func(error)
+
+	inner    InvalidationBus[K]
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingInvalidation[K]
+	order   []string
+	timer   *time.Timer
+	closed  bool
+}
+
+// pendingInvalidation collects the keys of one origin, in the order they were first invalidated
+type pendingInvalidation[K comparable] struct {
+	keys map[K]struct{}
+	inv  Invalidation[K]
+}
+
+// NewBatchingBus publishes to inner at most once per interval
+func NewBatchingBus[K comparable](inner InvalidationBus[K], interval time.Duration) *BatchingBus[K] {
+	return &BatchingBus[K]{inner: inner, interval: interval, pending: make(map[string]*pendingInvalidation[K])}
+}
+
+// Publish adds inv to the current batch, an All invalidation makes its keys redundant
+func (b *BatchingBus[K]) Publish(inv Invalidation[K]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return errBusClosed
+	}
+
+	p, ok := b.pending[inv.Origin]
+	if !ok {
+		p = &pendingInvalidation[K]{keys: make(map[K]struct{}), inv: Invalidation[K]{Origin: inv.Origin}}
+		b.pending[inv.Origin] = p
+		b.order = append(b.order, inv.Origin)
+	}
+
+	if inv.All {
+		p.inv.All = true
+		p.inv.Keys = nil
+	}
+
+	if !p.inv.All {
+		for _, key := range inv.Keys {
+			if _, seen := p.keys[key]; !seen {
+				p.keys[key] = struct{}{}
+				p.inv.Keys = append(p.inv.Keys, key)
+			}
+		}
+	}
+
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, func() {
+			if err := b.Flush(); err != nil && b.OnError != nil {
+				b.OnError(err)
+			}
+		})
+	}
+
+	return nil
+}
+
+func (b *BatchingBus[K]) Subscribe(fn func(Invalidation[K])) (func(), error) {
+	return b.inner.Subscribe(fn)
+}
+
+// Flush publishes the current batch right away
+func (b *BatchingBus[K]) Flush() error {
+	b.mu.Lock()
+	batch := make([]Invalidation[K], 0, len(b.order))
+	for _, origin := range b.order {
+		batch = append(batch, b.pending[origin].inv)
+	}
+
+	b.pending = make(map[string]*pendingInvalidation[K])
+	b.order = nil
+
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	var err error
+	for _, inv := range batch {
+		if e := b.inner.Publish(inv); e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// Close publishes what's pending, later Publish calls fail. The inner bus stays open.
+func (b *BatchingBus[K]) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	return b.Flush()
+}
@@ -0,0 +1,51 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchingBus(t *testing.T) {
+	inner := &cache.LocalBus[string]{}
+
+	received := make(chan cache.Invalidation[string], 10)
+	_, err := inner.Subscribe(func(inv cache.Invalidation[string]) { received <- inv })
+	assert.NoError(t, err)
+
+	bus := cache.NewBatchingBus[string](inner, 20*time.Millisecond)
+
+	assert.NoError(t, bus.Publish(cache.Invalidation[string]{Origin: "a", Keys: []string{"key1", "key2"}}))
+	assert.NoError(t, bus.Publish(cache.Invalidation[string]{Origin: "a", Keys: []string{"key2", "key3"}}))
+	assert.NoError(t, bus.Publish(cache.Invalidation[string]{Origin: "b", Keys: []string{"key1"}}))
+	assert.Empty(t, received)
+
+	// One message per origin once the interval passes
+	assert.Equal(t, cache.Invalidation[string]{Origin: "a", Keys: []string{"key1", "key2", "key3"}}, <-received)
+	assert.Equal(t, cache.Invalidation[string]{Origin: "b", Keys: []string{"key1"}}, <-received)
+
+	assert.NoError(t, bus.Publish(cache.Invalidation[string]{Origin: "a", Keys: []string{"key1"}}))
+	assert.NoError(t, bus.Publish(cache.Invalidation[string]{Origin: "a", All: true}))
+	assert.NoError(t, bus.Publish(cache.Invalidation[string]{Origin: "a", Keys: []string{"key2"}}))
+
+	assert.NoError(t, bus.Close())
+	assert.Equal(t, cache.Invalidation[string]{Origin: "a", All: true}, <-received)
+	assert.Error(t, bus.Publish(cache.Invalidation[string]{Origin: "a", Keys: []string{"key1"}}))
+}
+
+func TestBatchingBusBetweenCaches(t *testing.T) {
+	inner := &cache.LocalBus[string]{}
+
+	replica1 := cache.New[string, int]().WithInvalidationBus(cache.NewBatchingBus[string](inner, 10*time.Millisecond))
+	replica2 := cache.New[string, int]().WithInvalidationBus(inner)
+
+	replica2.Set("key", 1)
+	replica1.Set("key", 2)
+
+	assert.Eventually(t, func() bool {
+		_, ok := replica2.Get("key")
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,41 @@
+package simplecache
+
+import "time"
+
+// EventPublisher is implemented by application event buses the cache can publish into
+type EventPublisher[K comparable, T any] interface {
+	Publish(Event[K, T])
+}
+
+type EventPublisherFunc[K comparable, T any] func(Event[K, T])
+
+func (f EventPublisherFunc[K, T]) Publish(event Event[K, T]) {
+	f(event)
+}
+
+// PublishTo publishes every event into bus in order, returns a func that stops publishing
+func (c *Cache[K, T]) PublishTo(bus EventPublisher[K, T]) func() {
+	return c.Subscribe(Middlewares[K, T]{OnChanges: func(changes ChangeSet[K, T]) {
+		now := time.Now()
+
+		for _, group := range []struct {
+			t       EventType
+			changes []Change[K, T]
+		}{
+			{EventCreated, changes.Created},
+			{EventUpdated, changes.Updated},
+			{EventDeleted, changes.Deleted},
+			{EventExpired, changes.Expired},
+		} {
+			for _, change := range group.changes {
+				bus.Publish(newEvent(group.t, change, now))
+			}
+		}
+	}})
+}
+
+// PipeEvents sends every event into ch, unlike Events it blocks when ch is full instead of dropping,
+// use WithAsyncDispatch to keep a slow consumer from holding up writers
+func (c *Cache[K, T]) PipeEvents(ch chan<- Event[K, T]) func() {
+	return c.PublishTo(EventPublisherFunc[K, T](func(event Event[K, T]) { ch <- event }))
+}
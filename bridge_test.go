@@ -0,0 +1,61 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBus struct {
+	events []cache.Event[string, TestStruct]
+}
+
+func (b *testBus) Publish(event cache.Event[string, TestStruct]) {
+	b.events = append(b.events, event)
+}
+
+func TestPublishTo(t *testing.T) {
+	bus := &testBus{}
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		Equals(func(a, b TestStruct) bool { return a == b })
+	stop := c.PublishTo(bus)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Delete("item1")
+
+	stop()
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	types := make([]cache.EventType, 0)
+	for _, event := range bus.events {
+		assert.Equal(t, "item1", event.Key)
+		types = append(types, event.Type)
+	}
+
+	assert.Equal(t, []cache.EventType{cache.EventCreated, cache.EventUpdated, cache.EventDeleted}, types)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, bus.events[1].Previous)
+}
+
+func TestPipeEvents(t *testing.T) {
+	ch := make(chan cache.Event[string, TestStruct])
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).WithImmediateNotifications().WithAsyncDispatch(16)
+	c.PipeEvents(ch)
+
+	go c.Maintain()
+	defer c.Stop()
+
+	for i := range 5 {
+		c.Set("item1", TestStruct{Name: "Alice", Age: 30 + i})
+	}
+
+	// An unbuffered channel still receives every event, none are dropped
+	for i := range 5 {
+		event := <-ch
+		assert.Equal(t, 30+i, event.Value.Age)
+	}
+}
@@ -0,0 +1,83 @@
+package simplecache
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChangelogMessage is one change as sent to a ChangelogProducer
+type ChangelogMessage struct {
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// ChangelogProducer sends the messages of one batch of changes, KafkaProducer is built in
+type ChangelogProducer interface {
+	Produce(msgs []ChangelogMessage) error
+}
+
+// eventRecord is the JSON form of an event, the default changelog value and what the streaming endpoints send
+type eventRecord[K comparable, T any] struct {
+	Seq      uint64    `json:"seq,omitempty"`
+	Type     string    `json:"type"`
+	Key      K         `json:"key"`
+	Value    T         `json:"value"`
+	Previous *T        `json:"previous,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// PublishChangelog sends every batch of changes to producer as one message per change, making the cache a change-data source.
+// key and value serialize the messages, nil keys with the key's string form and values as JSON objects with seq, type, key, value,
+// previous (for updates) and time. Failed batches are retried and dead-lettered like with OnChangesE. Returns a func that stops publishing.
+func (c *Cache[K, T]) PublishChangelog(producer ChangelogProducer, key func(K) ([]byte, error), value func(Event[K, T]) ([]byte, error)) func() {
+	if key == nil {
+		key = func(k K) ([]byte, error) {
+			return []byte(keyString(k)), nil
+		}
+	}
+
+	if value == nil {
+		value = eventJSON[K, T]
+	}
+
+	return c.Subscribe(Middlewares[K, T]{OnChanges: func(changes ChangeSet[K, T]) {
+		c.handle(func(changes ChangeSet[K, T]) error {
+			now := time.Now()
+
+			var msgs []ChangelogMessage
+			for _, event := range eventsOf(changes, now) {
+				k, err := key(event.Key)
+				if err != nil {
+					return err
+				}
+
+				v, err := value(event)
+				if err != nil {
+					return err
+				}
+
+				msgs = append(msgs, ChangelogMessage{Key: k, Value: v, Time: now})
+			}
+
+			if len(msgs) == 0 {
+				return nil
+			}
+
+			return producer.Produce(msgs)
+		}, changes)
+	}})
+}
+
+func newEventRecord[K comparable, T any](event Event[K, T]) eventRecord[K, T] {
+	rec := eventRecord[K, T]{Seq: event.Seq, Type: event.Type.String(), Key: event.Key, Value: event.Value, Time: event.Time}
+	if event.Type == EventUpdated {
+		rec.Previous = &event.Previous
+	}
+
+	return rec
+}
+
+func eventJSON[K comparable, T any](event Event[K, T]) ([]byte, error) {
+	return json.Marshal(newEventRecord(event))
+}
@@ -0,0 +1,194 @@
+package simplecache
+
+import (
+	"context"
+	"slices"
+)
+
+type Change[K comparable, T any] struct {
+	Seq   uint64
+	Key   K
+	Value T
+
+	// Previous is the value seen on the last tick, only set for updates
+	Previous T
+}
+
+// ChangeSet holds the changes detected during a single Maintain tick, expired items are not listed in Deleted.
+// The slices are reused after the middlewares return, copy them to keep them around.
+type ChangeSet[K comparable, T any] struct {
+	Created []Change[K, T]
+	Updated []Change[K, T]
+	Deleted []Change[K, T]
+	Expired []Change[K, T]
+}
+
+type ChangesMiddleware[K comparable, T any] func(ChangeSet[K, T])
+type KeyedMiddleware[K comparable, T any] func([]Change[K, T])
+
+func (cs ChangeSet[K, T]) Empty() bool {
+	return len(cs.Created) == 0 && len(cs.Updated) == 0 && len(cs.Deleted) == 0 && len(cs.Expired) == 0
+}
+
+func (cs ChangeSet[K, T]) clone() ChangeSet[K, T] {
+	return ChangeSet[K, T]{
+		Created: slices.Clone(cs.Created),
+		Updated: slices.Clone(cs.Updated),
+		Deleted: slices.Clone(cs.Deleted),
+		Expired: slices.Clone(cs.Expired),
+	}
+}
+
+// WithImmediateNotifications reports writes from Set/Delete directly, Maintain is then only needed for expiry
+func (c *Cache[K, T]) WithImmediateNotifications() *Cache[K, T] {
+	c.immediate = true
+
+	return c
+}
+
+func (c *Cache[K, T]) OnChanges(m ChangesMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnChanges: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnCreateKeyed(m KeyedMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnCreateKeyed: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnUpdateKeyed(m KeyedMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnUpdateKeyed: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnDeleteKeyed(m KeyedMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnDeleteKeyed: m})
+
+	return c
+}
+
+// notify runs the middlewares in the context of origin, queued tells the dispatch span to start a trace of its
+// own linked to origin's, as handlers running after the write returned aren't part of its work
+func (c *Cache[K, T]) notify(origin context.Context, changes ChangeSet[K, T], queued bool) {
+	ctx := c.dispatchContext(origin)
+
+	var end func(error)
+	if queued {
+		ctx, end = c.linkedSpan(ctx, "dispatch")
+	} else {
+		ctx, end = c.span(ctx, "dispatch")
+	}
+	defer end(nil)
+
+	middlewares := c.snapshotMiddlewares()
+
+	// Call middlewares for created, updated, and deleted records, expired ones count as deleted
+	c.notifyValues(middlewares, EventCreated, func(m *Middlewares[K, T]) Middleware[T] { return m.OnCreate }, changes.Created)
+	c.notifyValues(middlewares, EventUpdated, func(m *Middlewares[K, T]) Middleware[T] { return m.OnUpdate }, changes.Updated)
+	c.notifyValues(middlewares, EventDeleted, func(m *Middlewares[K, T]) Middleware[T] { return m.OnDelete }, changes.Expired, changes.Deleted)
+
+	c.notifyKeyed(middlewares, EventCreated, func(m *Middlewares[K, T]) KeyedMiddleware[K, T] { return m.OnCreateKeyed }, changes.Created)
+	c.notifyKeyed(middlewares, EventUpdated, func(m *Middlewares[K, T]) KeyedMiddleware[K, T] { return m.OnUpdateKeyed }, changes.Updated)
+	c.notifyKeyed(middlewares, EventDeleted, func(m *Middlewares[K, T]) KeyedMiddleware[K, T] { return m.OnDeleteKeyed }, changes.Expired, changes.Deleted)
+
+	for _, m := range middlewares {
+		if m.OnChanges == nil {
+			continue
+		}
+
+		selected := changes
+		if filter := c.filterFor(m); filter != nil {
+			selected = ChangeSet[K, T]{
+				Created: filterChanges(changes.Created, filter),
+				Updated: filterChanges(changes.Updated, filter),
+				Deleted: filterChanges(changes.Deleted, filter),
+				Expired: filterChanges(changes.Expired, filter),
+			}
+
+			if selected.Empty() {
+				continue
+			}
+		}
+
+		c.safely(func() { m.OnChanges(selected) })
+	}
+
+	c.notifyContext(ctx, middlewares, changes)
+}
+
+func (c *Cache[K, T]) notifyValues(middlewares []*Middlewares[K, T], event EventType, pick func(*Middlewares[K, T]) Middleware[T], changes ...[]Change[K, T]) {
+	c.notifyKeyed(middlewares, event, func(m *Middlewares[K, T]) KeyedMiddleware[K, T] {
+		fn := pick(m)
+		if fn == nil {
+			return nil
+		}
+
+		return func(changes []Change[K, T]) {
+			values := make([]T, len(changes))
+			for i, change := range changes {
+				values[i] = change.Value
+			}
+
+			fn(values)
+		}
+	}, changes...)
+}
+
+func (c *Cache[K, T]) notifyKeyed(middlewares []*Middlewares[K, T], event EventType, pick func(*Middlewares[K, T]) KeyedMiddleware[K, T], changes ...[]Change[K, T]) {
+	var all []Change[K, T]
+	var jobs []func()
+
+	for _, m := range middlewares {
+		fn := pick(m)
+		if fn == nil {
+			continue
+		}
+
+		// Only merge the changes once someone is listening
+		if all == nil {
+			for _, cs := range changes {
+				all = append(all, cs...)
+			}
+
+			if len(all) == 0 {
+				return
+			}
+		}
+
+		selected := filterChanges(all, c.filterFor(m))
+		if len(selected) == 0 {
+			continue
+		}
+
+		jobs = append(jobs, func() { fn(selected) })
+	}
+
+	c.fanOut(event, jobs)
+}
+
+func filterChanges[K comparable, T any](changes []Change[K, T], filter func(K, T) bool) []Change[K, T] {
+	if filter == nil {
+		return changes
+	}
+
+	var res []Change[K, T]
+	for _, change := range changes {
+		if filter(change.Key, change.Value) {
+			res = append(res, change)
+		}
+	}
+
+	return res
+}
+
+// truncate empties buf for reuse, dropping it entirely once it grew past retention
+func truncate[K comparable, T any](buf []Change[K, T], retention int) []Change[K, T] {
+	if cap(buf) > retention {
+		return nil
+	}
+
+	return buf[:0]
+}
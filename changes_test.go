@@ -0,0 +1,169 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnChanges(t *testing.T) {
+	created := make([]cache.Change[string, TestStruct], 0)
+	deleted := make([]cache.Change[string, TestStruct], 0)
+	expired := make([]cache.Change[string, TestStruct], 0)
+	deletedItems := make([]TestStruct, 0)
+
+	c := cache.New[string, TestStruct]().WithInterval(100 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		OnChanges(func(cs cache.ChangeSet[string, TestStruct]) {
+			assert.False(t, cs.Empty())
+
+			created = append(created, withoutSeq(cs.Created)...)
+			deleted = append(deleted, withoutSeq(cs.Deleted)...)
+			expired = append(expired, withoutSeq(cs.Expired)...)
+		}).
+		OnDelete(func(items []TestStruct) { deletedItems = append(deletedItems, items...) })
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, time.Now().Add(150*time.Millisecond))
+	time.Sleep(250 * time.Millisecond)
+
+	c.Delete("item1")
+	time.Sleep(100 * time.Millisecond)
+
+	c.Stop()
+
+	assert.ElementsMatch(t, []cache.Change[string, TestStruct]{
+		{Key: "item1", Value: TestStruct{Name: "Alice", Age: 30}},
+		{Key: "item2", Value: TestStruct{Name: "Bob", Age: 25}},
+	}, created)
+	assert.Equal(t, []cache.Change[string, TestStruct]{{Key: "item1", Value: TestStruct{Name: "Alice", Age: 30}}}, deleted)
+	assert.Equal(t, []cache.Change[string, TestStruct]{{Key: "item2", Value: TestStruct{Name: "Bob", Age: 25}}}, expired)
+
+	// Plain delete middleware still receives expired items
+	assert.ElementsMatch(t, []TestStruct{{Name: "Alice", Age: 30}, {Name: "Bob", Age: 25}}, deletedItems)
+}
+
+func TestKeyedMiddlewares(t *testing.T) {
+	createdKeys := make([]string, 0)
+	updatedKeys := make([]string, 0)
+	deletedKeys := make([]string, 0)
+
+	c := cache.New[string, TestStruct]().WithInterval(100 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		OnCreateKeyed(func(changes []cache.Change[string, TestStruct]) {
+			for _, change := range changes {
+				createdKeys = append(createdKeys, change.Key)
+			}
+		}).
+		OnUpdateKeyed(func(changes []cache.Change[string, TestStruct]) {
+			for _, change := range changes {
+				updatedKeys = append(updatedKeys, change.Key)
+			}
+		}).
+		OnDeleteKeyed(func(changes []cache.Change[string, TestStruct]) {
+			for _, change := range changes {
+				deletedKeys = append(deletedKeys, change.Key)
+			}
+		})
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	time.Sleep(150 * time.Millisecond)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	time.Sleep(100 * time.Millisecond)
+
+	c.Delete("item1")
+	time.Sleep(100 * time.Millisecond)
+
+	c.Stop()
+
+	assert.Equal(t, []string{"item1"}, createdKeys)
+	assert.Equal(t, []string{"item1"}, updatedKeys)
+	assert.Equal(t, []string{"item1"}, deletedKeys)
+}
+
+func TestUpdatePreviousValue(t *testing.T) {
+	updates := make([]cache.Change[string, TestStruct], 0)
+
+	c := cache.New[string, TestStruct]().WithInterval(100 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		OnUpdateKeyed(func(changes []cache.Change[string, TestStruct]) { updates = append(updates, changes...) })
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	time.Sleep(150 * time.Millisecond)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	time.Sleep(100 * time.Millisecond)
+
+	c.Stop()
+
+	assert.Equal(t, []cache.Change[string, TestStruct]{{
+		Seq:      2,
+		Key:      "item1",
+		Value:    TestStruct{Name: "Alice", Age: 31},
+		Previous: TestStruct{Name: "Alice", Age: 30},
+	}}, updates)
+}
+
+func TestImmediateNotifications(t *testing.T) {
+	createdItems := make([]TestStruct, 0)
+	updatedItems := make([]TestStruct, 0)
+	deletedItems := make([]TestStruct, 0)
+
+	c := cache.New[string, TestStruct]().
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		WithImmediateNotifications().
+		OnCreate(func(items []TestStruct) { createdItems = append(createdItems, items...) }).
+		OnUpdate(func(items []TestStruct) { updatedItems = append(updatedItems, items...) }).
+		OnDelete(func(items []TestStruct) { deletedItems = append(deletedItems, items...) })
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Delete("item1")
+	c.Delete("item1")
+
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 30}}, createdItems)
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 31}}, updatedItems)
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 31}}, deletedItems)
+}
+
+func withoutSeq(changes []cache.Change[string, TestStruct]) []cache.Change[string, TestStruct] {
+	res := make([]cache.Change[string, TestStruct], 0, len(changes))
+	for _, change := range changes {
+		change.Seq = 0
+		res = append(res, change)
+	}
+
+	return res
+}
+
+func TestNoUpdatesWithoutWrites(t *testing.T) {
+	ticks := make(chan cache.TickStats, 16)
+	var updates int
+
+	// No Equals, values are compared with reflect.DeepEqual
+	c := cache.New[string, TestStruct]().WithInterval(20 * time.Millisecond).
+		OnUpdate(func(values []TestStruct) { updates += len(values) }).
+		OnTickStats(func(stats cache.TickStats) { ticks <- stats })
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	go c.Maintain()
+	defer c.Stop()
+
+	assert.Equal(t, 1, (<-ticks).Created)
+	assert.Zero(t, (<-ticks).Updated)
+	assert.Zero(t, (<-ticks).Updated)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	assert.Equal(t, 1, (<-ticks).Updated)
+	assert.Equal(t, 1, updates)
+}
@@ -0,0 +1,77 @@
+package simplecache
+
+import (
+	"context"
+	"time"
+)
+
+// WithCoalesceWindow merges updates to the same key within d into a single update event
+func (c *Cache[K, T]) WithCoalesceWindow(d time.Duration) *Cache[K, T] {
+	c.coalesceWindow = d
+
+	return c
+}
+
+// coalesce holds back updates until the window closes, a pending update is released early
+// when its key is deleted so handlers still see the events in order
+func (c *Cache[K, T]) coalesce(changes ChangeSet[K, T]) ChangeSet[K, T] {
+	c.coalesceMu.Lock()
+	defer c.coalesceMu.Unlock()
+
+	var released []Change[K, T]
+	for _, removed := range [][]Change[K, T]{changes.Deleted, changes.Expired} {
+		for _, change := range removed {
+			if update, ok := c.pending[change.Key]; ok {
+				released = append(released, update)
+				c.removePending(change.Key)
+			}
+		}
+	}
+
+	if len(changes.Updated) > 0 && c.pending == nil {
+		c.pending = make(map[K]Change[K, T])
+		time.AfterFunc(c.coalesceWindow, c.flushPending)
+	}
+
+	for _, change := range changes.Updated {
+		if update, ok := c.pending[change.Key]; ok {
+			// Keep the value from before the window opened
+			change.Previous = update.Previous
+		} else {
+			c.pendingOrder = append(c.pendingOrder, change.Key)
+		}
+
+		c.pending[change.Key] = change
+	}
+
+	changes.Updated = released
+
+	return changes
+}
+
+func (c *Cache[K, T]) removePending(key K) {
+	delete(c.pending, key)
+
+	for i, k := range c.pendingOrder {
+		if k == key {
+			c.pendingOrder = append(c.pendingOrder[:i], c.pendingOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *Cache[K, T]) flushPending() {
+	c.coalesceMu.Lock()
+
+	updates := make([]Change[K, T], 0, len(c.pendingOrder))
+	for _, key := range c.pendingOrder {
+		updates = append(updates, c.pending[key])
+	}
+
+	c.pending = nil
+	c.pendingOrder = nil
+
+	c.coalesceMu.Unlock()
+
+	c.deliver(context.Background(), ChangeSet[K, T]{Updated: updates})
+}
@@ -0,0 +1,68 @@
+package simplecache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalesceWindow(t *testing.T) {
+	var mu sync.Mutex
+	updates := make([]cache.Change[string, TestStruct], 0)
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithCoalesceWindow(100 * time.Millisecond).
+		OnUpdateKeyed(func(changes []cache.Change[string, TestStruct]) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			updates = append(updates, changes...)
+		})
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	for age := 31; age <= 35; age++ {
+		c.Set("item1", TestStruct{Name: "Alice", Age: age})
+	}
+
+	mu.Lock()
+	assert.Empty(t, updates)
+	mu.Unlock()
+
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []cache.Change[string, TestStruct]{{
+		Seq:      2,
+		Key:      "item1",
+		Value:    TestStruct{Name: "Alice", Age: 35},
+		Previous: TestStruct{Name: "Alice", Age: 30},
+	}}, updates)
+}
+
+func TestCoalesceWindowReleasedOnDelete(t *testing.T) {
+	events := make([]cache.EventType, 0)
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithCoalesceWindow(time.Hour).
+		OnChanges(func(cs cache.ChangeSet[string, TestStruct]) {
+			for range cs.Updated {
+				events = append(events, cache.EventUpdated)
+			}
+
+			for range cs.Deleted {
+				events = append(events, cache.EventDeleted)
+			}
+		})
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 32})
+	c.Delete("item1")
+
+	assert.Equal(t, []cache.EventType{cache.EventUpdated, cache.EventDeleted}, events)
+}
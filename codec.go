@@ -0,0 +1,71 @@
+package simplecache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec serializes cached values for persistence and replication, see WithCodec
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// GobCodec is the default codec, interface values need to be registered with gob.Register
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(value T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+
+	return value, err
+}
+
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+// StringCodec stores strings as their raw bytes, e.g. for caches served to redis-cli with ListenRESP
+type StringCodec struct{}
+
+func (StringCodec) Encode(value string) ([]byte, error) {
+	return []byte(value), nil
+}
+
+func (StringCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// WithCodec sets how values are serialized by Save, SaveFile and the other persistence features, gob by default
+func (c *Cache[K, T]) WithCodec(codec Codec[T]) *Cache[K, T] {
+	c.codec = codec
+
+	return c
+}
+
+func (c *Cache[K, T]) valueCodec() Codec[T] {
+	if c.codec == nil {
+		return GobCodec[T]{}
+	}
+
+	return c.codec
+}
@@ -0,0 +1,60 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// csvCodec stores TestStruct as "name,age"
+type csvCodec struct{}
+
+func (csvCodec) Encode(value TestStruct) ([]byte, error) {
+	return []byte(fmt.Sprintf("%s,%d", value.Name, value.Age)), nil
+}
+
+func (csvCodec) Decode(data []byte) (TestStruct, error) {
+	name, age, _ := strings.Cut(string(data), ",")
+	n, err := strconv.Atoi(age)
+
+	return TestStruct{Name: name, Age: n}, err
+}
+
+func TestCodecs(t *testing.T) {
+	for name, codec := range map[string]cache.Codec[TestStruct]{
+		"gob":    cache.GobCodec[TestStruct]{},
+		"json":   cache.JSONCodec[TestStruct]{},
+		"custom": csvCodec{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			c := cache.New[string, TestStruct]().WithCodec(codec)
+			c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+			assert.NoError(t, c.Save(&buf))
+
+			restored := cache.New[string, TestStruct]().WithCodec(codec)
+			assert.NoError(t, restored.Load(&buf))
+
+			item1, ok := restored.Get("item1")
+			assert.True(t, ok)
+			assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+		})
+	}
+}
+
+func TestCodecDecodeError(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]().WithCodec(cache.JSONCodec[TestStruct]{})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.Save(&buf))
+
+	restored := cache.New[string, TestStruct]().WithCodec(csvCodec{})
+	assert.ErrorContains(t, restored.Load(&buf), "decode item1")
+}
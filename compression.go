@@ -0,0 +1,79 @@
+package simplecache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Compression compresses snapshots, write-ahead log records and overflow entries, see WithCompression.
+// Other algorithms such as zstd plug in by wrapping their writer and reader.
+type Compression interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Gzip compresses with compress/gzip, a zero Level uses the default compression
+type Gzip struct {
+	Level int
+}
+
+func (g Gzip) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	return gzip.NewWriterLevel(w, level)
+}
+
+func (g Gzip) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// WithCompression compresses everything the cache persists, before it is encrypted
+func (c *Cache[K, T]) WithCompression(compression Compression) *Cache[K, T] {
+	c.compression = compression
+
+	return c
+}
+
+// pack prepares a persisted blob, compressing and then encrypting it when enabled
+func (c *Cache[K, T]) pack(data []byte) ([]byte, error) {
+	if c.compression != nil {
+		var buf bytes.Buffer
+
+		zw, err := c.compression.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		data = buf.Bytes()
+	}
+
+	return c.seal(data)
+}
+
+// unpack reverses pack
+func (c *Cache[K, T]) unpack(data []byte) ([]byte, error) {
+	data, err := c.open(data)
+	if err != nil || c.compression == nil {
+		return data, err
+	}
+
+	zr, err := c.compression.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
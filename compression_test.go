@@ -0,0 +1,51 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressedSnapshot(t *testing.T) {
+	var plain, compressed bytes.Buffer
+
+	fill := func(c *cache.Cache[string, TestStruct]) *cache.Cache[string, TestStruct] {
+		for i := range 100 {
+			c.Set(fmt.Sprintf("item%d", i), TestStruct{Name: strings.Repeat("Alice", 20), Age: i})
+		}
+
+		return c
+	}
+
+	assert.NoError(t, fill(cache.New[string, TestStruct]().WithCodec(cache.JSONCodec[TestStruct]{})).Save(&plain))
+
+	c := fill(cache.New[string, TestStruct]().WithCodec(cache.JSONCodec[TestStruct]{}).WithCompression(cache.Gzip{}))
+	assert.NoError(t, c.Save(&compressed))
+	assert.Less(t, compressed.Len(), plain.Len()/5)
+
+	restored := cache.New[string, TestStruct]().WithCodec(cache.JSONCodec[TestStruct]{}).WithCompression(cache.Gzip{})
+	assert.NoError(t, restored.Load(&compressed))
+	assert.ElementsMatch(t, c.GetAll(), restored.GetAll())
+}
+
+func TestCompressedEncryptedWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithCompression(cache.Gzip{Level: 9}).WithEncryption(testKey).WithWAL(path)
+	assert.NoError(t, c.Open())
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.CloseWAL())
+
+	recovered := cache.New[string, TestStruct]().WithCompression(cache.Gzip{}).WithEncryption(testKey).WithWAL(path)
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	item1, ok := recovered.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
@@ -0,0 +1,47 @@
+package simplecache
+
+import "sync"
+
+// WithConcurrency runs the middlewares for an event type in parallel, at most n at a time, n <= 1 keeps them sequential (the default).
+// Every middleware still finishes before the next batch is delivered, so each one sees changes in order.
+// Expiry middlewares registered with OnExpiry use EventExpired, deletes and expiries delivered to delete middlewares use EventDeleted.
+func (c *Cache[K, T]) WithConcurrency(event EventType, n int) *Cache[K, T] {
+	if c.concurrency == nil {
+		c.concurrency = make(map[EventType]int)
+	}
+
+	c.concurrency[event] = n
+
+	return c
+}
+
+// fanOut runs jobs through safely, in parallel when configured for the event type, and waits for all of them
+func (c *Cache[K, T]) fanOut(event EventType, jobs []func()) {
+	n := c.concurrency[event]
+	if n <= 1 || len(jobs) <= 1 {
+		for _, job := range jobs {
+			c.safely(job)
+		}
+
+		return
+	}
+
+	sem := make(chan struct{}, n)
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			c.safely(job)
+		}()
+	}
+
+	wg.Wait()
+}
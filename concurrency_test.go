@@ -0,0 +1,132 @@
+package simplecache_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+
+	slow := func(key string, item cache.Item[TestStruct]) {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	c := cache.New[string, TestStruct]().WithInterval(50*time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		WithConcurrency(cache.EventExpired, 2)
+
+	for range 3 {
+		c.OnExpiry(slow)
+	}
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30}, time.Now().Add(10*time.Millisecond))
+	time.Sleep(300 * time.Millisecond)
+
+	c.Stop()
+
+	assert.Equal(t, int32(2), peak.Load())
+	assert.Equal(t, int32(0), running.Load())
+}
+
+func TestConcurrencySequentialByDefault(t *testing.T) {
+	var mu sync.Mutex
+	order := make([]int, 0)
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithConcurrency(cache.EventUpdated, 4)
+
+	for i := range 3 {
+		c.OnCreate(func([]TestStruct) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			order = append(order, i)
+		})
+	}
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	assert.Equal(t, []int{0, 1, 2}, order)
+}
+
+func TestConcurrencyPerEventType(t *testing.T) {
+	var running, peak atomic.Int32
+
+	slow := func([]TestStruct) {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithConcurrency(cache.EventDeleted, 4)
+
+	for range 3 {
+		c.OnCreate(slow)
+		c.OnDelete(slow)
+	}
+
+	// Only deletes run in parallel
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.Equal(t, int32(1), peak.Load())
+
+	c.Delete("item1")
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestExpiryHandlersRunOutsideLock(t *testing.T) {
+	var c *cache.Cache[string, TestStruct]
+
+	refreshed := make(chan struct{}, 2)
+
+	// Writing back from OnExpiry would deadlock if it ran under the cache lock
+	c = cache.New[string, TestStruct]().WithInterval(50*time.Millisecond).
+		WithConcurrency(cache.EventExpired, 2).
+		OnExpiry(func(key string, item cache.Item[TestStruct]) {
+			c.Set(key+"-refreshed", item.Value)
+			refreshed <- struct{}{}
+		})
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30}, time.Now().Add(10*time.Millisecond))
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, time.Now().Add(10*time.Millisecond))
+
+	go c.Maintain()
+	defer c.Stop()
+
+	for range 2 {
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatal("OnExpiry blocked")
+		}
+	}
+
+	_, ok := c.Get("item1-refreshed")
+	assert.True(t, ok)
+}
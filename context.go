@@ -0,0 +1,78 @@
+package simplecache
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+type ContextMiddleware[K comparable, T any] func(context.Context, []Change[K, T])
+
+// WithContext sets the parent of the context passed to context-aware middlewares
+func (c *Cache[K, T]) WithContext(ctx context.Context) *Cache[K, T] {
+	c.parentContext = ctx
+
+	return c
+}
+
+// WithMiddlewareTimeout bounds each notification round of context-aware middlewares
+func (c *Cache[K, T]) WithMiddlewareTimeout(d time.Duration) *Cache[K, T] {
+	c.middlewareTimeout = d
+
+	return c
+}
+
+func (c *Cache[K, T]) OnCreateContext(m ContextMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnCreateContext: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnUpdateContext(m ContextMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnUpdateContext: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnDeleteContext(m ContextMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnDeleteContext: m})
+
+	return c
+}
+
+// lifecycleContext returns the running Maintain's context, or the parent when Maintain isn't running
+func (c *Cache[K, T]) lifecycleContext() context.Context {
+	if ctx := c.lifecycle.Load(); ctx != nil {
+		return *ctx
+	}
+
+	return c.parentContext
+}
+
+// notifyContext passes ctx, carrying the values of the write that made the changes, to context-aware middlewares
+func (c *Cache[K, T]) notifyContext(ctx context.Context, middlewares []*Middlewares[K, T], changes ChangeSet[K, T]) {
+	if !slices.ContainsFunc(middlewares, func(m *Middlewares[K, T]) bool {
+		return m.OnCreateContext != nil || m.OnUpdateContext != nil || m.OnDeleteContext != nil
+	}) {
+		return
+	}
+
+	if c.middlewareTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.middlewareTimeout)
+		defer cancel()
+	}
+
+	withContext := func(m ContextMiddleware[K, T]) KeyedMiddleware[K, T] {
+		if m == nil {
+			return nil
+		}
+
+		return func(changes []Change[K, T]) { m(ctx, changes) }
+	}
+
+	c.notifyKeyed(middlewares, EventCreated, func(m *Middlewares[K, T]) KeyedMiddleware[K, T] { return withContext(m.OnCreateContext) }, changes.Created)
+	c.notifyKeyed(middlewares, EventUpdated, func(m *Middlewares[K, T]) KeyedMiddleware[K, T] { return withContext(m.OnUpdateContext) }, changes.Updated)
+	c.notifyKeyed(middlewares, EventDeleted, func(m *Middlewares[K, T]) KeyedMiddleware[K, T] { return withContext(m.OnDeleteContext) }, changes.Expired, changes.Deleted)
+}
@@ -0,0 +1,85 @@
+package simplecache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextMiddlewareCanceledOnStop(t *testing.T) {
+	contexts := make(chan context.Context, 1)
+
+	c := cache.New[string, TestStruct]().WithInterval(50 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		OnCreateContext(func(ctx context.Context, changes []cache.Change[string, TestStruct]) {
+			contexts <- ctx
+		})
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	received := <-contexts
+	assert.NoError(t, received.Err())
+
+	c.Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.ErrorIs(t, received.Err(), context.Canceled)
+}
+
+func TestContextMiddlewareTimeout(t *testing.T) {
+	type key struct{}
+
+	var deadline time.Time
+	var value any
+
+	parent := context.WithValue(context.Background(), key{}, "parent")
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithContext(parent).
+		WithMiddlewareTimeout(time.Minute).
+		OnDeleteContext(func(ctx context.Context, changes []cache.Change[string, TestStruct]) {
+			deadline, _ = ctx.Deadline()
+			value = ctx.Value(key{})
+		})
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Delete("item1")
+
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	assert.Equal(t, "parent", value)
+}
+
+func TestContextMiddlewareCarriesOrigin(t *testing.T) {
+	type key struct{}
+
+	contexts := make(chan context.Context, 1)
+
+	parent := context.WithValue(context.Background(), key{}, "parent")
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().WithAsyncDispatch(8).
+		WithInterval(time.Minute).
+		WithContext(parent).
+		OnCreateContext(func(ctx context.Context, changes []cache.Change[string, TestStruct]) {
+			contexts <- ctx
+		})
+
+	go c.Maintain()
+	defer c.Stop()
+
+	origin, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "origin"))
+	assert.NoError(t, c.SetContext(origin, "item1", TestStruct{Name: "Alice", Age: 30}))
+	cancel()
+
+	received := <-contexts
+	assert.Equal(t, "origin", received.Value(key{}))
+
+	// The write being done doesn't cancel its handlers, stopping the cache does
+	assert.NoError(t, received.Err())
+
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	assert.Equal(t, "parent", (<-contexts).Value(key{}))
+}
@@ -0,0 +1,34 @@
+package simplecache
+
+import (
+	"cmp"
+	"encoding/csv"
+	"io"
+	"slices"
+)
+
+// ExportCSV writes one row per live item ordered by key, header may be nil to leave out the header row
+func (c *Cache[K, T]) ExportCSV(w io.Writer, header func() []string, row func(key K, item Item[T]) []string) error {
+	entries := c.entries()
+	slices.SortFunc(entries, func(a, b entry[K, T]) int {
+		return cmp.Compare(keyString(a.Key), keyString(b.Key))
+	})
+
+	cw := csv.NewWriter(w)
+
+	if header != nil {
+		if err := cw.Write(header()); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		if err := cw.Write(row(e.Key, Item[T]{Value: e.Value, Expires: e.Expires})); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,36 @@
+package simplecache_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCSV(t *testing.T) {
+	var b strings.Builder
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item2", TestStruct{Name: "Bob, Jr.", Age: 25}, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40}, time.Now().Add(-time.Minute))
+
+	err := c.ExportCSV(&b,
+		func() []string { return []string{"key", "name", "age", "expires"} },
+		func(key string, item cache.Item[TestStruct]) []string {
+			expires := ""
+			if !item.Expires.IsZero() {
+				expires = item.Expires.Format(time.RFC3339)
+			}
+
+			return []string{key, item.Value.Name, strconv.Itoa(item.Value.Age), expires}
+		})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "key,name,age,expires\n"+
+		"item1,Alice,30,\n"+
+		"item2,\"Bob, Jr.\",25,2100-01-01T00:00:00Z\n", b.String())
+}
@@ -0,0 +1,105 @@
+package simplecache
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultMaxAttempts = 3
+
+// ChangesHandler is a change middleware that can fail, failed batches are retried and then dead-lettered
+type ChangesHandler[K comparable, T any] func(ChangeSet[K, T]) error
+
+// DeadLetter is a batch of changes a handler kept failing on
+type DeadLetter[K comparable, T any] struct {
+	Changes  ChangeSet[K, T]
+	Attempts int
+	Err      error
+	Time     time.Time
+}
+
+type DeadLetterMiddleware[K comparable, T any] func(DeadLetter[K, T])
+
+// DeadLetterError is reported to OnError when a batch is dead-lettered without an OnDeadLetter middleware
+type DeadLetterError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *DeadLetterError) Error() string {
+	return fmt.Sprintf("simplecache: handler failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *DeadLetterError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetry sets how many times a ChangesHandler is tried (default 3) and the delay between tries.
+// Retries block delivery of later changes, use WithAsyncDispatch to keep them off the writer.
+func (c *Cache[K, T]) WithRetry(attempts int, backoff time.Duration) *Cache[K, T] {
+	c.maxAttempts = attempts
+	c.retryBackoff = backoff
+
+	return c
+}
+
+// OnChangesE is like OnChanges but retries the batch while the handler returns an error, then passes it to OnDeadLetter
+func (c *Cache[K, T]) OnChangesE(h ChangesHandler[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnChanges: func(changes ChangeSet[K, T]) {
+		c.handle(h, changes)
+	}})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnDeadLetter(m DeadLetterMiddleware[K, T]) *Cache[K, T] {
+	c.deadLetterMu.Lock()
+	defer c.deadLetterMu.Unlock()
+
+	c.deadLetterMiddlewares = append(c.deadLetterMiddlewares, m)
+
+	return c
+}
+
+func (c *Cache[K, T]) handle(h ChangesHandler[K, T], changes ChangeSet[K, T]) {
+	attempts := c.maxAttempts
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = h(changes); err == nil {
+			return
+		}
+
+		if attempt < attempts && c.retryBackoff > 0 {
+			time.Sleep(c.retryBackoff)
+		}
+	}
+
+	c.deadLetter(DeadLetter[K, T]{
+		Changes:  changes.clone(),
+		Attempts: attempts,
+		Err:      err,
+		Time:     time.Now(),
+	})
+}
+
+func (c *Cache[K, T]) deadLetter(dl DeadLetter[K, T]) {
+	c.addMetric(metricDeadLetters, 1)
+
+	c.deadLetterMu.Lock()
+	middlewares := c.deadLetterMiddlewares
+	c.deadLetterMu.Unlock()
+
+	// Don't drop it silently when nobody collects dead letters
+	if len(middlewares) == 0 {
+		c.reportError(&DeadLetterError{Attempts: dl.Attempts, Err: dl.Err})
+		return
+	}
+
+	for _, m := range middlewares {
+		c.safely(func() { m(dl) })
+	}
+}
@@ -0,0 +1,69 @@
+package simplecache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetter(t *testing.T) {
+	errDown := errors.New("downstream unavailable")
+
+	calls := 0
+	deadLetters := make(chan cache.DeadLetter[string, TestStruct], 1)
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithRetry(4, time.Millisecond).
+		OnChangesE(func(cache.ChangeSet[string, TestStruct]) error {
+			calls++
+			return errDown
+		}).
+		OnDeadLetter(func(dl cache.DeadLetter[string, TestStruct]) { deadLetters <- dl })
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	dl := <-deadLetters
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, 4, dl.Attempts)
+	assert.ErrorIs(t, dl.Err, errDown)
+	assert.Equal(t, "item1", dl.Changes.Created[0].Key)
+	assert.Equal(t, int64(1), c.Stats().DeadLetters)
+}
+
+func TestDeadLetterRecovers(t *testing.T) {
+	calls := 0
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		OnChangesE(func(cache.ChangeSet[string, TestStruct]) error {
+			calls++
+			if calls < 2 {
+				return errors.New("flaky")
+			}
+
+			return nil
+		}).
+		OnDeadLetter(func(cache.DeadLetter[string, TestStruct]) { t.Fatal("unexpected dead letter") })
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int64(0), c.Stats().DeadLetters)
+}
+
+func TestDeadLetterReportedWithoutHandler(t *testing.T) {
+	var reported error
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithRetry(1, 0).
+		OnChangesE(func(cache.ChangeSet[string, TestStruct]) error { return errors.New("boom") }).
+		OnError(func(err error) { reported = err })
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	var dlErr *cache.DeadLetterError
+	assert.ErrorAs(t, reported, &dlErr)
+	assert.Equal(t, 1, dlErr.Attempts)
+}
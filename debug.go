@@ -0,0 +1,146 @@
+package simplecache
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultDebugSample = 20
+
+// debugConfig is the configuration shown by DebugHandler, durations as strings for reading
+type debugConfig struct {
+	Store              string `json:"store"`
+	Interval           string `json:"interval"`
+	Immediate          bool   `json:"immediate"`
+	CopyOnWrite        bool   `json:"copyOnWrite"`
+	ClockResolution    string `json:"clockResolution,omitempty"`
+	NamespaceSeparator string `json:"namespaceSeparator"`
+	EventBuffer        int    `json:"eventBuffer"`
+	ChangeFeed         int    `json:"changeFeed"`
+	AsyncQueue         int    `json:"asyncQueue,omitempty"`
+	CoalesceWindow     string `json:"coalesceWindow,omitempty"`
+	MiddlewareTimeout  string `json:"middlewareTimeout,omitempty"`
+	Middlewares        int    `json:"middlewares"`
+	Subscriptions      int    `json:"subscriptions"`
+	OverflowMax        int    `json:"overflowMax,omitempty"`
+	WAL                string `json:"wal,omitempty"`
+	HotKeys            bool   `json:"hotKeys"`
+	NamespaceStats     bool   `json:"namespaceStats"`
+}
+
+type debugKey struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// debugPage is the body of DebugHandler
+type debugPage struct {
+	Stats  Stats       `json:"stats"`
+	Config debugConfig `json:"config"`
+
+	// Distribution counts the items by key namespace, the cache keeps a single map rather than shards
+	Distribution map[string]int `json:"distribution"`
+
+	Namespaces map[string]NamespaceStats `json:"namespaces,omitempty"`
+	TopKeys    []KeyCount[string]        `json:"topKeys,omitempty"`
+	Sample     []debugKey                `json:"sample"`
+}
+
+// DebugHandler renders the stats, configuration, item distribution by namespace and a random sample of keys
+// (without values) as JSON, ?sample=n sets the sample size (default 20). Mount it under e.g. /debug/simplecache.
+func (c *Cache[K, T]) DebugHandler() http.Handler {
+	return c.secured(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := defaultDebugSample
+		if param := r.URL.Query().Get("sample"); param != "" {
+			n, err := strconv.Atoi(param)
+			if err != nil || n < 0 {
+				writeHTTPError(w, http.StatusBadRequest, fmt.Errorf("invalid sample %q", param))
+				return
+			}
+
+			size = n
+		}
+
+		writeJSON(w, http.StatusOK, c.debugPage(size))
+	}))
+}
+
+func (c *Cache[K, T]) debugPage(size int) debugPage {
+	entries := c.entries()
+
+	page := debugPage{
+		Stats:        c.Stats(),
+		Config:       c.debugConfig(),
+		Distribution: make(map[string]int),
+		Namespaces:   c.NamespaceStats(),
+		Sample:       make([]debugKey, 0, min(size, len(entries))),
+	}
+
+	for _, e := range entries {
+		page.Distribution[c.namespaceOf(e.Key)]++
+	}
+
+	// A partial Fisher-Yates shuffle picks the sample
+	for i := range min(size, len(entries)) {
+		j := i + rand.IntN(len(entries)-i)
+		entries[i], entries[j] = entries[j], entries[i]
+
+		page.Sample = append(page.Sample, debugKey{Key: keyString(entries[i].Key), Expires: entries[i].Expires})
+	}
+
+	for _, top := range c.TopKeys(10) {
+		page.TopKeys = append(page.TopKeys, KeyCount[string]{Key: keyString(top.Key), Count: top.Count})
+	}
+
+	return page
+}
+
+func (c *Cache[K, T]) debugConfig() debugConfig {
+	c.rlock()
+	store := fmt.Sprintf("%T", c.data)
+	c.RUnlock()
+
+	c.middlewaresMu.RLock()
+	middlewares := len(c.middlewares)
+	c.middlewaresMu.RUnlock()
+
+	c.subsMu.Lock()
+	subscriptions := len(c.subscriptions)
+	c.subsMu.Unlock()
+
+	c.feedMu.Lock()
+	feed := len(c.feed)
+	c.feedMu.Unlock()
+
+	return debugConfig{
+		Store:              store,
+		Interval:           c.interval.String(),
+		Immediate:          c.immediate,
+		CopyOnWrite:        c.copyOnWrite,
+		ClockResolution:    durationString(c.clockResolution),
+		NamespaceSeparator: c.namespaceSeparator,
+		EventBuffer:        c.eventBuffer,
+		ChangeFeed:         feed,
+		AsyncQueue:         cap(c.asyncQueue),
+		CoalesceWindow:     durationString(c.coalesceWindow),
+		MiddlewareTimeout:  durationString(c.middlewareTimeout),
+		Middlewares:        middlewares,
+		Subscriptions:      subscriptions,
+		OverflowMax:        c.overflowMax,
+		WAL:                c.walPath,
+		HotKeys:            c.hotKeys != nil,
+		NamespaceStats:     c.namespaceStats != nil,
+	}
+}
+
+// durationString is empty for zero, leaving unset options out
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+
+	return d.String()
+}
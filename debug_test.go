@@ -0,0 +1,53 @@
+package simplecache_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugHandler(t *testing.T) {
+	c := cache.New[string, int]().WithInterval(time.Minute).WithHotKeys(100, time.Minute)
+
+	for _, key := range []string{"acme:1", "acme:2", "acme:3", "globex:1", "config"} {
+		c.Set(key, 1, time.Now().Add(time.Hour))
+	}
+	c.Get("acme:1")
+
+	server := httptest.NewServer(c.DebugHandler())
+	defer server.Close()
+
+	var page struct {
+		Stats        cache.Stats
+		Config       map[string]any
+		Distribution map[string]int
+		TopKeys      []cache.KeyCount[string]
+		Sample       []struct {
+			Key     string
+			Expires time.Time
+		}
+	}
+
+	res, err := http.Get(server.URL + "?sample=3")
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&page))
+
+	assert.Equal(t, int64(5), page.Stats.Items)
+	assert.Equal(t, "1m0s", page.Config["interval"])
+	assert.Equal(t, "simplecache.MapStore[string,int]", page.Config["store"])
+	assert.Equal(t, map[string]int{"acme": 3, "globex": 1, "": 1}, page.Distribution)
+	assert.Equal(t, []cache.KeyCount[string]{{Key: "acme:1", Count: 1}}, page.TopKeys)
+
+	assert.Len(t, page.Sample, 3)
+	assert.NotEqual(t, page.Sample[0].Key, page.Sample[1].Key)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), page.Sample[0].Expires, time.Minute)
+
+	res, err = http.Get(server.URL + "?sample=many")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
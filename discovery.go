@@ -0,0 +1,332 @@
+package simplecache
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discoverer finds the current peers of a cluster, see DiscoverPeers
+type Discoverer interface {
+	Discover(ctx context.Context) ([]string, error)
+}
+
+// DiscoverPeers asks d for the peers every interval and calls update with them sorted whenever they change,
+// e.g. with Group.SetPeers or PartitionedClient.SetNodes. Failed lookups are reported to OnError and keep the last peers.
+// Returns a func that stops discovering.
+func (c *Cache[K, T]) DiscoverPeers(d Discoverer, interval time.Duration, update func(peers []string)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last []string
+		for {
+			peers, err := d.Discover(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				c.reportError(err)
+			default:
+				slices.Sort(peers)
+				peers = slices.Compact(peers)
+
+				if last == nil || !slices.Equal(peers, last) {
+					last = peers
+					c.safely(func() { update(slices.Clone(peers)) })
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// DNSDiscovery finds peers in DNS SRV records, such as the _port._proto.service records of a Kubernetes headless service
+type DNSDiscovery struct {
+	// Service and Proto may both be empty to look Name up directly
+	Service string
+	Proto   string
+	Name    string
+
+	// Resolver defaults to net.DefaultResolver
+	Resolver *net.Resolver
+}
+
+// Discover returns the records' targets as host:port
+func (d DNSDiscovery) Discover(ctx context.Context) ([]string, error) {
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, d.Service, d.Proto, d.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]string, len(records))
+	for i, srv := range records {
+		peers[i] = net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+	}
+
+	return peers, nil
+}
+
+const (
+	defaultMDNSAddr    = "224.0.0.251:5353"
+	defaultMDNSDomain  = "local."
+	defaultMDNSTimeout = time.Second
+
+	dnsTypeA    = 1
+	dnsTypePTR  = 12
+	dnsTypeAAAA = 28
+	dnsTypeSRV  = 33
+	dnsClassIN  = 1
+
+	// dnsUnicastResponse asks responders to answer the querying port directly
+	dnsUnicastResponse = 0x8000
+)
+
+// MDNSDiscovery finds peers advertised over multicast DNS (DNS-SD) on the local network
+type MDNSDiscovery struct {
+	// Service is the advertised service, e.g. "_simplecache._tcp"
+	Service string
+
+	// Domain defaults to "local."
+	Domain string
+
+	// Timeout is how long answers are collected, defaults to 1s
+	Timeout time.Duration
+
+	// Addr is where queries are sent, defaults to the mDNS group 224.0.0.251:5353
+	Addr string
+}
+
+// Discover browses the service and returns its instances as host:port, preferring their advertised addresses
+func (d MDNSDiscovery) Discover(ctx context.Context) ([]string, error) {
+	addr := cmp.Or(d.Addr, defaultMDNSAddr)
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = defaultMDNSTimeout
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	service := strings.TrimSuffix(d.Service, ".") + "." + cmp.Or(d.Domain, defaultMDNSDomain)
+
+	if _, err := conn.WriteToUDP(dnsQuery(service, dnsTypePTR), raddr); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	conn.SetReadDeadline(deadline)
+
+	records := dnsRecords{ptr: make(map[string][]string), srv: make(map[string]net.SRV), addrs: make(map[string][]net.IP)}
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+
+			return nil, err
+		}
+
+		// Other traffic on the group is skipped
+		records.parse(buf[:n])
+	}
+
+	var peers []string
+	for _, instance := range records.ptr[service] {
+		srv, ok := records.srv[instance]
+		if !ok {
+			continue
+		}
+
+		host := strings.TrimSuffix(srv.Target, ".")
+		if ips := records.addrs[srv.Target]; len(ips) > 0 {
+			host = ips[0].String()
+		}
+
+		peers = append(peers, net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+	}
+
+	return peers, nil
+}
+
+// dnsQuery builds a one-question query asking for a unicast response
+func dnsQuery(name string, qtype uint16) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+
+	msg = appendDNSName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+
+	return binary.BigEndian.AppendUint16(msg, dnsClassIN|dnsUnicastResponse)
+}
+
+func appendDNSName(msg []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+
+	return append(msg, 0)
+}
+
+// dnsRecords collects the DNS-SD records of the responses, names are fully qualified with a trailing dot
+type dnsRecords struct {
+	ptr   map[string][]string
+	srv   map[string]net.SRV
+	addrs map[string][]net.IP
+}
+
+var errDNSMessage = errors.New("simplecache: malformed DNS message")
+
+// parse adds the records of a response, answers and additional records alike
+func (r *dnsRecords) parse(msg []byte) error {
+	if len(msg) < 12 || msg[2]&0x80 == 0 {
+		return errDNSMessage
+	}
+
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	records := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	for range questions {
+		_, next, err := readDNSName(msg, off)
+		if err != nil || next+4 > len(msg) {
+			return errDNSMessage
+		}
+
+		off = next + 4
+	}
+
+	for range records {
+		name, next, err := readDNSName(msg, off)
+		if err != nil || next+10 > len(msg) {
+			return errDNSMessage
+		}
+
+		rtype := binary.BigEndian.Uint16(msg[next:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+
+		data := next + 10
+		if data+length > len(msg) {
+			return errDNSMessage
+		}
+
+		switch rtype {
+		case dnsTypePTR:
+			if target, _, err := readDNSName(msg, data); err == nil {
+				if !slices.Contains(r.ptr[name], target) {
+					r.ptr[name] = append(r.ptr[name], target)
+				}
+			}
+
+		case dnsTypeSRV:
+			if length < 7 {
+				return errDNSMessage
+			}
+
+			if target, _, err := readDNSName(msg, data+6); err == nil {
+				r.srv[name] = net.SRV{
+					Target:   target,
+					Port:     binary.BigEndian.Uint16(msg[data+4:]),
+					Priority: binary.BigEndian.Uint16(msg[data:]),
+					Weight:   binary.BigEndian.Uint16(msg[data+2:]),
+				}
+			}
+
+		case dnsTypeA, dnsTypeAAAA:
+			if length == net.IPv4len || length == net.IPv6len {
+				r.addrs[name] = append(r.addrs[name], net.IP(slices.Clone(msg[data:data+length])))
+			}
+		}
+
+		off = data + length
+	}
+
+	return nil
+}
+
+// readDNSName reads a possibly compressed name at off, returning it and the offset after it
+func readDNSName(msg []byte, off int) (string, int, error) {
+	var labels []string
+
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errDNSMessage
+		}
+
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+
+			return strings.Join(labels, ".") + ".", next, nil
+
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(msg) || jumps > 16 {
+				return "", 0, errDNSMessage
+			}
+
+			if next < 0 {
+				next = off + 2
+			}
+
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+			jumps++
+
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errDNSMessage
+			}
+
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package simplecache_test
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+type dnsRR struct {
+	name  string
+	rtype uint16
+	data  []byte
+}
+
+func dnsName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	return append(b, 0)
+}
+
+func srvData(port uint16, target string) []byte {
+	return append([]byte{0, 1, 0, 1, byte(port >> 8), byte(port)}, dnsName(target)...)
+}
+
+// dnsResponse answers query, repeating its question
+func dnsResponse(query []byte, answers []dnsRR) []byte {
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5
+
+	msg := append([]byte{query[0], query[1], 0x84, 0, 0, 1, 0, byte(len(answers)), 0, 0, 0, 0}, query[12:end]...)
+	for _, rr := range answers {
+		msg = append(msg, dnsName(rr.name)...)
+		msg = binary.BigEndian.AppendUint16(msg, rr.rtype)
+		msg = append(msg, 0, 1, 0, 0, 0, 120)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(rr.data)))
+		msg = append(msg, rr.data...)
+	}
+
+	return msg
+}
+
+// startFakeDNS answers every UDP query with answers
+func startFakeDNS(t *testing.T, answers []dnsRR) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			conn.WriteTo(dnsResponse(buf[:n], answers), addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestDNSDiscovery(t *testing.T) {
+	addr := startFakeDNS(t, []dnsRR{
+		{"_cache._tcp.peers.svc.", 33, srvData(6379, "node-1.peers.svc.")},
+		{"_cache._tcp.peers.svc.", 33, srvData(6379, "node-2.peers.svc.")},
+	})
+
+	d := cache.DNSDiscovery{Service: "cache", Proto: "tcp", Name: "peers.svc", Resolver: &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return net.Dial("udp", addr)
+		},
+	}}
+
+	peers, err := d.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"node-1.peers.svc:6379", "node-2.peers.svc:6379"}, peers)
+}
+
+func TestMDNSDiscovery(t *testing.T) {
+	addr := startFakeDNS(t, []dnsRR{
+		{"_simplecache._tcp.local.", 12, dnsName("node-1._simplecache._tcp.local.")},
+		{"_simplecache._tcp.local.", 12, dnsName("node-2._simplecache._tcp.local.")},
+		{"node-1._simplecache._tcp.local.", 33, srvData(7000, "host-1.local.")},
+		{"node-2._simplecache._tcp.local.", 33, srvData(7000, "host-2.local.")},
+		{"host-1.local.", 1, []byte{10, 0, 0, 1}},
+		{"_other._tcp.local.", 12, dnsName("node-3._other._tcp.local.")},
+	})
+
+	d := cache.MDNSDiscovery{Service: "_simplecache._tcp", Addr: addr, Timeout: 100 * time.Millisecond}
+
+	peers, err := d.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"10.0.0.1:7000", "host-2.local:7000"}, peers)
+}
+
+type discovererFunc func(ctx context.Context) ([]string, error)
+
+func (f discovererFunc) Discover(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+func TestDiscoverPeers(t *testing.T) {
+	var mu sync.Mutex
+	found := []string{"b", "a"}
+
+	d := discovererFunc(func(ctx context.Context) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return found, nil
+	})
+
+	updates := make(chan []string, 10)
+
+	c := cache.New[string, int]()
+	stop := c.DiscoverPeers(d, 5*time.Millisecond, func(peers []string) { updates <- peers })
+	defer stop()
+
+	assert.Equal(t, []string{"a", "b"}, <-updates)
+
+	mu.Lock()
+	found = []string{"a", "c"}
+	mu.Unlock()
+
+	// Unchanged lookups in between don't call update
+	assert.Equal(t, []string{"a", "c"}, <-updates)
+	assert.Empty(t, updates)
+}
@@ -0,0 +1,173 @@
+package simplecache
+
+import "context"
+
+type QueueFullMiddleware func()
+
+// WithAsyncDispatch runs the middlewares on a goroutine of their own fed by a queue of queueSize batches,
+// it starts with the first batch whether or not Maintain runs, Close drains and stops it
+func (c *Cache[K, T]) WithAsyncDispatch(queueSize int) *Cache[K, T] {
+	c.asyncQueue = make(chan func(), queueSize)
+
+	return c
+}
+
+// WithBlockingDispatch makes a full async queue block the producer instead of dropping the event
+func (c *Cache[K, T]) WithBlockingDispatch() *Cache[K, T] {
+	c.blockOnFullQueue = true
+
+	return c
+}
+
+func (c *Cache[K, T]) OnQueueFull(m QueueFullMiddleware) *Cache[K, T] {
+	c.queueFullMiddlewares = append(c.queueFullMiddlewares, m)
+
+	return c
+}
+
+// dispatch notifies the middlewares of changes, origin is the context of the Set or Delete making them or Background
+func (c *Cache[K, T]) dispatch(origin context.Context, changes ChangeSet[K, T]) {
+	if c.coalesceWindow > 0 {
+		changes = c.coalesce(changes)
+	}
+
+	c.deliver(origin, changes)
+}
+
+func (c *Cache[K, T]) deliver(origin context.Context, changes ChangeSet[K, T]) {
+	if changes.Empty() {
+		return
+	}
+
+	c.record(changes)
+
+	if c.asyncQueue == nil {
+		c.notify(origin, changes, false)
+		return
+	}
+
+	// The change buffers are reused on the next tick
+	changes = changes.clone()
+
+	c.enqueue(func() {
+		c.notify(origin, changes, true)
+	})
+}
+
+// originContext is the Maintain context with the values of origin, the Set or Delete that made the changes, on top.
+// Cancellation comes from Maintain only, as origin is usually done by the time the async queue gets to the changes.
+type originContext struct {
+	context.Context
+	origin context.Context
+}
+
+func (o originContext) Value(key any) any {
+	if value := o.origin.Value(key); value != nil {
+		return value
+	}
+
+	return o.Context.Value(key)
+}
+
+// dispatchContext is the context the middlewares of changes made in origin run in
+func (c *Cache[K, T]) dispatchContext(origin context.Context) context.Context {
+	// Changes of ticks, flushes and loads have no origin
+	if origin == context.Background() {
+		return c.lifecycleContext()
+	}
+
+	return originContext{Context: c.lifecycleContext(), origin: origin}
+}
+
+func (c *Cache[K, T]) enqueue(job func()) {
+	// Held across the send, so Close can't stop the dispatcher between the check and the send
+	c.dispatcherMu.RLock()
+
+	if c.dispatcherDone == nil && !c.dispatcherClosed {
+		c.dispatcherMu.RUnlock()
+		c.startDispatcher()
+		c.dispatcherMu.RLock()
+	}
+
+	if c.dispatcherClosed {
+		c.dispatcherMu.RUnlock()
+
+		// Closed, nothing drains the queue anymore
+		c.safely(job)
+		return
+	}
+
+	defer c.dispatcherMu.RUnlock()
+
+	select {
+	case c.asyncQueue <- job:
+		return
+	default:
+	}
+
+	for _, m := range c.queueFullMiddlewares {
+		c.safely(m)
+	}
+
+	if c.blockOnFullQueue {
+		c.asyncQueue <- job
+		return
+	}
+
+	c.dropEvent()
+}
+
+// startDispatcher starts the goroutine draining the queue on first use, unless Close stopped it
+func (c *Cache[K, T]) startDispatcher() {
+	c.dispatcherMu.Lock()
+	defer c.dispatcherMu.Unlock()
+
+	if c.dispatcherClosed {
+		return
+	}
+
+	if c.dispatcherDone == nil {
+		done, stopped := make(chan struct{}), make(chan struct{})
+		c.dispatcherDone, c.dispatcherStopped = done, stopped
+
+		go func() {
+			defer close(stopped)
+
+			c.runDispatcher(done)
+		}()
+	}
+}
+
+// stopDispatcher delivers what is queued and stops the dispatcher, later batches are delivered by the writer.
+// Taking the write lock waits for the sends in progress, the dispatcher drains them before returning.
+func (c *Cache[K, T]) stopDispatcher() {
+	c.dispatcherMu.Lock()
+	c.dispatcherClosed = true
+	done, stopped := c.dispatcherDone, c.dispatcherStopped
+	c.dispatcherMu.Unlock()
+
+	if done != nil {
+		close(done)
+		<-stopped
+	}
+}
+
+func (c *Cache[K, T]) runDispatcher(done <-chan struct{}) {
+	for {
+		select {
+		case job := <-c.asyncQueue:
+			c.safely(job)
+
+		case <-done:
+			// Drain what was queued before Close
+			for {
+				select {
+				case job := <-c.asyncQueue:
+					c.safely(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
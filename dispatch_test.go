@@ -0,0 +1,150 @@
+package simplecache_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncDispatch(t *testing.T) {
+	var mu sync.Mutex
+	createdItems := make([]TestStruct, 0)
+
+	c := cache.New[string, TestStruct]().WithInterval(50 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		WithAsyncDispatch(10).
+		OnCreate(func(items []TestStruct) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			createdItems = append(createdItems, items...)
+		})
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	time.Sleep(200 * time.Millisecond)
+
+	c.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 30}}, createdItems)
+}
+
+func TestAsyncDispatchQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	var full atomic.Int32
+
+	c := cache.New[string, TestStruct]().WithInterval(50 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		WithAsyncDispatch(1).
+		OnCreate(func(items []TestStruct) { <-release }).
+		OnQueueFull(func() { full.Add(1) })
+
+	go c.Maintain()
+
+	// First batch blocks the dispatcher, second fills the queue, third is dropped
+	for _, name := range []string{"item1", "item2", "item3"} {
+		c.Set(name, TestStruct{Name: name})
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	close(release)
+	c.Stop()
+
+	assert.GreaterOrEqual(t, full.Load(), int32(1))
+	assert.GreaterOrEqual(t, c.Stats().DroppedEvents, int64(1))
+}
+
+func TestAsyncDispatchWithoutMaintain(t *testing.T) {
+	created := make(chan string, 16)
+	release := make(chan struct{})
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		WithAsyncDispatch(1).WithBlockingDispatch().
+		OnCreateKeyed(func(changes []cache.Change[string, TestStruct]) {
+			<-release
+			created <- changes[0].Key
+		})
+
+	// The first batch holds the dispatcher, the second fills the queue and the third waits for room
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for _, key := range []string{"item1", "item2", "item3"} {
+			c.Set(key, TestStruct{Name: key})
+		}
+	}()
+
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Set blocked on the queue")
+	}
+
+	for _, key := range []string{"item1", "item2", "item3"} {
+		select {
+		case got := <-created:
+			assert.Equal(t, key, got)
+		case <-time.After(time.Second):
+			t.Fatal("event not delivered")
+		}
+	}
+
+	// Queued events are delivered by Close, later ones synchronously
+	assert.NoError(t, c.Close())
+
+	c.Set("item4", TestStruct{Name: "item4"})
+	assert.Equal(t, "item4", <-created)
+}
+
+func TestAsyncDispatchCloseDuringWrites(t *testing.T) {
+	var delivered atomic.Int64
+
+	c := cache.New[int, int]().WithImmediateNotifications().
+		WithAsyncDispatch(1).WithBlockingDispatch().
+		OnCreate(func(items []int) {
+			time.Sleep(time.Millisecond)
+			delivered.Add(int64(len(items)))
+		})
+
+	// Writers blocked on the full queue while Close runs neither hang nor lose their batch
+	var writers sync.WaitGroup
+	for w := range 4 {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+
+			for i := range 25 {
+				c.Set(w*100+i, i)
+			}
+		}()
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		assert.NoError(t, c.Close())
+	}()
+
+	writers.Wait()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked")
+	}
+
+	assert.Equal(t, int64(100), delivered.Load())
+}
@@ -0,0 +1,94 @@
+package simplecache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// KeyProvider returns the AES key (16, 24 or 32 bytes) used for encryption at rest, e.g. a data key unwrapped by a KMS
+type KeyProvider func() ([]byte, error)
+
+// WithEncryption encrypts snapshots, the write-ahead log and overflow entries with AES-GCM
+func (c *Cache[K, T]) WithEncryption(key []byte) *Cache[K, T] {
+	return c.WithKeyProvider(func() ([]byte, error) { return key, nil })
+}
+
+// WithKeyProvider is WithEncryption with the key fetched on first use, it is kept for the lifetime of the cache
+func (c *Cache[K, T]) WithKeyProvider(p KeyProvider) *Cache[K, T] {
+	c.keyProvider = p
+
+	return c
+}
+
+func (c *Cache[K, T]) encrypted() bool {
+	return c.keyProvider != nil
+}
+
+func (c *Cache[K, T]) aeadCipher() (cipher.AEAD, error) {
+	c.aeadMu.Lock()
+	defer c.aeadMu.Unlock()
+
+	if c.aead != nil {
+		return c.aead, nil
+	}
+
+	key, err := c.keyProvider()
+	if err != nil {
+		return nil, fmt.Errorf("simplecache: encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.aead, err = cipher.NewGCM(block)
+
+	return c.aead, err
+}
+
+// seal encrypts data when encryption is enabled, prefixing the nonce
+func (c *Cache[K, T]) seal(data []byte) ([]byte, error) {
+	if !c.encrypted() {
+		return data, nil
+	}
+
+	aead, err := c.aeadCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func (c *Cache[K, T]) open(data []byte) ([]byte, error) {
+	if !c.encrypted() {
+		return data, nil
+	}
+
+	aead, err := c.aeadCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("simplecache: decrypt: data too short")
+	}
+
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("simplecache: decrypt: %w", err)
+	}
+
+	return plain, nil
+}
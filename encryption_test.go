@@ -0,0 +1,65 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testKey  = bytes.Repeat([]byte{1}, 32)
+	otherKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestEncryptedSnapshot(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]().WithEncryption(testKey)
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.Save(&buf))
+	assert.NotContains(t, buf.String(), "Alice")
+
+	assert.ErrorContains(t, cache.New[string, TestStruct]().WithEncryption(otherKey).Load(bytes.NewReader(buf.Bytes())), "decrypt")
+
+	restored := cache.New[string, TestStruct]().WithKeyProvider(func() ([]byte, error) { return testKey, nil })
+	assert.NoError(t, restored.Load(&buf))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
+
+func TestEncryptedWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithEncryption(testKey).WithWAL(path)
+	assert.NoError(t, c.Open())
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.CloseWAL())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "Alice")
+
+	recovered := cache.New[string, TestStruct]().WithEncryption(testKey).WithWAL(path)
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	item1, ok := recovered.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
+
+func TestKeyProviderError(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]().WithKeyProvider(func() ([]byte, error) { return nil, errors.New("kms unavailable") })
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	assert.ErrorContains(t, c.Save(&buf), "kms unavailable")
+}
@@ -0,0 +1,13 @@
+package simplecache
+
+import "fmt"
+
+// PanicError is reported to OnError when a middleware panics
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("simplecache: middleware panicked: %v", e.Value)
+}
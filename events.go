@@ -0,0 +1,343 @@
+package simplecache
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EventType int
+
+const (
+	EventCreated EventType = iota
+	EventUpdated
+	EventDeleted
+	EventExpired
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventCreated:
+		return "created"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	case EventExpired:
+		return "expired"
+	}
+
+	return "unknown"
+}
+
+type Event[K comparable, T any] struct {
+	Seq      uint64
+	Type     EventType
+	Key      K
+	Value    T
+	Previous T
+	Time     time.Time
+}
+
+type subscription[K comparable, T any] struct {
+	ch    chan Event[K, T]
+	match func(K, T) bool
+
+	// policy applies when ch is full, closed and disconnected are guarded by subsMu
+	policy       SlowConsumerPolicy
+	closed       bool
+	disconnected bool
+
+	// SlowConsumerBuffer only, the events waiting for room in ch and the goroutine moving them
+	mu       sync.Mutex
+	backlog  []Event[K, T]
+	draining bool
+	done     chan struct{}
+	drained  sync.WaitGroup
+}
+
+const defaultEventBuffer = 64
+
+// SlowConsumerPolicy decides what happens when the consumer of a network stream falls behind, see WithStreamFlowControl
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerDrop drops the events the stream has no room for
+	SlowConsumerDrop SlowConsumerPolicy = iota
+
+	// SlowConsumerBuffer queues the events without bound until the consumer catches up
+	SlowConsumerBuffer
+
+	// SlowConsumerDisconnect ends the stream, the consumer reconnects and resumes from the last sequence number it saw
+	SlowConsumerDisconnect
+)
+
+func (c *Cache[K, T]) WithEventBuffer(n int) *Cache[K, T] {
+	c.eventBuffer = n
+
+	return c
+}
+
+// Events returns a channel receiving every change in sequence order, events are dropped when the buffer is full
+func (c *Cache[K, T]) Events() <-chan Event[K, T] {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	if c.events == nil {
+		c.events = &subscription[K, T]{ch: make(chan Event[K, T], c.eventBuffer)}
+		c.subscriptions = append(c.subscriptions, c.events)
+	}
+
+	return c.events.ch
+}
+
+// Watch returns a channel receiving changes to key only, cancel stops the watch and closes the channel
+func (c *Cache[K, T]) Watch(key K) (<-chan Event[K, T], func()) {
+	return c.watch(func(k K, _ T) bool { return k == key })
+}
+
+// WatchPrefix watches all keys starting with prefix, non-string keys are matched on their fmt representation
+func (c *Cache[K, T]) WatchPrefix(prefix string) (<-chan Event[K, T], func()) {
+	return c.watch(func(k K, _ T) bool { return strings.HasPrefix(keyString(k), prefix) })
+}
+
+// WatchPattern watches all keys matching a glob pattern as understood by path.Match, e.g. "user:*:profile"
+func (c *Cache[K, T]) WatchPattern(pattern string) (<-chan Event[K, T], func(), error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, nil, err
+	}
+
+	ch, cancel := c.watch(func(k K, _ T) bool {
+		matched, _ := path.Match(pattern, keyString(k))
+		return matched
+	})
+
+	return ch, cancel, nil
+}
+
+// WatchFunc watches all changes whose key and value satisfy filter
+func (c *Cache[K, T]) WatchFunc(filter func(key K, value T) bool) (<-chan Event[K, T], func()) {
+	return c.watch(filter)
+}
+
+// WithStreamFlowControl bounds the events in flight to each consumer of ReplicationHandler, SSEHandler and
+// WebSocketHandler, policy decides what happens once a consumer has maxInFlight events it hasn't taken yet.
+// Publishing never waits for a stream, so a slow follower can't stall writers or the janitor.
+// Streams default to the event buffer and SlowConsumerDrop.
+func (c *Cache[K, T]) WithStreamFlowControl(maxInFlight int, policy SlowConsumerPolicy) *Cache[K, T] {
+	c.streamInFlight = maxInFlight
+	c.streamPolicy = policy
+
+	return c
+}
+
+func (c *Cache[K, T]) watch(match func(K, T) bool) (<-chan Event[K, T], func()) {
+	s := &subscription[K, T]{ch: make(chan Event[K, T], c.eventBuffer), match: match}
+
+	return s.ch, c.subscribe(s)
+}
+
+// streamWatch is watch for a network stream, following WithStreamFlowControl
+func (c *Cache[K, T]) streamWatch(match func(K, T) bool) (<-chan Event[K, T], func()) {
+	s := c.newStream(0)
+	s.match = match
+
+	return s.ch, c.subscribe(s)
+}
+
+// newStream returns a subscription following WithStreamFlowControl, with room for replay more events
+func (c *Cache[K, T]) newStream(replay int) *subscription[K, T] {
+	s := &subscription[K, T]{
+		ch:     make(chan Event[K, T], replay+cmp.Or(c.streamInFlight, c.eventBuffer)),
+		policy: c.streamPolicy,
+	}
+
+	if s.policy == SlowConsumerBuffer {
+		s.done = make(chan struct{})
+	}
+
+	return s
+}
+
+// subscribe registers s and returns the func cancelling it
+func (c *Cache[K, T]) subscribe(s *subscription[K, T]) func() {
+	c.subsMu.Lock()
+	c.subscriptions = append(c.subscriptions, s)
+	c.subsMu.Unlock()
+
+	return func() {
+		c.subsMu.Lock()
+		defer c.subsMu.Unlock()
+
+		c.unsubscribe(s)
+	}
+}
+
+// unsubscribe removes s and closes its channel, called with subsMu held
+func (c *Cache[K, T]) unsubscribe(s *subscription[K, T]) {
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	c.subscriptions = slices.DeleteFunc(c.subscriptions, func(other *subscription[K, T]) bool {
+		return other == s
+	})
+
+	// The backlog goroutine may be sending on ch
+	if s.done != nil {
+		close(s.done)
+		s.drained.Wait()
+	}
+
+	close(s.ch)
+}
+
+func (c *Cache[K, T]) publish(changes ChangeSet[K, T], now time.Time) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	if len(c.subscriptions) == 0 {
+		return
+	}
+
+	c.publishAll(EventCreated, changes.Created, now)
+	c.publishAll(EventUpdated, changes.Updated, now)
+	c.publishAll(EventDeleted, changes.Deleted, now)
+	c.publishAll(EventExpired, changes.Expired, now)
+
+	var disconnected []*subscription[K, T]
+	for _, s := range c.subscriptions {
+		if s.disconnected {
+			disconnected = append(disconnected, s)
+		}
+	}
+
+	for _, s := range disconnected {
+		c.unsubscribe(s)
+	}
+}
+
+func newEvent[K comparable, T any](t EventType, change Change[K, T], now time.Time) Event[K, T] {
+	return Event[K, T]{
+		Seq:      change.Seq,
+		Type:     t,
+		Key:      change.Key,
+		Value:    change.Value,
+		Previous: change.Previous,
+		Time:     now,
+	}
+}
+
+// eventsOf lists a batch of changes as events, in the order they are delivered
+func eventsOf[K comparable, T any](changes ChangeSet[K, T], now time.Time) []Event[K, T] {
+	var events []Event[K, T]
+	for _, group := range []struct {
+		t       EventType
+		changes []Change[K, T]
+	}{
+		{EventCreated, changes.Created},
+		{EventUpdated, changes.Updated},
+		{EventDeleted, changes.Deleted},
+		{EventExpired, changes.Expired},
+	} {
+		for _, change := range group.changes {
+			events = append(events, newEvent(group.t, change, now))
+		}
+	}
+
+	return events
+}
+
+func (c *Cache[K, T]) publishAll(t EventType, changes []Change[K, T], now time.Time) {
+	for _, change := range changes {
+		event := newEvent(t, change, now)
+
+		for _, s := range c.subscriptions {
+			if s.disconnected || (s.match != nil && !s.match(change.Key, change.Value)) {
+				continue
+			}
+
+			c.send(s, event)
+		}
+	}
+}
+
+// send hands event to s without blocking, applying its policy when s is full
+func (c *Cache[K, T]) send(s *subscription[K, T], event Event[K, T]) {
+	if s.policy == SlowConsumerBuffer {
+		if s.enqueue(event) {
+			c.addMetric(metricSlowConsumerBuffered, 1)
+		}
+
+		return
+	}
+
+	select {
+	case s.ch <- event:
+		return
+	default:
+	}
+
+	if s.policy == SlowConsumerDisconnect {
+		s.disconnected = true
+		c.addMetric(metricSlowConsumerDisconnects, 1)
+
+		return
+	}
+
+	c.dropEvent()
+}
+
+// enqueue sends event to ch or, once ch is full, queues it behind the backlog, reporting whether it was queued
+func (s *subscription[K, T]) enqueue(event Event[K, T]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Going around the backlog would reorder the events
+	if !s.draining {
+		select {
+		case s.ch <- event:
+			return false
+		default:
+		}
+	}
+
+	s.backlog = append(s.backlog, event)
+
+	if !s.draining {
+		s.draining = true
+		s.drained.Add(1)
+		go s.drain()
+	}
+
+	return true
+}
+
+// drain moves the backlog to ch as the consumer makes room, until it is empty or the subscription is cancelled
+func (s *subscription[K, T]) drain() {
+	defer s.drained.Done()
+
+	for {
+		s.mu.Lock()
+		if len(s.backlog) == 0 {
+			s.draining = false
+			s.mu.Unlock()
+			return
+		}
+
+		event := s.backlog[0]
+		s.backlog[0] = Event[K, T]{}
+		s.backlog = s.backlog[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.ch <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package simplecache_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	c := cache.New[string, TestStruct]().
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		WithImmediateNotifications()
+
+	events := c.Events()
+	assert.Equal(t, events, c.Events())
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Delete("item1")
+
+	created := <-events
+	assert.Equal(t, cache.EventCreated, created.Type)
+	assert.Equal(t, "item1", created.Key)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, created.Value)
+	assert.False(t, created.Time.IsZero())
+
+	updated := <-events
+	assert.Equal(t, cache.EventUpdated, updated.Type)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 31}, updated.Value)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, updated.Previous)
+
+	deleted := <-events
+	assert.Equal(t, cache.EventDeleted, deleted.Type)
+	assert.Equal(t, "deleted", deleted.Type.String())
+}
+
+func TestEventsInSequenceOrder(t *testing.T) {
+	c := cache.New[string, int]().WithImmediateNotifications().WithChangeFeed().WithAsyncDispatch(16).WithEventBuffer(2000)
+	defer c.Close()
+
+	events := c.Events()
+
+	// Concurrent writers get their sequence numbers and reach subscribers in the same order
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range 200 {
+				c.Set(strconv.Itoa(w)+":"+strconv.Itoa(i), i)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range 1600 {
+		select {
+		case event := <-events:
+			assert.Equal(t, uint64(i+1), event.Seq)
+		case <-time.After(time.Second):
+			t.Fatalf("event %d not published", i+1)
+		}
+	}
+}
+
+func TestEventsBufferFull(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().WithEventBuffer(1)
+	events := c.Events()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, int64(1), c.Stats().DroppedEvents)
+}
+
+func TestWatch(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithImmediateNotifications()
+
+	events, cancel := c.Watch("item1")
+
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	event := <-events
+	assert.Equal(t, cache.EventCreated, event.Type)
+	assert.Equal(t, "item1", event.Key)
+	assert.Empty(t, events)
+
+	cancel()
+	cancel()
+
+	c.Delete("item1")
+
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestWatchPrefixAndPattern(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithImmediateNotifications()
+
+	users, cancelUsers := c.WatchPrefix("user:")
+	defer cancelUsers()
+
+	profiles, cancelProfiles, err := c.WatchPattern("user:*:profile")
+	assert.NoError(t, err)
+	defer cancelProfiles()
+
+	c.Set("order:1", TestStruct{Name: "Order"})
+	c.Set("user:1:settings", TestStruct{Name: "Settings"})
+	c.Set("user:1:profile", TestStruct{Name: "Alice"})
+
+	assert.Equal(t, "user:1:settings", (<-users).Key)
+	assert.Equal(t, "user:1:profile", (<-users).Key)
+	assert.Empty(t, users)
+
+	assert.Equal(t, "user:1:profile", (<-profiles).Key)
+	assert.Empty(t, profiles)
+
+	_, _, err = c.WatchPattern("[")
+	assert.Error(t, err)
+}
+
+func TestWatchFunc(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithImmediateNotifications()
+
+	events, cancel := c.WatchFunc(func(key string, value TestStruct) bool { return value.Name == "Bob" })
+	defer cancel()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	assert.Equal(t, "item2", (<-events).Key)
+	assert.Empty(t, events)
+}
@@ -44,7 +44,7 @@ func main() {
 		return a.Name == b.Name && a.Age == b.Age
 	}
 
-	c := cache.New[TestStruct]().
+	c := cache.New[string, TestStruct]().
 		Equals(eqFunc).                // equality check, used to determine updates
 		WithInterval(1 * time.Second). // ticker interval to check for cache changes
 		OnBeforeTick(func() {
@@ -70,7 +70,7 @@ func main() {
 
 	time.Sleep(5 * time.Second) // simulate delay
 
-	fmt.Println(c.Metrics)
+	fmt.Printf("%+v\n", c.Stats())
 	s3 := NewTestStruct("S3", 3)
 	c.Set(s3.Name, s3)
 	c.Get(s1.Name)  // get an item to "hit"
@@ -83,10 +83,10 @@ func main() {
 
 	time.Sleep(3 * time.Second)
 
-	fmt.Println(c.Metrics)
+	fmt.Printf("%+v\n", c.Stats())
 	c.Delete(s1.Name) // delete existing item
 
-	fmt.Println(c.Metrics)
+	fmt.Printf("%+v\n", c.Stats())
 
 	time.Sleep(10 * time.Second) // artificial delay
 	fmt.Println("finished")
@@ -0,0 +1,128 @@
+package simplecache
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrChangesTruncated = errors.New("simplecache: changes after the requested sequence are no longer retained")
+
+const defaultChangeFeedRetention = 1024
+
+// WithChangeFeed keeps the most recent changes so they can be read back with Changes
+func (c *Cache[K, T]) WithChangeFeed() *Cache[K, T] {
+	return c.WithChangeFeedRetention(defaultChangeFeedRetention)
+}
+
+// WithChangeFeedRetention enables the change feed keeping the last n changes in a ring buffer
+func (c *Cache[K, T]) WithChangeFeedRetention(n int) *Cache[K, T] {
+	c.feedMu.Lock()
+	defer c.feedMu.Unlock()
+
+	c.feed = make([]Event[K, T], n)
+	c.feedLen = 0
+
+	return c
+}
+
+// LastSeq returns the sequence number of the most recent change
+func (c *Cache[K, T]) LastSeq() uint64 {
+	c.feedMu.Lock()
+	defer c.feedMu.Unlock()
+
+	return c.seq
+}
+
+// Changes returns the retained changes with a sequence number above since, in order.
+// ErrChangesTruncated is returned when some of them were already discarded, or since is ahead of the feed as after a restart.
+func (c *Cache[K, T]) Changes(since uint64) ([]Event[K, T], error) {
+	c.feedMu.Lock()
+	defer c.feedMu.Unlock()
+
+	return c.changesSince(since)
+}
+
+// EventsSince replays retained changes after since and then follows live changes, so a reconnecting
+// consumer can resume from the last sequence number it saw. When changes after since are no longer
+// retained, or since was numbered before a restart, it returns ErrChangesTruncated and the consumer has to resync from scratch.
+func (c *Cache[K, T]) EventsSince(since uint64) (<-chan Event[K, T], func(), error) {
+	return c.eventsSince(since, false)
+}
+
+// streamSince is EventsSince for a network stream, following WithStreamFlowControl
+func (c *Cache[K, T]) streamSince(since uint64) (<-chan Event[K, T], func(), error) {
+	return c.eventsSince(since, true)
+}
+
+func (c *Cache[K, T]) eventsSince(since uint64, stream bool) (<-chan Event[K, T], func(), error) {
+	c.feedMu.Lock()
+	defer c.feedMu.Unlock()
+
+	replay, err := c.changesSince(since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s := &subscription[K, T]{ch: make(chan Event[K, T], len(replay)+c.eventBuffer)}
+	if stream {
+		s = c.newStream(len(replay))
+	}
+
+	for _, event := range replay {
+		s.ch <- event
+	}
+
+	// Recording and publishing share feedMu, every change after the replay reaches s live
+	return s.ch, c.subscribe(s), nil
+}
+
+func (c *Cache[K, T]) changesSince(since uint64) ([]Event[K, T], error) {
+	switch {
+	case since == c.seq:
+		return nil, nil
+
+	// Numbered before a restart, what changed since can't be told
+	case since > c.seq:
+		return nil, ErrChangesTruncated
+	}
+
+	oldest := c.seq - uint64(c.feedLen) + 1
+	if c.feedLen == 0 || oldest > since+1 {
+		return nil, ErrChangesTruncated
+	}
+
+	res := make([]Event[K, T], 0, c.seq-since)
+	for seq := since + 1; seq <= c.seq; seq++ {
+		res = append(res, c.feed[seq%uint64(len(c.feed))])
+	}
+
+	return res, nil
+}
+
+// record assigns sequence numbers in place, appends the changes to the feed and publishes them to Events.
+// Publishing without blocking under the same lock keeps concurrent writers from reaching subscribers out of order.
+func (c *Cache[K, T]) record(changes ChangeSet[K, T]) {
+	c.feedMu.Lock()
+	defer c.feedMu.Unlock()
+
+	now := time.Now()
+
+	c.recordAll(EventCreated, changes.Created, now)
+	c.recordAll(EventUpdated, changes.Updated, now)
+	c.recordAll(EventDeleted, changes.Deleted, now)
+	c.recordAll(EventExpired, changes.Expired, now)
+
+	c.publish(changes, now)
+}
+
+func (c *Cache[K, T]) recordAll(t EventType, changes []Change[K, T], now time.Time) {
+	for i := range changes {
+		c.seq++
+		changes[i].Seq = c.seq
+
+		if len(c.feed) > 0 {
+			c.feed[c.seq%uint64(len(c.feed))] = newEvent(t, changes[i], now)
+			c.feedLen = min(c.feedLen+1, len(c.feed))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package simplecache_test
+
+import (
+	"fmt"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeFeed(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().WithChangeFeed()
+	events := c.Events()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Delete("item1")
+
+	assert.Equal(t, uint64(3), c.LastSeq())
+	assert.Equal(t, uint64(1), (<-events).Seq)
+
+	changes, err := c.Changes(1)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, uint64(2), changes[0].Seq)
+	assert.Equal(t, cache.EventUpdated, changes[0].Type)
+	assert.Equal(t, uint64(3), changes[1].Seq)
+	assert.Equal(t, cache.EventDeleted, changes[1].Type)
+
+	changes, err = c.Changes(3)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestChangeFeedTruncated(t *testing.T) {
+	c := cache.New[string, int]().WithImmediateNotifications().WithChangeFeed()
+
+	for i := 0; i < 1100; i++ {
+		c.Set(fmt.Sprint(i), i)
+	}
+
+	_, err := c.Changes(0)
+	assert.ErrorIs(t, err, cache.ErrChangesTruncated)
+
+	changes, err := c.Changes(76)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1024)
+	assert.Equal(t, uint64(77), changes[0].Seq)
+	assert.Equal(t, uint64(1100), changes[1023].Seq)
+}
+
+func TestChangeFeedRetention(t *testing.T) {
+	c := cache.New[string, int]().WithImmediateNotifications().WithChangeFeedRetention(3)
+
+	for i := 0; i < 5; i++ {
+		c.Set(fmt.Sprint(i), i)
+	}
+
+	changes, err := c.Changes(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 4}, []int{changes[0].Value, changes[1].Value, changes[2].Value})
+
+	_, err = c.Changes(1)
+	assert.ErrorIs(t, err, cache.ErrChangesTruncated)
+}
+
+func TestEventsSince(t *testing.T) {
+	c := cache.New[string, int]().WithImmediateNotifications().WithChangeFeedRetention(10)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	events, cancel, err := c.EventsSince(1)
+	assert.NoError(t, err)
+	defer cancel()
+
+	c.Set("d", 4)
+
+	for _, want := range []uint64{2, 3, 4} {
+		assert.Equal(t, want, (<-events).Seq)
+	}
+	assert.Empty(t, events)
+
+	for i := 0; i < 20; i++ {
+		c.Set("e", i)
+	}
+
+	_, _, err = c.EventsSince(4)
+	assert.ErrorIs(t, err, cache.ErrChangesTruncated)
+}
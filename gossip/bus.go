@@ -0,0 +1,190 @@
+// Package gossip is a simplecache.InvalidationBus on hashicorp/memberlist, peers discover each other and gossip invalidations without a broker.
+package gossip
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	cache "github.com/kamludwinski2/simplecache"
+)
+
+// broadcastOverhead leaves room in a UDP packet for memberlist's headers, labels and encryption
+const broadcastOverhead = 128
+
+// Bus gossips invalidations to the other members of a memberlist cluster, suited to small clusters.
+// Delivery is at-most-once, a peer joining late or partitioned away misses what was gossiped meanwhile.
+type Bus[K comparable] struct {
+	list  atomic.Pointer[memberlist.Memberlist]
+	queue *memberlist.TransmitLimitedQueue
+
+	// maxBroadcast is the largest message memberlist piggybacks on its gossip, it never sends bigger ones
+	maxBroadcast int
+
+	mu       sync.Mutex
+	handlers map[int]func(cache.Invalidation[K])
+	nextID   int
+}
+
+// New starts a member with config (e.g. memberlist.DefaultLANConfig with a unique Name) and joins the cluster through peers.
+// A first member passes no peers. config.Delegate is set by New.
+func New[K comparable](config *memberlist.Config, peers ...string) (*Bus[K], error) {
+	b := &Bus[K]{
+		handlers:     make(map[int]func(cache.Invalidation[K])),
+		maxBroadcast: config.UDPBufferSize - broadcastOverhead,
+	}
+
+	// Set up before the member starts gossiping, the list is only known once it has
+	b.queue = &memberlist.TransmitLimitedQueue{
+		NumNodes: func() int {
+			if list := b.list.Load(); list != nil {
+				return list.NumMembers()
+			}
+
+			return 1
+		},
+		RetransmitMult: config.RetransmitMult,
+	}
+
+	config.Delegate = (*delegate[K])(b)
+
+	list, err := memberlist.Create(config)
+	if err != nil {
+		return nil, err
+	}
+
+	b.list.Store(list)
+
+	if len(peers) > 0 {
+		if _, err := list.Join(peers); err != nil {
+			list.Shutdown()
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// Publish queues inv to be gossiped with the next rounds. Invalidations too big for a packet are split by keys,
+// a single key too big on its own is sent over TCP to every other member.
+func (b *Bus[K]) Publish(inv cache.Invalidation[K]) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(inv); err != nil {
+		return err
+	}
+
+	switch {
+	case buf.Len() <= b.maxBroadcast:
+		b.queue.QueueBroadcast(broadcast(buf.Bytes()))
+		return nil
+
+	case len(inv.Keys) > 1:
+		half := len(inv.Keys) / 2
+		first, second := inv, inv
+		first.Keys, second.Keys = inv.Keys[:half], inv.Keys[half:]
+
+		return errors.Join(b.Publish(first), b.Publish(second))
+	}
+
+	list := b.list.Load()
+
+	var errs []error
+	for _, member := range list.Members() {
+		if member.Name != list.LocalNode().Name {
+			errs = append(errs, list.SendReliable(member, buf.Bytes()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (b *Bus[K]) Subscribe(fn func(cache.Invalidation[K])) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.handlers[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.handlers, id)
+	}, nil
+}
+
+// Addr is the address other members join through
+func (b *Bus[K]) Addr() string {
+	return b.list.Load().LocalNode().Address()
+}
+
+// Members returns the names of the members currently alive, including this one
+func (b *Bus[K]) Members() []string {
+	var names []string
+	for _, member := range b.list.Load().Members() {
+		names = append(names, member.Name)
+	}
+
+	return names
+}
+
+// Close leaves the cluster, waiting up to timeout for the others to be told, and stops the member
+func (b *Bus[K]) Close(timeout time.Duration) error {
+	list := b.list.Load()
+
+	return errors.Join(list.Leave(timeout), list.Shutdown())
+}
+
+// delegate receives the gossip of the cluster on behalf of a Bus
+type delegate[K comparable] Bus[K]
+
+func (d *delegate[K]) NodeMeta(limit int) []byte {
+	return nil
+}
+
+// NotifyMsg delivers a gossiped invalidation, messages that don't decode are dropped
+func (d *delegate[K]) NotifyMsg(msg []byte) {
+	var inv cache.Invalidation[K]
+	if err := gob.NewDecoder(bytes.NewReader(msg)).Decode(&inv); err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	handlers := make([]func(cache.Invalidation[K]), 0, len(d.handlers))
+	for _, fn := range d.handlers {
+		handlers = append(handlers, fn)
+	}
+	d.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(inv)
+	}
+}
+
+func (d *delegate[K]) GetBroadcasts(overhead, limit int) [][]byte {
+	return d.queue.GetBroadcasts(overhead, limit)
+}
+
+func (d *delegate[K]) LocalState(join bool) []byte {
+	return nil
+}
+
+func (d *delegate[K]) MergeRemoteState(buf []byte, join bool) {}
+
+// broadcast is a gossiped invalidation, invalidations never supersede each other
+type broadcast []byte
+
+func (b broadcast) Invalidates(other memberlist.Broadcast) bool {
+	return false
+}
+
+func (b broadcast) Message() []byte {
+	return b
+}
+
+func (b broadcast) Finished() {}
@@ -0,0 +1,104 @@
+package gossip_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/kamludwinski2/simplecache/gossip"
+	"github.com/stretchr/testify/assert"
+)
+
+func localConfig(name string) *memberlist.Config {
+	config := memberlist.DefaultLocalConfig()
+	config.Name = name
+	config.BindAddr = "127.0.0.1"
+	config.BindPort = 0
+	config.LogOutput = io.Discard
+
+	return config
+}
+
+func TestBus(t *testing.T) {
+	bus1, err := gossip.New[string](localConfig("node1"))
+	assert.NoError(t, err)
+	defer bus1.Close(time.Second)
+
+	bus2, err := gossip.New[string](localConfig("node2"), bus1.Addr())
+	assert.NoError(t, err)
+	defer bus2.Close(time.Second)
+
+	// The join is acknowledged before node1 has merged node2's state
+	assert.Eventually(t, func() bool { return len(bus1.Members()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"node1", "node2"}, bus1.Members())
+
+	invalidated := make(chan string, 1)
+
+	replica1 := cache.New[string, int]().WithInvalidationBus(bus1)
+	replica2 := cache.New[string, int]().WithInvalidationBus(bus2).WithImmediateNotifications().
+		OnDeleteKeyed(func(changes []cache.Change[string, int]) {
+			invalidated <- changes[0].Key
+		})
+
+	replica2.Set("item1", 1)
+	replica1.Set("item1", 2)
+
+	select {
+	case key := <-invalidated:
+		assert.Equal(t, "item1", key)
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalidation not gossiped")
+	}
+
+	_, ok := replica2.Get("item1")
+	assert.False(t, ok)
+}
+
+func TestBusLargeInvalidations(t *testing.T) {
+	bus1, err := gossip.New[string](localConfig("node1"))
+	assert.NoError(t, err)
+	defer bus1.Close(time.Second)
+
+	bus2, err := gossip.New[string](localConfig("node2"), bus1.Addr())
+	assert.NoError(t, err)
+	defer bus2.Close(time.Second)
+
+	assert.Eventually(t, func() bool { return len(bus1.Members()) == 2 }, time.Second, 10*time.Millisecond)
+
+	var mu sync.Mutex
+	received := make(map[string]bool)
+
+	_, err = bus2.Subscribe(func(inv cache.Invalidation[string]) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, key := range inv.Keys {
+			received[key] = true
+		}
+	})
+	assert.NoError(t, err)
+
+	// Far more than one packet, as a tick expiring many keys publishes
+	keys := make([]string, 200)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("session:%08d", i)
+	}
+
+	assert.NoError(t, bus1.Publish(cache.Invalidation[string]{Origin: "node1", Keys: keys}))
+
+	// A key too big for any packet
+	big := strings.Repeat("k", 4<<10)
+	assert.NoError(t, bus1.Publish(cache.Invalidation[string]{Origin: "node1", Keys: []string{big}}))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(received) == len(keys)+1
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,228 @@
+package simplecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GroupPath is where a Group serves its peers
+const GroupPath = "/_simplecache/"
+
+const groupExpiresHeader = "Simplecache-Expires"
+
+// GroupLoader fetches a missing value from the origin, a zero expires keeps it until deleted
+type GroupLoader[T any] func(ctx context.Context, key string) (value T, expires time.Time, err error)
+
+// Group fills a cache groupcache-style, a miss asks the peer owning the key before the origin so each key is
+// loaded once across the cluster. Concurrent misses on a key share one load on every node.
+// Peers serve each other through the Group's ServeHTTP mounted at GroupPath, values go through the cache's codec.
+type Group[T any] struct {
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+
+	cache  *Cache[string, T]
+	self   string
+	loader GroupLoader[T]
+
+	mu   sync.RWMutex
+	ring *HashRing
+
+	flights flightGroup[T]
+}
+
+// NewGroup fills c with loader, self is this node's base URL (e.g. "http://10.0.0.1:8080") as listed in SetPeers
+func NewGroup[T any](c *Cache[string, T], self string, loader GroupLoader[T]) *Group[T] {
+	return &Group[T]{cache: c, self: self, loader: loader, ring: NewHashRing(0, self)}
+}
+
+// SetPeers replaces the nodes keys are spread over, base URLs including self
+func (g *Group[T]) SetPeers(peers ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.ring = NewHashRing(0, peers...)
+}
+
+// Get returns the cached value, or loads it on the peer owning key. When the owner can't be reached it's loaded here.
+func (g *Group[T]) Get(ctx context.Context, key string) (T, error) {
+	if value, ok := g.cache.Get(key); ok {
+		return value, nil
+	}
+
+	item, err := g.flights.do(key, func() (Item[T], error) {
+		g.mu.RLock()
+		owner := g.ring.Node(key)
+		g.mu.RUnlock()
+
+		if owner != "" && owner != g.self {
+			item, err := g.fetch(ctx, owner, key)
+
+			var peerErr *PeerError
+			if err == nil || errors.As(err, &peerErr) {
+				return item, err
+			}
+
+			g.cache.reportError(err)
+		}
+
+		return g.load(ctx, key)
+	})
+
+	return item.Value, err
+}
+
+// PeerError is the origin error a peer got loading a key
+type PeerError struct {
+	Peer string
+	Err  string
+}
+
+func (e *PeerError) Error() string {
+	return fmt.Sprintf("simplecache: peer %s: %s", e.Peer, e.Err)
+}
+
+// ServeHTTP answers peers asking for keys this node owns, loading them from the origin at most once
+func (g *Group[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	escaped, ok := strings.CutPrefix(r.URL.EscapedPath(), GroupPath)
+	key, err := url.PathUnescape(escaped)
+	if !ok || err != nil || r.Method != http.MethodGet {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Peers are never forwarded to, so nodes disagreeing on the owner can't bounce a key between them
+	item, ok := g.cache.lookup(key, g.cache.now())
+	if !ok {
+		item, err = g.flights.do(key, func() (Item[T], error) {
+			return g.load(r.Context(), key)
+		})
+	}
+
+	var data []byte
+	if err == nil {
+		data, err = g.cache.valueCodec().Encode(item.Value)
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !item.Expires.IsZero() {
+		w.Header().Set(groupExpiresHeader, item.Expires.Format(time.RFC3339Nano))
+	}
+
+	w.Write(data)
+}
+
+// load asks the origin and caches the value
+func (g *Group[T]) load(ctx context.Context, key string) (Item[T], error) {
+	g.cache.addMetric(metricLoads, 1)
+
+	ctx, end := g.cache.span(ctx, "load")
+	value, expires, err := g.loader(ctx, key)
+	end(err)
+
+	if err != nil {
+		return Item[T]{}, err
+	}
+
+	item := Item[T]{Value: value, Expires: expires}
+
+	return item, g.cache.SetContext(ctx, key, value, expires)
+}
+
+// fetch asks the owner and keeps a copy until the owner's copy expires
+func (g *Group[T]) fetch(ctx context.Context, peer, key string) (Item[T], error) {
+	g.cache.addMetric(metricPeerLoads, 1)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer+GroupPath+url.PathEscape(key), nil)
+	if err != nil {
+		return Item[T]{}, err
+	}
+
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Item[T]{}, fmt.Errorf("simplecache: peer %s: %w", peer, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Item[T]{}, fmt.Errorf("simplecache: peer %s: %w", peer, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusInternalServerError:
+		return Item[T]{}, &PeerError{Peer: peer, Err: strings.TrimSpace(string(body))}
+	case resp.StatusCode != http.StatusOK:
+		return Item[T]{}, fmt.Errorf("simplecache: peer %s: %s", peer, resp.Status)
+	}
+
+	var item Item[T]
+	if expires := resp.Header.Get(groupExpiresHeader); expires != "" {
+		if item.Expires, err = time.Parse(time.RFC3339Nano, expires); err != nil {
+			return Item[T]{}, fmt.Errorf("simplecache: peer %s: %w", peer, err)
+		}
+	}
+
+	if item.Value, err = g.cache.valueCodec().Decode(body); err != nil {
+		return Item[T]{}, err
+	}
+
+	return item, g.cache.SetContext(ctx, key, item.Value, item.Expires)
+}
+
+// flightGroup runs one call per key at a time, callers arriving meanwhile share its result
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flight[T]
+}
+
+type flight[T any] struct {
+	done chan struct{}
+	item Item[T]
+	err  error
+}
+
+func (f *flightGroup[T]) do(key string, fn func() (Item[T], error)) (Item[T], error) {
+	f.mu.Lock()
+	if call, ok := f.calls[key]; ok {
+		f.mu.Unlock()
+		<-call.done
+
+		return call.item, call.err
+	}
+
+	if f.calls == nil {
+		f.calls = make(map[string]*flight[T])
+	}
+
+	call := &flight[T]{done: make(chan struct{})}
+	f.calls[key] = call
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.calls, key)
+		f.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.item, call.err = fn()
+
+	return call.item, call.err
+}
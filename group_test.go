@@ -0,0 +1,93 @@
+package simplecache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	var loads atomic.Int32
+	loader := func(ctx context.Context, key string) (string, time.Time, error) {
+		if key == "broken" {
+			return "", time.Time{}, errors.New("origin down")
+		}
+
+		loads.Add(1)
+		time.Sleep(20 * time.Millisecond)
+
+		return "value of " + key, time.Now().Add(time.Minute), nil
+	}
+
+	var groups []*cache.Group[string]
+	var peers []string
+
+	for range 3 {
+		var group *cache.Group[string]
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			group.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		group = cache.NewGroup(cache.New[string, string](), server.URL, loader)
+		groups = append(groups, group)
+		peers = append(peers, server.URL)
+	}
+
+	for _, group := range groups {
+		group.SetPeers(peers...)
+	}
+
+	// Every node asks at once, the origin is hit once per key
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		for _, key := range []string{"a/b c", "key1", "key2"} {
+			for range 5 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					value, err := group.Get(context.Background(), key)
+					assert.NoError(t, err)
+					assert.Equal(t, "value of "+key, value)
+				}()
+			}
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), loads.Load())
+
+	_, err := groups[0].Get(context.Background(), "broken")
+	assert.ErrorContains(t, err, "origin down")
+}
+
+func TestGroupPeerDown(t *testing.T) {
+	var errs []error
+	c := cache.New[string, int]().OnError(func(err error) { errs = append(errs, err) })
+
+	group := cache.NewGroup(c, "http://self", func(ctx context.Context, key string) (int, time.Time, error) {
+		return len(key), time.Time{}, nil
+	})
+	group.SetPeers("http://self", "http://127.0.0.1:1")
+
+	for i := range 20 {
+		key := fmt.Sprint("key", i)
+
+		value, err := group.Get(context.Background(), key)
+		assert.NoError(t, err)
+		assert.Equal(t, len(key), value)
+	}
+
+	// Keys owned by the unreachable peer were loaded here
+	assert.NotEmpty(t, errs)
+}
@@ -0,0 +1,43 @@
+package simplecache
+
+import (
+	"net/http"
+	"time"
+)
+
+// healthStatus is the body of HealthHandler
+type healthStatus struct {
+	Healthy  bool      `json:"healthy"`
+	LastTick time.Time `json:"lastTick,omitzero"`
+}
+
+// Healthy reports whether Maintain ticked within twice the interval, false before it starts and after Stop
+func (c *Cache[K, T]) Healthy() bool {
+	return c.healthyAt(time.Now())
+}
+
+// HealthHandler answers 200 while the cache is Healthy and 503 otherwise, for a Kubernetes readiness or liveness probe.
+// It shows no data, so unlike the other handlers it skips the WithServerSecurity authenticator.
+func (c *Cache[K, T]) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		status := healthStatus{Healthy: c.healthyAt(now)}
+
+		if last := c.janitor.lastTick.Load(); last != 0 {
+			status.LastTick = time.Unix(0, last)
+		}
+
+		code := http.StatusOK
+		if !status.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+
+		writeJSON(w, code, status)
+	})
+}
+
+func (c *Cache[K, T]) healthyAt(now time.Time) bool {
+	last := c.janitor.lastTick.Load()
+
+	return last != 0 && now.Sub(time.Unix(0, last)) <= 2*c.interval
+}
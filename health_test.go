@@ -0,0 +1,40 @@
+package simplecache_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthy(t *testing.T) {
+	ticked := make(chan cache.TickStats, 16)
+
+	c := cache.New[string, TestStruct]().WithInterval(20 * time.Millisecond).
+		OnTickStats(func(stats cache.TickStats) { ticked <- stats })
+	handler := c.HealthHandler()
+
+	probe := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		return rec.Code
+	}
+
+	assert.False(t, c.Healthy())
+	assert.Equal(t, http.StatusServiceUnavailable, probe())
+
+	go c.Maintain()
+	<-ticked
+
+	assert.True(t, c.Healthy())
+	assert.Equal(t, http.StatusOK, probe())
+
+	c.Stop()
+
+	assert.False(t, c.Healthy())
+	assert.Equal(t, http.StatusServiceUnavailable, probe())
+}
@@ -0,0 +1,184 @@
+package simplecache
+
+import (
+	"cmp"
+	"hash/maphash"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Count-min sketch dimensions, estimates exceed the true count by about 0.1% of the accesses in a window
+const (
+	sketchDepth   = 4
+	sketchWidth   = 2048
+	hotCandidates = 128
+)
+
+// KeyCount is a key with its approximate number of accesses, see TopKeys
+type KeyCount[K comparable] struct {
+	Key   K
+	Count int
+}
+
+type HotKeyMiddleware[K comparable] func(key K, count int)
+
+// hotKeys estimates access frequencies in a count-min sketch and keeps the most accessed keys as candidates for TopKeys
+type hotKeys[K comparable] struct {
+	threshold int
+	window    time.Duration
+	seed      maphash.Seed
+
+	mu         sync.Mutex
+	sketch     [sketchDepth][sketchWidth]uint32
+	candidates map[K]int
+	reported   map[K]struct{}
+	decayed    time.Time
+	handlers   []HotKeyMiddleware[K]
+}
+
+// WithHotKeys estimates how often each key is read, halving the counts every window so keys cool down.
+// Keys read threshold times are reported to OnHotKey, once until their count has decayed below it again.
+func (c *Cache[K, T]) WithHotKeys(threshold int, window time.Duration) *Cache[K, T] {
+	c.hotKeys = &hotKeys[K]{
+		threshold:  threshold,
+		window:     window,
+		seed:       maphash.MakeSeed(),
+		candidates: make(map[K]int),
+		reported:   make(map[K]struct{}),
+		decayed:    time.Now(),
+	}
+
+	return c
+}
+
+// OnHotKey is triggered when a key's estimated reads reach the WithHotKeys threshold
+func (c *Cache[K, T]) OnHotKey(m HotKeyMiddleware[K]) *Cache[K, T] {
+	if c.hotKeys != nil {
+		c.hotKeys.mu.Lock()
+		c.hotKeys.handlers = append(c.hotKeys.handlers, m)
+		c.hotKeys.mu.Unlock()
+	}
+
+	return c
+}
+
+// TopKeys returns up to n of the most read keys with their estimated reads, most read first, nil without WithHotKeys
+func (c *Cache[K, T]) TopKeys(n int) []KeyCount[K] {
+	if c.hotKeys == nil {
+		return nil
+	}
+
+	h := c.hotKeys
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.decay(time.Now())
+
+	top := make([]KeyCount[K], 0, len(h.candidates))
+	for key, count := range h.candidates {
+		top = append(top, KeyCount[K]{Key: key, Count: count})
+	}
+
+	slices.SortFunc(top, func(a, b KeyCount[K]) int { return cmp.Compare(b.Count, a.Count) })
+
+	return top[:min(n, len(top))]
+}
+
+// recordRead counts a read of key and runs the OnHotKey middlewares when it became hot
+func (c *Cache[K, T]) recordRead(key K) {
+	count, handlers := c.hotKeys.record(key, time.Now())
+
+	for _, m := range handlers {
+		c.safely(func() { m(key, count) })
+	}
+}
+
+// record counts an access and returns the handlers to run when key just reached the threshold
+func (h *hotKeys[K]) record(key K, now time.Time) (int, []HotKeyMiddleware[K]) {
+	hash := maphash.String(h.seed, keyString(key))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.decay(now)
+
+	// The rows index with h1 + i*h2, the minimum over the rows is the estimate
+	h1, h2 := uint32(hash), uint32(hash>>32)|1
+	count := uint32(0)
+	for i := range sketchDepth {
+		cell := &h.sketch[i][(h1+uint32(i)*h2)%sketchWidth]
+		*cell++
+
+		if i == 0 || *cell < count {
+			count = *cell
+		}
+	}
+
+	h.track(key, int(count))
+
+	if int(count) < h.threshold {
+		return 0, nil
+	}
+
+	if _, done := h.reported[key]; done {
+		return 0, nil
+	}
+
+	h.reported[key] = struct{}{}
+
+	return int(count), h.handlers
+}
+
+// track keeps key as a candidate when it is among the most accessed, evicting the least accessed one
+func (h *hotKeys[K]) track(key K, count int) {
+	if _, ok := h.candidates[key]; ok || len(h.candidates) < hotCandidates {
+		h.candidates[key] = count
+		return
+	}
+
+	var coldest K
+	lowest := -1
+	for k, c := range h.candidates {
+		if lowest < 0 || c < lowest {
+			coldest, lowest = k, c
+		}
+	}
+
+	if count > lowest {
+		delete(h.candidates, coldest)
+		h.candidates[key] = count
+	}
+}
+
+// decay halves every count once per window passed, called with mu held
+func (h *hotKeys[K]) decay(now time.Time) {
+	if h.window <= 0 || now.Sub(h.decayed) < h.window {
+		return
+	}
+
+	windows := now.Sub(h.decayed) / h.window
+	h.decayed = h.decayed.Add(windows * h.window)
+	shift := min(windows, 32)
+
+	for i := range h.sketch {
+		for j := range h.sketch[i] {
+			h.sketch[i][j] >>= shift
+		}
+	}
+
+	for key, count := range h.candidates {
+		if count >>= shift; count == 0 {
+			delete(h.candidates, key)
+		} else {
+			h.candidates[key] = count
+		}
+	}
+
+	// Keys no longer tracked count as cooled down
+	for key := range h.reported {
+		if h.candidates[key] < h.threshold {
+			delete(h.reported, key)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package simplecache_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHotKeys(t *testing.T) {
+	var hot []string
+
+	c := cache.New[string, int]().WithHotKeys(50, 300*time.Millisecond).
+		OnHotKey(func(key string, count int) {
+			hot = append(hot, key)
+			assert.Equal(t, 50, count)
+		})
+
+	c.Set("popular", 1)
+
+	for i := range 200 {
+		c.Get("popular")
+		c.Get("warm")
+		c.Get("cold" + strconv.Itoa(i))
+
+		if i%2 == 0 {
+			c.Get("warm")
+		}
+	}
+
+	// Reported once, missing keys count as well
+	assert.Equal(t, []string{"warm", "popular"}, hot)
+
+	top := c.TopKeys(2)
+	assert.Equal(t, []cache.KeyCount[string]{{Key: "warm", Count: 300}, {Key: "popular", Count: 200}}, top)
+
+	// Halved every window, cooled down keys are reported again
+	time.Sleep(time.Second)
+	assert.Equal(t, []cache.KeyCount[string]{{Key: "warm", Count: 37}}, c.TopKeys(1))
+
+	for range 25 {
+		c.Get("popular")
+	}
+
+	assert.Equal(t, []string{"warm", "popular", "popular"}, hot)
+}
+
+func TestTopKeysDisabled(t *testing.T) {
+	c := cache.New[string, int]()
+	c.Get("item")
+
+	assert.Nil(t, c.TopKeys(10))
+}
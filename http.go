@@ -0,0 +1,161 @@
+package simplecache
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultHTTPBodyLimit = 1 << 20
+
+// WithHTTPBodyLimit bounds the size of the values PUT to HTTPHandler, larger bodies get 413 (default 1MB)
+func (c *Cache[K, T]) WithHTTPBodyLimit(n int64) *Cache[K, T] {
+	c.httpBodyLimit = n
+
+	return c
+}
+
+// HTTPHandler exposes the cache as JSON over REST, checking requests with the Authenticator of WithServerSecurity:
+//
+//	GET    /keys?prefix=p  live items as {"key", "value", "expires"} objects ordered by key
+//	GET    /keys/{key}     one item
+//	PUT    /keys/{key}     sets the value in the body, ?ttl=30s makes it expire
+//	DELETE /keys/{key}     deletes the item
+//	GET    /stats          Stats
+//
+// Reads don't count as hits or misses but do go through the Get interceptors. Keys in paths need the key type to be string.
+func (c *Cache[K, T]) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /keys", c.httpList)
+	mux.HandleFunc("GET /keys/{key}", c.httpGet)
+	mux.HandleFunc("PUT /keys/{key}", c.httpPut)
+	mux.HandleFunc("DELETE /keys/{key}", c.httpDelete)
+	mux.HandleFunc("GET /stats", c.httpStats)
+
+	return c.secured(mux)
+}
+
+func (c *Cache[K, T]) httpList(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	entries := slices.DeleteFunc(c.entries(), func(e entry[K, T]) bool {
+		return !strings.HasPrefix(keyString(e.Key), prefix)
+	})
+
+	slices.SortFunc(entries, func(a, b entry[K, T]) int {
+		return cmp.Compare(keyString(a.Key), keyString(b.Key))
+	})
+
+	for i, e := range entries {
+		entries[i].Value = c.httpValue(e.Key, e.Value)
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (c *Cache[K, T]) httpGet(w http.ResponseWriter, r *http.Request) {
+	key, ok := c.httpKey(w, r)
+	if !ok {
+		return
+	}
+
+	item, exists := c.lookup(key, time.Now())
+	if !exists {
+		writeHTTPError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry[K, T]{Key: key, Value: c.httpValue(key, item.Value), Expires: item.Expires})
+}
+
+func (c *Cache[K, T]) httpPut(w http.ResponseWriter, r *http.Request) {
+	key, ok := c.httpKey(w, r)
+	if !ok {
+		return
+	}
+
+	var expires time.Time
+	if ttl := r.URL.Query().Get("ttl"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			writeHTTPError(w, http.StatusBadRequest, errors.New("ttl must be a positive duration such as 30s"))
+			return
+		}
+
+		expires = time.Now().Add(d)
+	}
+
+	var value T
+	body := http.MaxBytesReader(w, r.Body, cmp.Or(c.httpBodyLimit, defaultHTTPBodyLimit))
+	if err := json.NewDecoder(body).Decode(&value); err != nil {
+		status := http.StatusBadRequest
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		writeHTTPError(w, status, err)
+		return
+	}
+
+	if err := c.SetContext(r.Context(), key, value, expires); err != nil {
+		writeHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cache[K, T]) httpDelete(w http.ResponseWriter, r *http.Request) {
+	key, ok := c.httpKey(w, r)
+	if !ok {
+		return
+	}
+
+	if _, exists := c.lookup(key, time.Now()); !exists {
+		writeHTTPError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	c.DeleteContext(r.Context(), key)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cache[K, T]) httpStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, c.Stats())
+}
+
+func (c *Cache[K, T]) httpKey(w http.ResponseWriter, r *http.Request) (K, bool) {
+	key, ok := any(r.PathValue("key")).(K)
+	if !ok {
+		writeHTTPError(w, http.StatusBadRequest, errors.New("keys must be strings"))
+	}
+
+	return key, ok
+}
+
+func (c *Cache[K, T]) httpValue(key K, value T) T {
+	if len(c.getInterceptors) > 0 {
+		return c.interceptGet(key, value)
+	}
+
+	return value
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
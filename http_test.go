@@ -0,0 +1,99 @@
+package simplecache_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithServerSecurity(nil, cache.TokenAuth("secret"))
+	c.Set("user:1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("order:1", TestStruct{Name: "Bob", Age: 25})
+
+	server := httptest.NewServer(c.HTTPHandler())
+	defer server.Close()
+
+	do := func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() })
+
+		return res
+	}
+
+	res, err := http.Get(server.URL + "/keys")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	var item struct {
+		Key   string
+		Value TestStruct
+	}
+
+	res = do(http.MethodGet, "/keys/user:1", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&item))
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item.Value)
+
+	res = do(http.MethodPut, "/keys/user:2?ttl=1h", `{"Name": "Carol", "Age": 40}`)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	var items []struct {
+		Key     string
+		Expires time.Time
+	}
+
+	res = do(http.MethodGet, "/keys?prefix=user:", "")
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&items))
+	assert.Len(t, items, 2)
+	assert.Equal(t, "user:2", items[1].Key)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), items[1].Expires, time.Minute)
+
+	res = do(http.MethodDelete, "/keys/order:1", "")
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res = do(http.MethodGet, "/keys/order:1", "")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	var stats cache.Stats
+
+	res = do(http.MethodGet, "/stats", "")
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&stats))
+	assert.Equal(t, int64(2), stats.Items)
+}
+
+func TestHTTPHandlerBodyLimit(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithHTTPBodyLimit(64)
+
+	server := httptest.NewServer(c.HTTPHandler())
+	defer server.Close()
+
+	put := func(body string) int {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/keys/user:1", strings.NewReader(body))
+		assert.NoError(t, err)
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		res.Body.Close()
+
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusNoContent, put(`{"Name": "Alice", "Age": 30}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, put(`{"Name": "`+strings.Repeat("A", 100)+`", "Age": 30}`))
+
+	alice, _ := c.Get("user:1")
+	assert.Equal(t, "Alice", alice.Name)
+}
@@ -0,0 +1,322 @@
+package simplecache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	fullSnapshotFile = "full.snap"
+	deltaPrefix      = "delta-"
+)
+
+// deltaRecord is a key written or removed since the previous save, Deleted records carry no value
+type deltaRecord[K comparable] struct {
+	Key     K
+	Deleted bool
+	Value   []byte
+	Expires time.Time
+}
+
+// WithIncrementalSave saves to dir every interval while Maintain runs, writing only the keys changed since the previous save.
+// Every fullEvery-th save (and the first) writes a full snapshot replacing the deltas, LoadIncremental restores the result.
+func (c *Cache[K, T]) WithIncrementalSave(dir string, interval time.Duration, fullEvery int) *Cache[K, T] {
+	c.Lock()
+	defer c.Unlock()
+
+	c.incrementalDir = dir
+	c.incrementalInterval = interval
+	c.incrementalFullEvery = fullEvery
+	c.dirty = make(map[K]struct{})
+	c.needsFull = true
+
+	return c
+}
+
+// markDirty records a changed key for the next delta, called with the lock held
+func (c *Cache[K, T]) markDirty(key K) {
+	if c.dirty != nil {
+		c.dirty[key] = struct{}{}
+	}
+}
+
+// SaveIncremental writes a delta, or a full snapshot when one is due
+func (c *Cache[K, T]) SaveIncremental() error {
+	c.lock()
+
+	full := c.needsFull || (c.incrementalFullEvery > 0 && c.incrementalSaves%c.incrementalFullEvery == 0)
+	c.incrementalSaves++
+
+	var data map[K]Item[T]
+	var entries []entry[K, T]
+	var deleted []K
+	if full {
+		data = c.frozen()
+	} else {
+		now := time.Now()
+		for key := range c.dirty {
+			if item, ok := c.data.Get(key); ok && !item.expired(now) {
+				entries = append(entries, entry[K, T]{Key: key, Value: item.Value, Expires: item.Expires})
+			} else {
+				deleted = append(deleted, key)
+			}
+		}
+	}
+
+	clear(c.dirty)
+	c.needsFull = false
+
+	// Generations are unique across restarts, so deltas of an earlier run never apply to a newer snapshot
+	if full {
+		c.incrementalGeneration = time.Now().UnixNano()
+	}
+
+	generation, seq := c.incrementalGeneration, c.incrementalSaves
+
+	c.Unlock()
+
+	var err error
+	if full {
+		err = c.saveFull(generation, entriesOf(data))
+	} else if len(entries) > 0 || len(deleted) > 0 {
+		err = c.saveDelta(generation, seq, entries, deleted)
+	}
+
+	// The changes are gone from the dirty set, only a full snapshot can make up for them
+	if err != nil {
+		c.Lock()
+		c.needsFull = true
+		c.Unlock()
+	}
+
+	return err
+}
+
+func (c *Cache[K, T]) saveFull(generation int64, entries []entry[K, T]) error {
+	dir := c.incrementalDir
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	err := FileTarget(filepath.Join(dir, fullSnapshotFile)).Write(func(w io.Writer) error {
+		if err := binary.Write(w, binary.BigEndian, generation); err != nil {
+			return err
+		}
+
+		return c.save(w, entries)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Earlier deltas are covered by the new snapshot
+	files, err := c.deltaFiles(-1)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, file := range files {
+		errs = append(errs, os.Remove(file))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Cache[K, T]) saveDelta(generation int64, seq int, entries []entry[K, T], deleted []K) error {
+	codec := c.valueCodec()
+
+	records := make([]deltaRecord[K], 0, len(entries)+len(deleted))
+	for _, e := range entries {
+		value, err := codec.Encode(c.saving(e.Key, e.Value))
+		if err != nil {
+			return fmt.Errorf("simplecache: encode %v: %w", e.Key, err)
+		}
+
+		records = append(records, deltaRecord[K]{Key: e.Key, Value: value, Expires: e.Expires})
+	}
+
+	for _, key := range deleted {
+		records = append(records, deltaRecord[K]{Key: key, Deleted: true})
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(c.snapshotHeader()); err != nil {
+		return err
+	}
+
+	if err := enc.Encode(records); err != nil {
+		return err
+	}
+
+	data, err := c.pack(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	data = appendChecksum(data)
+
+	name := fmt.Sprintf("%s%d-%010d", deltaPrefix, generation, seq)
+
+	return FileTarget(filepath.Join(c.incrementalDir, name)).Write(func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// LoadIncremental restores the full snapshot in dir and applies the deltas written after it
+func (c *Cache[K, T]) LoadIncremental(dir string) error {
+	var generation int64
+
+	err := FileTarget(filepath.Join(dir, fullSnapshotFile)).Read(func(r io.Reader) error {
+		if err := binary.Read(r, binary.BigEndian, &generation); err != nil {
+			return err
+		}
+
+		return c.Load(r)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	c.incrementalDir = dir
+	c.Unlock()
+
+	files, err := c.deltaFiles(generation)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := c.applyDelta(file); err != nil {
+			return fmt.Errorf("simplecache: %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Cache[K, T]) applyDelta(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	data, err = verifyChecksum(data)
+	if err != nil {
+		return err
+	}
+
+	data, err = c.unpack(data)
+	if err != nil {
+		return err
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(data))
+
+	var header SnapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+
+	decode, err := c.valueDecoder(header)
+	if err != nil {
+		return err
+	}
+
+	var records []deltaRecord[K]
+	if err := dec.Decode(&records); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var errs []error
+	discarded := 0
+	for _, rec := range records {
+		// Applied directly like a restored snapshot, interceptors and invalidations ran when the changes were made
+		op, item := walSet, Item[T]{Expires: rec.Expires}
+		switch {
+		case rec.Deleted:
+			op = walDelete
+
+		case item.expired(now):
+			op = walDelete
+			discarded++
+
+		default:
+			value, err := decode(rec.Value)
+			if err != nil {
+				return fmt.Errorf("decode %v: %w", rec.Key, err)
+			}
+
+			item.Value = c.loaded(rec.Key, value)
+		}
+
+		if err := c.applyRecovered(op, rec.Key, item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	c.reportDiscarded(discarded)
+
+	return errors.Join(errs...)
+}
+
+// deltaFiles lists the delta files in order, only those of generation unless it is negative
+func (c *Cache[K, T]) deltaFiles(generation int64) ([]string, error) {
+	entries, err := os.ReadDir(c.incrementalDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := deltaPrefix
+	if generation >= 0 {
+		prefix = fmt.Sprintf("%s%d-", deltaPrefix, generation)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, prefix) && !strings.Contains(name, ".") {
+			files = append(files, filepath.Join(c.incrementalDir, name))
+		}
+	}
+
+	slices.Sort(files)
+
+	return files, nil
+}
+
+func (c *Cache[K, T]) runIncrementalSave(done <-chan struct{}) {
+	ticker := time.NewTicker(c.incrementalInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.SaveIncremental(); err != nil {
+				c.reportError(err)
+			}
+
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package simplecache_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func deltaFiles(t *testing.T, dir string) []string {
+	matches, err := filepath.Glob(filepath.Join(dir, "delta-*"))
+	assert.NoError(t, err)
+
+	return matches
+}
+
+func TestIncrementalSave(t *testing.T) {
+	dir := t.TempDir()
+
+	c := cache.New[string, TestStruct]().WithIncrementalSave(dir, time.Hour, 3)
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40})
+
+	// The first save is a full snapshot
+	assert.NoError(t, c.SaveIncremental())
+	assert.Empty(t, deltaFiles(t, dir))
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Delete("item2")
+	assert.NoError(t, c.SaveIncremental())
+
+	c.Set("item4", TestStruct{Name: "Dave", Age: 50})
+	assert.NoError(t, c.SaveIncremental())
+	assert.Len(t, deltaFiles(t, dir), 2)
+
+	// Deltas are applied directly, interceptors don't see them
+	restored := cache.New[string, TestStruct]().InterceptSet(func(key string, value TestStruct) (TestStruct, error) {
+		return value, errors.New("rejected")
+	})
+	assert.NoError(t, restored.LoadIncremental(dir))
+	assert.ElementsMatch(t, c.GetAll(), restored.GetAll())
+
+	// Every third save is full again and replaces the deltas
+	c.Set("item3", TestStruct{Name: "Carol", Age: 41})
+	assert.NoError(t, c.SaveIncremental())
+	assert.Empty(t, deltaFiles(t, dir))
+
+	restored = cache.New[string, TestStruct]()
+	assert.NoError(t, restored.LoadIncremental(dir))
+	assert.ElementsMatch(t, c.GetAll(), restored.GetAll())
+}
+
+func TestIncrementalSaveIgnoresStaleDeltas(t *testing.T) {
+	dir := t.TempDir()
+
+	c := cache.New[string, TestStruct]().WithIncrementalSave(dir, time.Hour, 0)
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.SaveIncremental())
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	assert.NoError(t, c.SaveIncremental())
+
+	stale := deltaFiles(t, dir)
+	assert.Len(t, stale, 1)
+
+	data, err := os.ReadFile(stale[0])
+	assert.NoError(t, err)
+
+	// A newer run wrote a full snapshot but crashed before removing the old delta
+	next := cache.New[string, TestStruct]().WithIncrementalSave(dir, time.Hour, 0)
+	next.Set("item1", TestStruct{Name: "Alice", Age: 35})
+	assert.NoError(t, next.SaveIncremental())
+	assert.NoError(t, os.WriteFile(stale[0], data, 0o600))
+
+	restored := cache.New[string, TestStruct]()
+	assert.NoError(t, restored.LoadIncremental(dir))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 35}, item1)
+}
@@ -0,0 +1,54 @@
+package simplecache
+
+import "fmt"
+
+// SetInterceptor runs inline on Set, it can replace the value or reject the write by returning an error
+type SetInterceptor[K comparable, T any] func(key K, value T) (T, error)
+
+// BeforeSetMiddleware validates a write, a non-nil error aborts it
+type BeforeSetMiddleware[K comparable, T any] func(key K, value T) error
+
+// GetInterceptor runs inline on Get hits and GetAll and can rewrite the returned value
+type GetInterceptor[K comparable, T any] func(key K, value T) T
+
+// InterceptSet registers a set interceptor, interceptors run in registration order.
+// A rejected write is skipped and the error is reported to OnError.
+func (c *Cache[K, T]) InterceptSet(i SetInterceptor[K, T]) *Cache[K, T] {
+	c.setInterceptors = append(c.setInterceptors, i)
+
+	return c
+}
+
+// BeforeSet registers a validation hook, the error of a rejected write is returned from SetE
+func (c *Cache[K, T]) BeforeSet(m BeforeSetMiddleware[K, T]) *Cache[K, T] {
+	return c.InterceptSet(func(key K, value T) (T, error) {
+		return value, m(key, value)
+	})
+}
+
+func (c *Cache[K, T]) InterceptGet(i GetInterceptor[K, T]) *Cache[K, T] {
+	c.getInterceptors = append(c.getInterceptors, i)
+
+	return c
+}
+
+func (c *Cache[K, T]) interceptSet(key K, value T) (T, error) {
+	for _, i := range c.setInterceptors {
+		var err error
+
+		value, err = i(key, value)
+		if err != nil {
+			return value, fmt.Errorf("simplecache: set %v rejected: %w", key, err)
+		}
+	}
+
+	return value, nil
+}
+
+func (c *Cache[K, T]) interceptGet(key K, value T) T {
+	for _, i := range c.getInterceptors {
+		value = i(key, value)
+	}
+
+	return value
+}
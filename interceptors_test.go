@@ -0,0 +1,67 @@
+package simplecache_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptors(t *testing.T) {
+	errNegativeAge := errors.New("negative age")
+	errs := make([]error, 0)
+
+	c := cache.New[string, TestStruct]().
+		InterceptSet(func(key string, value TestStruct) (TestStruct, error) {
+			if value.Age < 0 {
+				return value, errNegativeAge
+			}
+
+			value.Name = strings.TrimSpace(value.Name)
+			return value, nil
+		}).
+		InterceptGet(func(key string, value TestStruct) TestStruct {
+			value.Name = strings.ToUpper(value.Name)
+			return value
+		}).
+		OnError(func(err error) { errs = append(errs, err) })
+
+	c.Set("item1", TestStruct{Name: " Alice ", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: -1})
+
+	val, exists := c.Get("item1")
+	assert.True(t, exists)
+	assert.Equal(t, TestStruct{Name: "ALICE", Age: 30}, val)
+
+	assert.Equal(t, []TestStruct{{Name: "ALICE", Age: 30}}, c.GetAll())
+
+	_, exists = c.Get("item2")
+	assert.False(t, exists)
+
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errNegativeAge)
+}
+
+func TestBeforeSetAndSetE(t *testing.T) {
+	errMissingName := errors.New("missing name")
+
+	c := cache.New[string, TestStruct]().
+		BeforeSet(func(key string, value TestStruct) error {
+			if value.Name == "" {
+				return errMissingName
+			}
+
+			return nil
+		})
+
+	assert.NoError(t, c.SetE("item1", TestStruct{Name: "Alice", Age: 30}))
+
+	err := c.SetE("item2", TestStruct{Age: 25})
+	assert.ErrorIs(t, err, errMissingName)
+
+	_, exists := c.Get("item2")
+	assert.False(t, exists)
+	assert.Equal(t, int64(1), c.Stats().Items)
+}
@@ -0,0 +1,219 @@
+package simplecache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Invalidation tells the other replicas to drop keys, or everything when All is set
+type Invalidation[K comparable] struct {
+	// Origin identifies the publishing cache, which ignores its own invalidations
+	Origin string
+	Keys   []K
+	All    bool
+}
+
+// InvalidationBus carries invalidations between replicas, adapters exist for NATS and Redis pub/sub.
+// Delivery may be at-most-once, a replica missing one keeps its stale entry until it expires or is written again.
+type InvalidationBus[K comparable] interface {
+	Publish(inv Invalidation[K]) error
+	Subscribe(fn func(Invalidation[K])) (unsubscribe func(), err error)
+}
+
+type invalidationKey struct{}
+
+// encodeInvalidation serializes a message of the network buses, compressed when compression is set
+func encodeInvalidation[K comparable](inv Invalidation[K], compression Compression) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if compression == nil {
+		err := gob.NewEncoder(&buf).Encode(inv)
+		return buf.Bytes(), err
+	}
+
+	zw, err := compression.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gob.NewEncoder(zw).Encode(inv); err != nil {
+		zw.Close()
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeInvalidation[K comparable](payload []byte, compression Compression) (Invalidation[K], error) {
+	var inv Invalidation[K]
+
+	var r io.Reader = bytes.NewReader(payload)
+	if compression != nil {
+		zr, err := compression.NewReader(r)
+		if err != nil {
+			return inv, err
+		}
+		defer zr.Close()
+
+		r = zr
+	}
+
+	err := gob.NewDecoder(r).Decode(&inv)
+
+	return inv, err
+}
+
+// invalidationQueueSize is the number of invalidations waiting to be published before more are dropped
+const invalidationQueueSize = 1024
+
+// busReconnectDelay is how long the network buses wait before redialing a dropped connection
+const busReconnectDelay = time.Second
+
+// busDialTimeout bounds dialing a broker and reading its greeting
+const busDialTimeout = 5 * time.Second
+
+var errBusClosed = errors.New("simplecache: invalidation bus closed")
+
+// WithInvalidationBus publishes the keys written, deleted or expired here and drops the keys invalidated by other replicas.
+// Dropped keys are deleted like with Delete, so middlewares see them, but aren't published back. Invalidations are published
+// in order from a goroutine of their own so a slow broker doesn't hold up writes, when too many wait they are dropped and
+// counted as DroppedEvents. Publish errors are reported to OnError.
+func (c *Cache[K, T]) WithInvalidationBus(bus InvalidationBus[K]) *Cache[K, T] {
+	origin := make([]byte, 16)
+	rand.Read(origin)
+
+	c.invalidationBus.Store(&bus)
+	c.invalidationOrigin = hex.EncodeToString(origin)
+
+	queue, published := make(chan Invalidation[K], invalidationQueueSize), make(chan struct{})
+	c.invalidationQueue, c.invalidationPublished = queue, published
+
+	go func() {
+		defer close(published)
+
+		for inv := range queue {
+			if err := bus.Publish(inv); err != nil {
+				c.reportError(err)
+			}
+		}
+	}()
+
+	unsubscribe, err := bus.Subscribe(c.applyInvalidation)
+	if err != nil {
+		c.startErrs = append(c.startErrs, err)
+		return c
+	}
+
+	c.invalidationUnsubscribe = unsubscribe
+
+	return c
+}
+
+// CloseInvalidationBus publishes the invalidations still queued and stops publishing and receiving them
+func (c *Cache[K, T]) CloseInvalidationBus() {
+	if c.invalidationUnsubscribe != nil {
+		c.invalidationUnsubscribe()
+	}
+
+	c.invalidationBus.Store(nil)
+
+	// Taking the write lock waits for the sends in progress
+	c.invalidationMu.Lock()
+	queue, published := c.invalidationQueue, c.invalidationPublished
+	c.invalidationQueue = nil
+	c.invalidationMu.Unlock()
+
+	if queue != nil {
+		close(queue)
+		<-published
+	}
+}
+
+func (c *Cache[K, T]) applyInvalidation(inv Invalidation[K]) {
+	if inv.Origin == c.invalidationOrigin {
+		return
+	}
+
+	// Marked so the deletes aren't published back
+	ctx := context.WithValue(context.Background(), invalidationKey{}, true)
+
+	if inv.All {
+		c.deleteAll(ctx)
+		return
+	}
+
+	for _, key := range inv.Keys {
+		c.DeleteContext(ctx, key)
+	}
+}
+
+// invalidate queues keys for publishing unless ctx comes from a received invalidation, called without the lock held
+func (c *Cache[K, T]) invalidate(ctx context.Context, all bool, keys ...K) {
+	if c.invalidationBus.Load() == nil || ctx.Value(invalidationKey{}) != nil || (!all && len(keys) == 0) {
+		return
+	}
+
+	c.invalidationMu.RLock()
+	defer c.invalidationMu.RUnlock()
+
+	if c.invalidationQueue == nil {
+		return
+	}
+
+	select {
+	case c.invalidationQueue <- Invalidation[K]{Origin: c.invalidationOrigin, Keys: keys, All: all}:
+	default:
+		// The other replicas keep their stale entries until they expire or are written again
+		c.dropEvent()
+	}
+}
+
+// LocalBus is an in-process InvalidationBus, for caches sharing a process and for tests
+type LocalBus[K comparable] struct {
+	mu       sync.RWMutex
+	handlers map[*func(Invalidation[K])]struct{}
+}
+
+func (b *LocalBus[K]) Publish(inv Invalidation[K]) error {
+	b.mu.RLock()
+	handlers := make([]func(Invalidation[K]), 0, len(b.handlers))
+	for fn := range b.handlers {
+		handlers = append(handlers, *fn)
+	}
+	b.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(inv)
+	}
+
+	return nil
+}
+
+func (b *LocalBus[K]) Subscribe(fn func(Invalidation[K])) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.handlers == nil {
+		b.handlers = make(map[*func(Invalidation[K])]struct{})
+	}
+
+	handler := &fn
+	b.handlers[handler] = struct{}{}
+
+	return func() {
+		b.mu.Lock()
+		delete(b.handlers, handler)
+		b.mu.Unlock()
+	}, nil
+}
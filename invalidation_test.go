@@ -0,0 +1,99 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidationBus(t *testing.T) {
+	bus := &cache.LocalBus[string]{}
+	published := make(chan cache.Invalidation[string], 16)
+
+	_, err := bus.Subscribe(func(inv cache.Invalidation[string]) {
+		published <- inv
+	})
+	assert.NoError(t, err)
+
+	replica1 := cache.New[string, TestStruct]().WithInvalidationBus(bus).WithInterval(20 * time.Millisecond)
+	replica2 := cache.New[string, TestStruct]().WithInvalidationBus(bus)
+
+	replica2.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	replica2.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	assert.Equal(t, []string{"item1"}, (<-published).Keys)
+	assert.Equal(t, []string{"item2"}, (<-published).Keys)
+
+	// A write on one replica drops the stale copy on the other, without being published back
+	replica1.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	assert.Equal(t, []string{"item1"}, (<-published).Keys)
+
+	// Published from a goroutine of replica1, replica2 may get it after the subscriber above
+	assert.Eventually(t, func() bool {
+		_, ok := replica2.Get("item1")
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	go replica1.Maintain()
+
+	replica1.Set("item3", TestStruct{Name: "Carol", Age: 40}, time.Now().Add(time.Millisecond))
+	assert.Equal(t, []string{"item3"}, (<-published).Keys)
+	assert.Equal(t, []string{"item3"}, (<-published).Keys)
+
+	replica1.Stop()
+
+	replica1.DeleteAll()
+	assert.True(t, (<-published).All)
+
+	assert.Eventually(t, func() bool {
+		_, ok := replica2.Get("item2")
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	assert.Empty(t, published)
+}
+
+// stalledBus is an InvalidationBus whose Publish waits for release, like a broker that stopped answering
+type stalledBus struct {
+	release   chan struct{}
+	published chan cache.Invalidation[string]
+}
+
+func (b *stalledBus) Publish(inv cache.Invalidation[string]) error {
+	<-b.release
+	b.published <- inv
+
+	return nil
+}
+
+func (b *stalledBus) Subscribe(fn func(cache.Invalidation[string])) (func(), error) {
+	return func() {}, nil
+}
+
+func TestInvalidationBusDoesNotBlockWrites(t *testing.T) {
+	bus := &stalledBus{release: make(chan struct{}), published: make(chan cache.Invalidation[string], 16)}
+	c := cache.New[string, TestStruct]().WithInvalidationBus(bus)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+		c.Delete("item1")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("writes waited for the bus")
+	}
+
+	// Queued invalidations are published in order once the bus answers, Close waits for them
+	close(bus.release)
+	assert.NoError(t, c.Close())
+
+	assert.Equal(t, []string{"item1"}, (<-bus.published).Keys)
+	assert.Equal(t, []string{"item1"}, (<-bus.published).Keys)
+	assert.Empty(t, bus.published)
+}
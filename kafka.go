@@ -0,0 +1,534 @@
+package simplecache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	kafkaProduce  = 0
+	kafkaMetadata = 3
+
+	// Kafka error codes that call for fresh metadata
+	kafkaUnknownTopicOrPartition = 3
+	kafkaLeaderNotAvailable      = 5
+	kafkaNotLeaderForPartition   = 6
+
+	defaultKafkaTimeout  = 10 * time.Second
+	defaultKafkaClientID = "simplecache"
+
+	// The largest response accepted, the brokers' default socket.request.max.bytes
+	maxKafkaResponse = 100 << 20
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// KafkaProducer is a ChangelogProducer writing to a Kafka topic, speaking the Kafka protocol (Metadata v1, Produce v3).
+// Messages with the same key go to the same partition (FNV-1a of the key), messages without one are spread round-robin.
+// Compression, idempotence and transactions are not supported.
+type KafkaProducer struct {
+	// Brokers are "host:port" addresses used to look up the topic, any one of them is enough
+	Brokers []string
+	Topic   string
+
+	// Acks is -1 to wait for all in-sync replicas, or 1 (used when 0) for the partition leader only
+	Acks int16
+
+	// Timeout bounds every request, defaults to 10s
+	Timeout time.Duration
+
+	// ClientID defaults to "simplecache"
+	ClientID string
+
+	mu          sync.Mutex
+	leaders     map[int32]string
+	partitions  []int32
+	conns       map[string]*kafkaConn
+	correlation int32
+	next        int
+}
+
+type kafkaConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// KafkaError is an error code returned by a broker for a partition
+type KafkaError struct {
+	Partition int32
+	Code      int16
+}
+
+func (e *KafkaError) Error() string {
+	return fmt.Sprintf("simplecache: kafka: partition %d: error code %d", e.Partition, e.Code)
+}
+
+// Produce writes msgs with one request per partition leader, failing if any partition isn't acknowledged
+func (p *KafkaProducer) Produce(msgs []ChangelogMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.leaders == nil {
+		if err := p.refresh(); err != nil {
+			return err
+		}
+	}
+
+	batches := make(map[int32][]ChangelogMessage)
+	for _, msg := range msgs {
+		partition := p.partition(msg.Key)
+		batches[partition] = append(batches[partition], msg)
+	}
+
+	byLeader := make(map[string]map[int32][]ChangelogMessage)
+	for partition, batch := range batches {
+		leader := p.leaders[partition]
+		if byLeader[leader] == nil {
+			byLeader[leader] = make(map[int32][]ChangelogMessage)
+		}
+
+		byLeader[leader][partition] = batch
+	}
+
+	var errs []error
+	for leader, batches := range byLeader {
+		errs = append(errs, p.produce(leader, batches))
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		// The leaders may have moved
+		p.leaders = nil
+	}
+
+	return err
+}
+
+// Close closes the broker connections, the next Produce opens new ones
+func (p *KafkaProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for addr, c := range p.conns {
+		errs = append(errs, c.conn.Close())
+		delete(p.conns, addr)
+	}
+
+	p.leaders = nil
+
+	return errors.Join(errs...)
+}
+
+func (p *KafkaProducer) partition(key []byte) int32 {
+	if key == nil {
+		p.next++
+		return p.partitions[p.next%len(p.partitions)]
+	}
+
+	h := fnv.New32a()
+	h.Write(key)
+
+	return p.partitions[h.Sum32()%uint32(len(p.partitions))]
+}
+
+// refresh looks up the partitions of the topic and their leaders
+func (p *KafkaProducer) refresh() error {
+	var errs []error
+	for _, broker := range p.Brokers {
+		err := p.metadata(broker)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return errors.New("simplecache: kafka: no brokers")
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *KafkaProducer) metadata(broker string) error {
+	var req kafkaEncoder
+	req.array(1)
+	req.string(p.Topic)
+
+	res, err := p.request(broker, kafkaMetadata, 1, req.Bytes())
+	if err != nil {
+		return err
+	}
+
+	addrs := make(map[int32]string)
+	for range res.array() {
+		node := res.int32()
+		host := res.string()
+		port := res.int32()
+		res.nullableString() // rack
+
+		addrs[node] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+
+	res.int32() // controller
+
+	leaders := make(map[int32]string)
+	var partitions []int32
+
+	for range res.array() {
+		code := res.int16()
+		name := res.string()
+		res.int8() // internal
+
+		for range res.array() {
+			res.int16() // partition error
+			partition := res.int32()
+			leader := res.int32()
+
+			for range res.array() {
+				res.int32() // replicas
+			}
+
+			for range res.array() {
+				res.int32() // in-sync replicas
+			}
+
+			if name == p.Topic && addrs[leader] != "" {
+				leaders[partition] = addrs[leader]
+				partitions = append(partitions, partition)
+			}
+		}
+
+		if name == p.Topic && code != 0 {
+			return &KafkaError{Partition: -1, Code: code}
+		}
+	}
+
+	if res.err != nil {
+		return fmt.Errorf("simplecache: kafka metadata: %w", res.err)
+	}
+
+	if len(partitions) == 0 {
+		return fmt.Errorf("simplecache: kafka: no partitions with a leader for %s", p.Topic)
+	}
+
+	p.leaders, p.partitions = leaders, partitions
+
+	return nil
+}
+
+func (p *KafkaProducer) produce(leader string, batches map[int32][]ChangelogMessage) error {
+	acks := p.Acks
+	if acks == 0 {
+		acks = 1
+	}
+
+	var req kafkaEncoder
+	req.int16(-1) // no transactional id
+	req.int16(acks)
+	req.int32(int32(p.timeout() / time.Millisecond))
+	req.array(1)
+	req.string(p.Topic)
+	req.array(len(batches))
+
+	for partition, batch := range batches {
+		req.int32(partition)
+		req.bytes(recordBatch(batch))
+	}
+
+	res, err := p.request(leader, kafkaProduce, 3, req.Bytes())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for range res.array() {
+		res.string()
+
+		for range res.array() {
+			partition := res.int32()
+			code := res.int16()
+			res.int64() // base offset
+			res.int64() // log append time
+
+			if code != 0 {
+				errs = append(errs, &KafkaError{Partition: partition, Code: code})
+			}
+		}
+	}
+
+	if res.err != nil {
+		return fmt.Errorf("simplecache: kafka produce: %w", res.err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// request sends a request to addr and returns a decoder for the response body, a failed connection is dropped
+func (p *KafkaProducer) request(addr string, apiKey, version int16, body []byte) (*kafkaDecoder, error) {
+	c, err := p.conn(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	p.correlation++
+
+	var header kafkaEncoder
+	header.int16(apiKey)
+	header.int16(version)
+	header.int32(p.correlation)
+	header.string(p.clientID())
+
+	frame := binary.BigEndian.AppendUint32(nil, uint32(header.Len()+len(body)))
+	frame = append(append(frame, header.Bytes()...), body...)
+
+	c.conn.SetDeadline(time.Now().Add(p.timeout()))
+
+	res, err := p.roundTrip(c, frame)
+	if err != nil {
+		c.conn.Close()
+		delete(p.conns, addr)
+
+		return nil, fmt.Errorf("simplecache: kafka %s: %w", addr, err)
+	}
+
+	dec := &kafkaDecoder{data: res}
+	if correlation := dec.int32(); correlation != p.correlation {
+		c.conn.Close()
+		delete(p.conns, addr)
+
+		return nil, fmt.Errorf("simplecache: kafka %s: response out of order", addr)
+	}
+
+	return dec, nil
+}
+
+func (p *KafkaProducer) roundTrip(c *kafkaConn, frame []byte) ([]byte, error) {
+	if _, err := c.conn.Write(frame); err != nil {
+		return nil, err
+	}
+
+	var size int32
+	if err := binary.Read(c.r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+
+	if size < 0 || size > maxKafkaResponse {
+		return nil, fmt.Errorf("invalid response size %d", size)
+	}
+
+	res := make([]byte, size)
+	_, err := io.ReadFull(c.r, res)
+
+	return res, err
+}
+
+func (p *KafkaProducer) conn(addr string) (*kafkaConn, error) {
+	if c, ok := p.conns[addr]; ok {
+		return c, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, p.timeout())
+	if err != nil {
+		return nil, fmt.Errorf("simplecache: kafka: %w", err)
+	}
+
+	if p.conns == nil {
+		p.conns = make(map[string]*kafkaConn)
+	}
+
+	c := &kafkaConn{conn: conn, r: bufio.NewReader(conn)}
+	p.conns[addr] = c
+
+	return c, nil
+}
+
+func (p *KafkaProducer) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return defaultKafkaTimeout
+	}
+
+	return p.Timeout
+}
+
+func (p *KafkaProducer) clientID() string {
+	if p.ClientID == "" {
+		return defaultKafkaClientID
+	}
+
+	return p.ClientID
+}
+
+// recordBatch encodes msgs as a v2 record batch
+func recordBatch(msgs []ChangelogMessage) []byte {
+	base := msgs[0].Time.UnixMilli()
+	maxTime := base
+
+	var records kafkaEncoder
+	for i, msg := range msgs {
+		ts := msg.Time.UnixMilli()
+		maxTime = max(maxTime, ts)
+
+		var rec kafkaEncoder
+		rec.int8(0) // attributes
+		rec.varint(ts - base)
+		rec.varint(int64(i))
+
+		if msg.Key == nil {
+			rec.varint(-1)
+		} else {
+			rec.varint(int64(len(msg.Key)))
+			rec.Write(msg.Key)
+		}
+
+		rec.varint(int64(len(msg.Value)))
+		rec.Write(msg.Value)
+		rec.varint(0) // headers
+
+		records.varint(int64(rec.Len()))
+		records.Write(rec.Bytes())
+	}
+
+	// Everything after the CRC field is covered by it
+	var body kafkaEncoder
+	body.int16(0) // attributes
+	body.int32(int32(len(msgs) - 1))
+	body.int64(base)
+	body.int64(maxTime)
+	body.int64(-1) // producer id
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(msgs)))
+	body.Write(records.Bytes())
+
+	var batch kafkaEncoder
+	batch.int64(0) // base offset
+	batch.int32(int32(4 + 1 + 4 + body.Len()))
+	batch.int32(-1) // partition leader epoch
+	batch.int8(2)   // magic
+	batch.int32(int32(crc32.Checksum(body.Bytes(), crc32c)))
+	batch.Write(body.Bytes())
+
+	return batch.Bytes()
+}
+
+type kafkaEncoder struct {
+	bytes.Buffer
+}
+
+func (e *kafkaEncoder) int8(v int8) {
+	e.WriteByte(byte(v))
+}
+
+func (e *kafkaEncoder) int16(v int16) {
+	e.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+}
+
+func (e *kafkaEncoder) int32(v int32) {
+	e.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+}
+
+func (e *kafkaEncoder) int64(v int64) {
+	e.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+}
+
+func (e *kafkaEncoder) varint(v int64) {
+	e.Write(binary.AppendVarint(nil, v))
+}
+
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.WriteString(s)
+}
+
+func (e *kafkaEncoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.Write(b)
+}
+
+func (e *kafkaEncoder) array(n int) {
+	e.int32(int32(n))
+}
+
+// kafkaDecoder reads a response, the first error sticks and zero values are returned after it
+type kafkaDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(d.data) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+
+	return b
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	return string(d.take(int(d.int16())))
+}
+
+func (d *kafkaDecoder) nullableString() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+
+	return string(d.take(int(n)))
+}
+
+// array returns the element count for ranging over, 0 for null arrays
+func (d *kafkaDecoder) array() int {
+	return max(0, int(d.int32()))
+}
@@ -0,0 +1,221 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+type kafkaRecord struct {
+	Partition int32
+	Key       string
+	Value     []byte
+}
+
+// startFakeKafka answers Metadata v1 with two partitions led by itself and decodes Produce v3 batches into records
+func startFakeKafka(t *testing.T, topic string) (string, <-chan kafkaRecord) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	records := make(chan kafkaRecord, 16)
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			var size int32
+			if binary.Read(r, binary.BigEndian, &size) != nil {
+				return
+			}
+
+			req := make([]byte, size)
+			if _, err := io.ReadFull(r, req); err != nil {
+				return
+			}
+
+			buf := bytes.NewBuffer(req)
+			apiKey := int16(binary.BigEndian.Uint16(buf.Next(2)))
+			buf.Next(2) // version
+			correlation := buf.Next(4)
+			buf.Next(int(binary.BigEndian.Uint16(buf.Next(2)))) // client id
+
+			res := bytes.NewBuffer(append([]byte(nil), correlation...))
+			put := func(v ...any) {
+				for _, v := range v {
+					if s, ok := v.(string); ok {
+						binary.Write(res, binary.BigEndian, int16(len(s)))
+						res.WriteString(s)
+					} else {
+						binary.Write(res, binary.BigEndian, v)
+					}
+				}
+			}
+
+			switch apiKey {
+			case 3:
+				put(int32(1), int32(0), host, int32(port), int16(-1)) // brokers
+				put(int32(0))                                         // controller
+				put(int32(1), int16(0), topic, int8(0), int32(2))     // topic with 2 partitions
+				for partition := range 2 {
+					put(int16(0), int32(partition), int32(0), int32(1), int32(0), int32(1), int32(0))
+				}
+
+			case 0:
+				buf.Next(2 + 2 + 4) // transactional id, acks, timeout
+				buf.Next(4)         // topics
+				name := string(buf.Next(int(binary.BigEndian.Uint16(buf.Next(2)))))
+				partitions := int(binary.BigEndian.Uint32(buf.Next(4)))
+
+				put(int32(1), name, int32(partitions))
+				for range partitions {
+					partition := int32(binary.BigEndian.Uint32(buf.Next(4)))
+					batch := buf.Next(int(binary.BigEndian.Uint32(buf.Next(4))))
+
+					code := int16(0)
+					if !decodeKafkaBatch(batch, partition, records) {
+						code = 2 // corrupt message
+					}
+
+					put(partition, code, int64(0), int64(-1))
+				}
+				put(int32(0)) // throttle
+			}
+
+			binary.Write(conn, binary.BigEndian, int32(res.Len()))
+			conn.Write(res.Bytes())
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().String(), records
+}
+
+func decodeKafkaBatch(batch []byte, partition int32, records chan<- kafkaRecord) bool {
+	body := batch[8+4+4+1+4:]
+	if crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)) != binary.BigEndian.Uint32(batch[8+4+4+1:]) {
+		return false
+	}
+
+	r := bytes.NewReader(body[2+4+8+8+8+2+4:])
+
+	var count int32
+	binary.Read(r, binary.BigEndian, &count)
+
+	for range count {
+		binary.ReadVarint(r) // length
+		r.ReadByte()         // attributes
+		binary.ReadVarint(r) // timestamp delta
+		binary.ReadVarint(r) // offset delta
+
+		keyLen, _ := binary.ReadVarint(r)
+		key := make([]byte, max(0, keyLen))
+		r.Read(key)
+
+		valueLen, _ := binary.ReadVarint(r)
+		value := make([]byte, valueLen)
+		r.Read(value)
+
+		binary.ReadVarint(r) // headers
+
+		records <- kafkaRecord{Partition: partition, Key: string(key), Value: value}
+	}
+
+	return true
+}
+
+func TestPublishChangelog(t *testing.T) {
+	addr, records := startFakeKafka(t, "cache-changes")
+
+	producer := &cache.KafkaProducer{Brokers: []string{addr}, Topic: "cache-changes"}
+	defer producer.Close()
+
+	var errs []error
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().OnError(func(err error) { errs = append(errs, err) })
+	stop := c.PublishChangelog(producer, nil, nil)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	var received []kafkaRecord
+	for range 3 {
+		select {
+		case rec := <-records:
+			received = append(received, rec)
+		case <-time.After(time.Second):
+			t.Fatal("changelog message not produced")
+		}
+	}
+
+	assert.Empty(t, errs)
+	assert.Equal(t, "item1", received[0].Key)
+	assert.Equal(t, received[0].Partition, received[1].Partition)
+
+	var update struct {
+		Type     string
+		Key      string
+		Value    TestStruct
+		Previous TestStruct
+	}
+
+	assert.NoError(t, json.Unmarshal(received[1].Value, &update))
+	assert.Equal(t, "updated", update.Type)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 31}, update.Value)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, update.Previous)
+
+	stop()
+	c.Delete("item2")
+	assert.Empty(t, records)
+}
+
+func TestKafkaProducerRejectsCorruptResponseSize(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var size int32
+		binary.Read(conn, binary.BigEndian, &size)
+		io.CopyN(io.Discard, conn, int64(size))
+
+		binary.Write(conn, binary.BigEndian, int32(-5))
+	}()
+
+	producer := &cache.KafkaProducer{Brokers: []string{ln.Addr().String()}, Topic: "cache-changes", Timeout: time.Second}
+	defer producer.Close()
+
+	err = producer.Produce([]cache.ChangelogMessage{{Key: []byte("item1"), Value: []byte("{}")}})
+	assert.ErrorContains(t, err, "invalid response size -5")
+}
@@ -0,0 +1,37 @@
+package simplecache
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultNamespaceSeparator = ":"
+
+// WithNamespaceSeparator sets what separates the namespace from the rest of a key, "tenant:user:1" is in namespace "tenant" by default
+func (c *Cache[K, T]) WithNamespaceSeparator(sep string) *Cache[K, T] {
+	c.namespaceSeparator = sep
+
+	return c
+}
+
+// keyString renders a key for prefix and pattern matching
+func keyString[K comparable](key K) string {
+	switch k := any(key).(type) {
+	case string:
+		return k
+	case fmt.Stringer:
+		return k.String()
+	}
+
+	return fmt.Sprint(key)
+}
+
+// namespaceOf returns the part of the key before the first separator, keys without one have no namespace
+func (c *Cache[K, T]) namespaceOf(key K) string {
+	namespace, _, found := strings.Cut(keyString(key), c.namespaceSeparator)
+	if !found {
+		return ""
+	}
+
+	return namespace
+}
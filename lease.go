@@ -0,0 +1,203 @@
+package simplecache
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLeaseWindow = 10 * time.Second
+	defaultLeaseTTL    = 10 * time.Second
+)
+
+// Leaser grants leases on keys, at most one holder per key until the lease is released or its ttl runs out
+type Leaser interface {
+	Acquire(key, token string, ttl time.Duration) (bool, error)
+	Release(key, token string) error
+}
+
+// Lease lets its holder refresh a key, Set publishes the new value and gives the lease up
+type Lease[K comparable, T any] struct {
+	c     *Cache[K, T]
+	key   K
+	token string
+}
+
+// WithLeases enables GetWithLease, leaser coordinates the nodes (a RedisLeaser across a cluster, nil for a LocalLeaser).
+// Items expiring within window get refreshed by a lease holder, a lease not given up within ttl is granted again.
+func (c *Cache[K, T]) WithLeases(leaser Leaser, window, ttl time.Duration) *Cache[K, T] {
+	if leaser == nil {
+		leaser = &LocalLeaser{}
+	}
+
+	c.leaser = leaser
+	c.leaseWindow = window
+	c.leaseTTL = ttl
+
+	return c
+}
+
+// GetWithLease is Get handing out a lease to the one caller that should refresh the key, across every node sharing the
+// Leaser. A miss or an item expiring soon comes with a lease if nobody holds one, the others keep getting the current value
+// (a miss for a missing key) until the holder calls Set. A lease the Leaser failed to grant is reported to OnError.
+func (c *Cache[K, T]) GetWithLease(key K) (T, *Lease[K, T], bool) {
+	leaser := c.leases()
+	now := c.now()
+
+	item, ok := c.lookup(key, now)
+	if ok && (item.Expires.IsZero() || item.Expires.Sub(now) > c.leaseWindow) {
+		value, ok := c.Get(key)
+		return value, nil, ok
+	}
+
+	value, ok := c.Get(key)
+
+	token := make([]byte, 16)
+	rand.Read(token)
+
+	lease := &Lease[K, T]{c: c, key: key, token: hex.EncodeToString(token)}
+
+	granted, err := leaser.Acquire(keyString(key), lease.token, c.leaseTTL)
+	if err != nil {
+		c.reportError(err)
+	}
+
+	if !granted {
+		return value, nil, ok
+	}
+
+	c.addMetric(metricLeases, 1)
+
+	return value, lease, ok
+}
+
+func (l *Lease[K, T]) Key() K {
+	return l.key
+}
+
+// Set writes the refreshed value and releases the lease
+func (l *Lease[K, T]) Set(value T, expires ...time.Time) error {
+	if err := l.c.SetE(l.key, value, expires...); err != nil {
+		l.Release()
+		return err
+	}
+
+	return l.Release()
+}
+
+// Release gives the lease up without a new value, e.g. when loading it failed
+func (l *Lease[K, T]) Release() error {
+	return l.c.leases().Release(keyString(l.key), l.token)
+}
+
+func (c *Cache[K, T]) leases() Leaser {
+	c.leaseOnce.Do(func() {
+		if c.leaser == nil {
+			c.leaser = &LocalLeaser{}
+		}
+
+		if c.leaseWindow <= 0 {
+			c.leaseWindow = defaultLeaseWindow
+		}
+
+		if c.leaseTTL <= 0 {
+			c.leaseTTL = defaultLeaseTTL
+		}
+	})
+
+	return c.leaser
+}
+
+// LocalLeaser grants leases within one process, e.g. to caches sharing a Tiered L2
+type LocalLeaser struct {
+	mu     sync.Mutex
+	leases map[string]localLease
+}
+
+type localLease struct {
+	token   string
+	expires time.Time
+}
+
+func (l *LocalLeaser) Acquire(key, token string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := l.leases[key]; ok && lease.expires.After(now) {
+		return false, nil
+	}
+
+	if l.leases == nil {
+		l.leases = make(map[string]localLease)
+	}
+
+	l.leases[key] = localLease{token: token, expires: now.Add(ttl)}
+
+	return true, nil
+}
+
+func (l *LocalLeaser) Release(key, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.leases[key].token == token {
+		delete(l.leases, key)
+	}
+
+	return nil
+}
+
+// releaseScript deletes a lease only while it's still held with the token
+const releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// RedisLeaser grants leases across a cluster as Redis keys set with NX and an expiry
+type RedisLeaser struct {
+	Addr     string
+	Password string
+
+	// TLS connects over TLS when set
+	TLS *tls.Config
+
+	// Prefix is put in front of the lease keys, defaults to "simplecache:lease:"
+	Prefix string
+
+	once sync.Once
+	conn *respConn
+}
+
+func (l *RedisLeaser) Acquire(key, token string, ttl time.Duration) (bool, error) {
+	reply, err := l.redis().do("SET", l.key(key), token, "NX", "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+
+	return reply != nil, err
+}
+
+func (l *RedisLeaser) Release(key, token string) error {
+	_, err := l.redis().do("EVAL", releaseScript, "1", l.key(key), token)
+	return err
+}
+
+// Close closes the connection, the next command opens a new one
+func (l *RedisLeaser) Close() error {
+	return l.redis().close()
+}
+
+func (l *RedisLeaser) redis() *respConn {
+	l.once.Do(func() {
+		l.conn = &respConn{addr: l.Addr, password: l.Password, tls: l.TLS}
+	})
+
+	return l.conn
+}
+
+func (l *RedisLeaser) key(key string) string {
+	if l.Prefix == "" {
+		return "simplecache:lease:" + key
+	}
+
+	return l.Prefix + key
+}
@@ -0,0 +1,76 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWithLease(t *testing.T) {
+	// Two nodes sharing the leaser
+	leaser := &cache.LocalLeaser{}
+	node1 := cache.New[string, int]().WithLeases(leaser, time.Minute, time.Minute)
+	node2 := cache.New[string, int]().WithLeases(leaser, time.Minute, time.Minute)
+
+	// Fresh items come without a lease
+	node1.Set("fresh", 1, time.Now().Add(time.Hour))
+	value, lease, ok := node1.GetWithLease("fresh")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Nil(t, lease)
+
+	// One node refreshes an item about to expire, the other keeps serving it
+	node1.Set("hot", 1, time.Now().Add(time.Second))
+	node2.Set("hot", 1, time.Now().Add(time.Second))
+
+	value, lease, ok = node1.GetWithLease("hot")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.NotNil(t, lease)
+	assert.Equal(t, "hot", lease.Key())
+
+	value, other, ok := node2.GetWithLease("hot")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Nil(t, other)
+
+	assert.NoError(t, lease.Set(2, time.Now().Add(time.Hour)))
+	value, _ = node1.Get("hot")
+	assert.Equal(t, 2, value)
+
+	// Misses too, until the holder gives up
+	_, lease, ok = node1.GetWithLease("missing")
+	assert.False(t, ok)
+	assert.NotNil(t, lease)
+
+	_, other, _ = node2.GetWithLease("missing")
+	assert.Nil(t, other)
+
+	assert.NoError(t, lease.Release())
+	_, other, _ = node2.GetWithLease("missing")
+	assert.NotNil(t, other)
+}
+
+func TestRedisLeaser(t *testing.T) {
+	leaser := &cache.RedisLeaser{Addr: startFakeRedis(t)}
+	defer leaser.Close()
+
+	granted, err := leaser.Acquire("key", "token1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, granted)
+
+	granted, err = leaser.Acquire("key", "token2", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, granted)
+
+	// Only the holder releases
+	assert.NoError(t, leaser.Release("key", "token2"))
+	granted, _ = leaser.Acquire("key", "token2", time.Minute)
+	assert.False(t, granted)
+
+	assert.NoError(t, leaser.Release("key", "token1"))
+	granted, _ = leaser.Acquire("key", "token2", time.Minute)
+	assert.True(t, granted)
+}
@@ -0,0 +1,125 @@
+package simplecache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// applyRecovered writes a mutation read back from a snapshot, a delta or the WAL straight to the map, without the
+// interceptors, audit and invalidations of Set and Delete. Like LoadMany without notify, it isn't reported as a change.
+func (c *Cache[K, T]) applyRecovered(op walOp, key K, item Item[T]) error {
+	c.lock()
+	defer c.Unlock()
+
+	if !c.immediate && c.prev == nil {
+		c.prev = make(map[K]Item[T])
+	}
+
+	if op == walDeleteAll {
+		clear(c.prev)
+
+		return errors.Join(c.clearData(), c.appendWAL(walDeleteAll, key, item))
+	}
+
+	data := c.writable()
+
+	if existing, exists := data.Get(key); exists {
+		c.updateMemoryUsage(key, existing, false)
+	}
+
+	if op == walSet {
+		c.updateMemoryUsage(key, item, true)
+		data.Set(key, item)
+	} else {
+		data.Delete(key)
+		c.itemHits.forget(key)
+		c.overflowAccess.forget(key)
+	}
+
+	c.commit(data)
+	c.setMetric(metricItems, data.Len())
+	c.markDirty(key)
+
+	switch {
+	case c.immediate:
+		// Nothing is compared between ticks
+	case op == walSet:
+		c.prev[key] = item
+	default:
+		delete(c.prev, key)
+	}
+
+	return errors.Join(c.appendWAL(op, key, item), c.dropSpilled(key))
+}
+
+// LoadMany installs items under a single lock keeping their expirations, expired items are skipped.
+// Interceptors are not run. With notify false the items are not reported as created or updated.
+func (c *Cache[K, T]) LoadMany(items map[K]Item[T], notify bool) {
+	now := time.Now()
+
+	c.lock()
+
+	var changes ChangeSet[K, T]
+	var errs []error
+	discarded := 0
+
+	data := c.writable()
+	for key, item := range items {
+		if item.expired(now) {
+			discarded++
+			continue
+		}
+
+		existing, exists := data.Get(key)
+		if exists {
+			c.updateMemoryUsage(key, existing, false)
+		}
+
+		c.updateMemoryUsage(key, item, true)
+		data.Set(key, item)
+
+		errs = append(errs, c.appendWAL(walSet, key, item), c.dropSpilled(key))
+		c.markDirty(key)
+
+		switch {
+		case !notify:
+			// Let the next tick see the item as already known
+			if !c.immediate {
+				if c.prev == nil {
+					c.prev = make(map[K]Item[T])
+				}
+
+				c.prev[key] = item
+			}
+
+		case !c.immediate:
+			// The next tick reports it
+
+		case !exists:
+			changes.Created = append(changes.Created, Change[K, T]{Key: key, Value: item.Value})
+
+		case !c.equal(item.Value, existing.Value):
+			changes.Updated = append(changes.Updated, Change[K, T]{Key: key, Value: item.Value, Previous: existing.Value})
+		}
+	}
+
+	c.commit(data)
+	c.setMetric(metricItems, data.Len())
+
+	c.Unlock()
+
+	if err := errors.Join(errs...); err != nil {
+		c.reportError(err)
+	}
+
+	c.reportDiscarded(discarded)
+
+	for key, item := range items {
+		if !item.expired(now) {
+			c.audit(context.Background(), AuditSet, key)
+		}
+	}
+
+	c.dispatch(context.Background(), changes)
+}
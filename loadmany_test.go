@@ -0,0 +1,71 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMany(t *testing.T) {
+	created := make([]string, 0)
+	expires := time.Now().Add(time.Hour)
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		OnCreateKeyed(func(changes []cache.Change[string, TestStruct]) {
+			for _, change := range changes {
+				created = append(created, change.Key)
+			}
+		})
+
+	c.LoadMany(map[string]cache.Item[TestStruct]{
+		"item1": {Value: TestStruct{Name: "Alice", Age: 30}, Expires: expires},
+		"item2": {Value: TestStruct{Name: "Bob", Age: 25}, Expires: time.Now().Add(-time.Minute)},
+	}, true)
+
+	c.LoadMany(map[string]cache.Item[TestStruct]{
+		"item3": {Value: TestStruct{Name: "Carol", Age: 40}},
+	}, false)
+
+	assert.Equal(t, []string{"item1"}, created)
+	assert.Equal(t, int64(2), c.Stats().Items)
+
+	item1, ok := c.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+
+	_, ok = c.Get("item2")
+	assert.False(t, ok)
+}
+
+func TestLoadManyQuietTick(t *testing.T) {
+	created := make(chan string, 16)
+
+	c := cache.New[string, TestStruct]().WithInterval(50 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		OnCreateKeyed(func(changes []cache.Change[string, TestStruct]) {
+			for _, change := range changes {
+				created <- change.Key
+			}
+		})
+
+	c.LoadMany(map[string]cache.Item[TestStruct]{"item1": {Value: TestStruct{Name: "Alice", Age: 30}}}, false)
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	go c.Maintain()
+	time.Sleep(80 * time.Millisecond)
+	c.Stop()
+
+	close(created)
+	assert.Equal(t, []string{"item2"}, collect(created))
+}
+
+func collect[V any](ch <-chan V) []V {
+	res := make([]V, 0)
+	for v := range ch {
+		res = append(res, v)
+	}
+
+	return res
+}
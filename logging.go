@@ -0,0 +1,74 @@
+package simplecache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Dropped events are logged at most once per interval, with the number dropped so far
+const droppedEventsLogInterval = time.Second
+
+// WithLogger logs the Maintain lifecycle and slow ticks at debug and warn level, dropped events, reported errors
+// and recovered panics as warnings. Nothing is logged without it.
+func (c *Cache[K, T]) WithLogger(logger *slog.Logger) *Cache[K, T] {
+	c.logger = logger
+
+	return c
+}
+
+func (c *Cache[K, T]) logDebug(msg string, args ...any) {
+	if c.logger != nil {
+		c.logger.Debug(msg, args...)
+	}
+}
+
+func (c *Cache[K, T]) logWarn(msg string, args ...any) {
+	if c.logger != nil {
+		c.logger.Warn(msg, args...)
+	}
+}
+
+// logError logs an error reported to OnError, with the stack of a recovered panic
+func (c *Cache[K, T]) logError(err error) {
+	if c.logger == nil {
+		return
+	}
+
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		c.logger.Warn("simplecache: recovered panic", "panic", panicErr.Value, "stack", string(panicErr.Stack))
+		return
+	}
+
+	c.logger.Warn("simplecache: error", "error", err)
+}
+
+// logTick logs a tick taking longer than the interval, ticks are skipped meanwhile
+func (c *Cache[K, T]) logTick(stats TickStats) {
+	if c.logger == nil || stats.Duration <= c.interval {
+		return
+	}
+
+	c.logger.Warn("simplecache: slow tick", "duration", stats.Duration, "interval", c.interval,
+		"scanned", stats.Scanned, "lockWait", stats.LockWait)
+}
+
+// dropEvent counts an event dropped for lack of room, logging the drops now and then
+func (c *Cache[K, T]) dropEvent() {
+	c.addMetric(metricDroppedEvents, 1)
+
+	if c.logger == nil {
+		return
+	}
+
+	now := time.Now().UnixNano()
+	last := c.droppedLogged.Load()
+	if now-last < int64(droppedEventsLogInterval) || !c.droppedLogged.CompareAndSwap(last, now) {
+		return
+	}
+
+	c.logger.LogAttrs(context.Background(), slog.LevelWarn, "simplecache: events dropped",
+		slog.Int64("dropped", c.metrics[metricDroppedEvents].Load()))
+}
@@ -0,0 +1,56 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from Maintain while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestLogger(t *testing.T) {
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := cache.New[string, int]().WithLogger(logger).WithImmediateNotifications().WithEventBuffer(0).
+		WithInterval(10 * time.Millisecond).
+		OnCreate(func([]int) { panic("middleware bug") })
+
+	c.Events()
+	c.Set("item1", 1)
+	c.Set("item2", 2)
+
+	assert.Contains(t, logs.String(), `level=WARN msg="simplecache: recovered panic" panic="middleware bug"`)
+	assert.Contains(t, logs.String(), `level=WARN msg="simplecache: events dropped" dropped=1`)
+	assert.NotContains(t, logs.String(), "dropped=2")
+
+	go c.Maintain()
+	assert.Eventually(t, func() bool { return c.Stats().LastTickScanned == 2 }, time.Second, 5*time.Millisecond)
+	c.Stop()
+
+	assert.Contains(t, logs.String(), `level=DEBUG msg="simplecache: maintain started" interval=10ms`)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="simplecache: maintain stopped"`)
+}
@@ -1,108 +1,332 @@
 package simplecache
 
 import (
+	"context"
+	"crypto/cipher"
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
-	"unsafe"
 )
 
 type TickMiddleware func()
 type Middleware[T any] func([]T)
-type ExpiryMiddleware[T any] func(string, Item[T])
+type ExpiryMiddleware[K comparable, T any] func(K, Item[T])
 
 type Item[T any] struct {
 	Value   T
 	Expires time.Time
 }
 
-type Cache[T any] struct {
+func (i Item[T]) expired(now time.Time) bool {
+	return !i.Expires.IsZero() && i.Expires.Before(now)
+}
+
+type Cache[K comparable, T any] struct {
 	sync.RWMutex
 
-	data        map[any]Item[T]
-	prev        map[any]Item[T]
+	data        Store[K, T]
+	prev        map[K]Item[T]
 	interval    time.Duration
 	compareFunc func(a, b T) bool
 
-	stopChan chan struct{}
-	updates  map[string][]T
-
-	beforeTickMiddleware []TickMiddleware
-	afterTickMiddleware  []TickMiddleware
-
-	createMiddlewares []Middleware[T]
-	updateMiddlewares []Middleware[T]
-	deleteMiddlewares []Middleware[T]
-	expiryMiddlewares []ExpiryMiddleware[T]
-
-	Metrics map[string]int
+	// Copy-on-write mode, writes swap in a new map and reads load it without locking
+	copyOnWrite bool
+	snapshot    atomic.Pointer[MapStore[K, T]]
+
+	// Context handed to context-aware middlewares, canceled when Maintain stops
+	parentContext     context.Context
+	lifecycle         atomic.Pointer[context.Context]
+	middlewareTimeout time.Duration
+
+	// Updates held back by the coalesce window, flushed as one batch when it closes
+	coalesceWindow time.Duration
+	coalesceMu     sync.Mutex
+	pending        map[K]Change[K, T]
+	pendingOrder   []K
+
+	// Change feed, every delivered change gets the next sequence number
+	feedMu  sync.Mutex
+	seq     uint64
+	feed    []Event[K, T]
+	feedLen int
+
+	// Immediate mode, change middlewares fire on Set/Delete instead of being diffed on tick
+	immediate bool
+
+	// Coarse clock updated by Maintain, used for expiry checks on reads
+	clockResolution time.Duration
+	clock           atomic.Int64
+
+	stopChan chan chan struct{}
+	changes  ChangeSet[K, T]
+
+	updatesRetention int
+
+	// Async dispatch, middlewares run on a separate goroutine fed by a bounded queue
+	asyncQueue           chan func()
+	blockOnFullQueue     bool
+	queueFullMiddlewares []QueueFullMiddleware
+
+	// The goroutine draining asyncQueue, started by the first batch and stopped by Close
+	dispatcherMu      sync.RWMutex
+	dispatcherDone    chan struct{}
+	dispatcherStopped chan struct{}
+	dispatcherClosed  bool
+
+	auditSink AuditSink
+
+	setInterceptors []SetInterceptor[K, T]
+	getInterceptors []GetInterceptor[K, T]
+
+	// Registered middlewares, replaced on every (un)subscribe so dispatch can iterate a snapshot
+	middlewaresMu sync.RWMutex
+	middlewares   []*Middlewares[K, T]
+
+	namespaceSeparator string
+
+	concurrency map[EventType]int
+
+	codec           Codec[T]
+	snapshotVersion int
+	migration       Migration[T]
+	beforeSave      []PersistMiddleware[K, T]
+	afterLoad       []PersistMiddleware[K, T]
+
+	discardMiddlewares []DiscardMiddleware
+	keyProvider        KeyProvider
+	compression        Compression
+	aeadMu             sync.Mutex
+	aead               cipher.AEAD
+	snapshotTarget     SnapshotTarget
+	autoSaveInterval   time.Duration
+	persistOnStop      bool
+	wal                *os.File
+	walPath            string
+	walWrites          int
+	walCompacting      bool
+	walPending         [][]byte
+
+	walCompactInterval time.Duration
+
+	incrementalDir        string
+	incrementalInterval   time.Duration
+	incrementalFullEvery  int
+	incrementalSaves      int
+	incrementalGeneration int64
+	dirty                 map[K]struct{}
+	needsFull             bool
+
+	overflow       OverflowTier
+	overflowMax    int
+	overflowAccess *overflowAccess[K]
+	spilled        map[K]time.Time
+
+	invalidationBus         atomic.Pointer[InvalidationBus[K]]
+	invalidationOrigin      string
+	invalidationUnsubscribe func()
+
+	// Invalidations waiting for the publisher goroutine, nil once the bus is closed
+	invalidationMu        sync.RWMutex
+	invalidationQueue     chan Invalidation[K]
+	invalidationPublished chan struct{}
+
+	serverTLS  *tls.Config
+	serverAuth Authenticator
+
+	origin        RemoteOrigin[K, T]
+	originOptions OriginOptions
+	originFlights flightGroup[T]
+	negativeMu    sync.Mutex
+	negatives     map[K]time.Time
+
+	leaser      Leaser
+	leaseOnce   sync.Once
+	leaseWindow time.Duration
+	leaseTTL    time.Duration
+
+	ready      chan struct{}
+	warmupLoad func() (map[K]Item[T], error)
+	warmupErr  atomic.Pointer[error]
+
+	// Errors from options applied while building the cache, reported once Maintain starts
+	startErrs []error
+
+	// Startup work of the options, run once they are all applied, see Open
+	openOnce sync.Once
+	openErr  error
+
+	maxAttempts           int
+	retryBackoff          time.Duration
+	deadLetterMu          sync.Mutex
+	deadLetterMiddlewares []DeadLetterMiddleware[K, T]
+
+	observersMu sync.Mutex
+	observers   map[Observer[K, T]]func()
+
+	// Number of registrations with OnHit/OnMiss, lets Get skip the lookup otherwise
+	accessMiddlewares atomic.Int32
+
+	subsMu        sync.Mutex
+	events        *subscription[K, T]
+	subscriptions []*subscription[K, T]
+	eventBuffer   int
+
+	// Flow control of the network streams, see WithStreamFlowControl
+	streamInFlight int
+	streamPolicy   SlowConsumerPolicy
+
+	lockMetrics bool
+	lockStats   lockStats
+	sampler     *sampler
+
+	telemetry Telemetry
+
+	metrics [metricCount]atomic.Int64
+	rates   rates
+	janitor janitorHealth
+
+	statsReporters []statsReporter
+
+	httpBodyLimit int64
+
+	logger        *slog.Logger
+	droppedLogged atomic.Int64
+
+	hotKeys        *hotKeys[K]
+	namespaceStats *namespaceStats
+	itemHits       *itemHits[K]
+
+	// Measures values for TopBySize and WithAccurateMemory, DeepSize when nil
+	sizer          Sizer[T]
+	accurateMemory bool
+	memoryRecount  time.Duration
 }
 
-func New[T any]() *Cache[T] {
-	return &Cache[T]{
-		data:     make(map[any]Item[T]),
-		prev:     make(map[any]Item[T]),
-		updates:  make(map[string][]T),
-		stopChan: make(chan struct{}),
-		Metrics: map[string]int{
-			"hits":             0,
-			"misses":           0,
-			"items":            0,
-			"memoryUsageBytes": 0,
-		},
+const defaultUpdatesRetention = 1024
+
+func New[K comparable, T any]() *Cache[K, T] {
+	return &Cache[K, T]{
+		data:               make(MapStore[K, T]),
+		prev:               make(map[K]Item[T]),
+		updatesRetention:   defaultUpdatesRetention,
+		eventBuffer:        defaultEventBuffer,
+		parentContext:      context.Background(),
+		namespaceSeparator: defaultNamespaceSeparator,
+		stopChan:           make(chan chan struct{}),
 	}
 }
 
-func (c *Cache[T]) Equals(f func(a, b T) bool) *Cache[T] {
+// Equals sets how values are compared to detect updates, reflect.DeepEqual by default
+func (c *Cache[K, T]) Equals(f func(a, b T) bool) *Cache[K, T] {
 	c.compareFunc = f
 
 	return c
 }
 
-func (c *Cache[T]) WithInterval(d time.Duration) *Cache[T] {
+func (c *Cache[K, T]) WithInterval(d time.Duration) *Cache[K, T] {
 	c.interval = d
 
 	return c
 }
 
-func (c *Cache[T]) OnCreate(m Middleware[T]) *Cache[T] {
-	c.createMiddlewares = append(c.createMiddlewares, m)
+// WithUpdatesRetention caps the capacity kept by the per-tick change buffers between ticks
+func (c *Cache[K, T]) WithUpdatesRetention(n int) *Cache[K, T] {
+	c.updatesRetention = n
 
 	return c
 }
 
-func (c *Cache[T]) OnUpdate(m Middleware[T]) *Cache[T] {
-	c.updateMiddlewares = append(c.updateMiddlewares, m)
+func (c *Cache[K, T]) WithCoarseClock(resolution time.Duration) *Cache[K, T] {
+	c.clockResolution = resolution
 
 	return c
 }
 
-func (c *Cache[T]) OnDelete(m Middleware[T]) *Cache[T] {
-	c.deleteMiddlewares = append(c.deleteMiddlewares, m)
+func (c *Cache[K, T]) WithLockMetrics() *Cache[K, T] {
+	c.lockMetrics = true
 
 	return c
 }
 
-func (c *Cache[T]) OnExpiry(m ExpiryMiddleware[T]) *Cache[T] {
-	c.expiryMiddlewares = append(c.expiryMiddlewares, m)
+func (c *Cache[K, T]) WithCopyOnWrite() *Cache[K, T] {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.data.(MapStore[K, T]); !ok {
+		c.startErrs = append(c.startErrs, errCopyOnWriteStore)
+		return c
+	}
+
+	c.copyOnWrite = true
+	c.commit(c.data)
 
 	return c
 }
 
-func (c *Cache[T]) OnBeforeTick(m TickMiddleware) *Cache[T] {
-	c.beforeTickMiddleware = append(c.beforeTickMiddleware, m)
+func (c *Cache[K, T]) OnCreate(m Middleware[T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnCreate: m})
 
 	return c
 }
 
-func (c *Cache[T]) OnAfterTick(m TickMiddleware) *Cache[T] {
-	c.afterTickMiddleware = append(c.afterTickMiddleware, m)
+func (c *Cache[K, T]) OnUpdate(m Middleware[T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnUpdate: m})
 
 	return c
 }
 
-func (c *Cache[T]) Set(key any, value T, expires ...time.Time) {
-	c.Lock()
-	defer c.Unlock()
+func (c *Cache[K, T]) OnDelete(m Middleware[T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnDelete: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnExpiry(m ExpiryMiddleware[K, T]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnExpiry: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnBeforeTick(m TickMiddleware) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnBeforeTick: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnAfterTick(m TickMiddleware) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnAfterTick: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) Set(key K, value T, expires ...time.Time) {
+	if err := c.SetE(key, value, expires...); err != nil {
+		c.reportError(err)
+	}
+}
+
+// SetE is Set returning the error of a rejected write instead of reporting it to OnError
+func (c *Cache[K, T]) SetE(key K, value T, expires ...time.Time) error {
+	return c.SetContext(context.Background(), key, value, expires...)
+}
+
+// SetContext is SetE carrying a context, e.g. the actor recorded by the audit log
+func (c *Cache[K, T]) SetContext(ctx context.Context, key K, value T, expires ...time.Time) error {
+	value, err := c.interceptSet(key, value)
+	if err != nil {
+		return err
+	}
+
+	probe := c.probe(opSet)
+	c.lock()
+	probe.acquired()
 
 	var expiration time.Time
 	if len(expires) > 0 {
@@ -115,51 +339,200 @@ func (c *Cache[T]) Set(key any, value T, expires ...time.Time) {
 	}
 
 	// Update memory usage, remove old item if exists
-	existingItem, exists := c.data[key]
+	existingItem, exists := c.data.Get(key)
 	if exists {
-		c.updateMemoryUsage(existingItem, false)
+		c.updateMemoryUsage(key, existingItem, false)
 	}
 
-	c.updateMemoryUsage(item, true)
+	c.updateMemoryUsage(key, item, true)
+
+	data := c.writable()
+	data.Set(key, item)
+	c.commit(data)
+
+	c.setMetric(metricItems, data.Len())
+	c.addMetric(metricSets, 1)
+
+	walErr := c.appendWAL(walSet, key, item)
+	c.markDirty(key)
+	overflowErr := c.dropSpilled(key)
+	c.overflowAccess.touch(key)
 
-	c.data[key] = item
-	c.Metrics["items"] = len(c.data)
+	var changes ChangeSet[K, T]
+	if c.immediate {
+		if !exists {
+			changes.Created = []Change[K, T]{{Key: key, Value: value}}
+		} else if !c.equal(value, existingItem.Value) {
+			changes.Updated = []Change[K, T]{{Key: key, Value: value, Previous: existingItem.Value}}
+		}
+	}
+
+	c.Unlock()
+	probe.released()
+
+	if walErr != nil {
+		c.reportError(walErr)
+	}
+
+	if overflowErr != nil {
+		c.reportError(overflowErr)
+	}
+
+	c.audit(ctx, AuditSet, key)
+	c.invalidate(ctx, false, key)
+
+	// Middlewares run outside the lock so they can use the cache
+	c.dispatch(ctx, changes)
+
+	return nil
 }
 
-func (c *Cache[T]) updateMemoryUsage(item Item[T], add bool) {
-	size := int(unsafe.Sizeof(item)) + int(unsafe.Sizeof(item.Value)) + int(unsafe.Sizeof(item.Expires))
+func (c *Cache[K, T]) equal(a, b T) bool {
+	if c.compareFunc == nil {
+		return reflect.DeepEqual(a, b)
+	}
 
-	if add {
-		c.Metrics["memoryUsageBytes"] += size
-	} else {
-		c.Metrics["memoryUsageBytes"] -= size
+	return c.compareFunc(a, b)
+}
+
+// writable returns the store writes should be applied to, in copy-on-write mode it is a fresh copy
+func (c *Cache[K, T]) writable() Store[K, T] {
+	if !c.copyOnWrite {
+		return c.data
 	}
+
+	return maps.Clone(c.data.(MapStore[K, T]))
 }
 
-func (c *Cache[T]) Get(key any) (T, bool) {
-	c.RLock()
-	defer c.RUnlock()
+// commit installs data as the current store and publishes it to lock-free readers
+func (c *Cache[K, T]) commit(data Store[K, T]) {
+	c.data = data
+
+	if c.copyOnWrite {
+		m := data.(MapStore[K, T])
+		c.snapshot.Store(&m)
+	}
+}
+
+func (c *Cache[K, T]) updateMemoryUsage(key K, item Item[T], add bool) {
+	size, items := c.memorySize(key, item), 1
+
+	if !add {
+		size, items = -size, -1
+	}
+
+	c.account(key, items, size)
+}
+
+// account adds items and size bytes to the namespace of key, and size to the memory in use
+func (c *Cache[K, T]) account(key K, items, size int) {
+	c.addMetric(metricMemoryBytes, size)
+
+	if c.namespaceStats != nil {
+		c.namespaceStats.resize(c.namespaceOf(key), items, size)
+	}
+}
+
+// now returns the coarse clock while Maintain keeps it fresh, time.Now() otherwise
+func (c *Cache[K, T]) now() time.Time {
+	if ns := c.clock.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+
+	return time.Now()
+}
+
+func (c *Cache[K, T]) Get(key K) (T, bool) {
+	if c.hotKeys != nil {
+		c.recordRead(key)
+	}
+
+	item, exists := c.lookup(key, c.now())
+
+	if c.namespaceStats != nil {
+		c.namespaceStats.read(c.namespaceOf(key), exists)
+	}
+
+	if !exists {
+		c.addMetric(metricMisses, 1)
 
-	item, exists := c.data[key]
-	if !exists || (!item.Expires.IsZero() && item.Expires.Before(time.Now())) {
-		c.Metrics["misses"]++
+		if c.accessMiddlewares.Load() > 0 {
+			c.notifyAccess(key, false)
+		}
 
 		var zero T
 		return zero, false
 	}
 
-	c.Metrics["hits"]++
+	c.addMetric(metricHits, 1)
+
+	if c.itemHits != nil {
+		c.itemHits.hit(key)
+	}
+
+	if c.accessMiddlewares.Load() > 0 {
+		c.notifyAccess(key, true)
+	}
+
+	if len(c.getInterceptors) > 0 {
+		return c.interceptGet(key, item.Value), true
+	}
 
 	return item.Value, true
 }
 
-func (c *Cache[T]) GetAll() []T {
-	c.RLock()
+// lookup returns the unexpired item stored under key, without metrics, access middlewares or interceptors
+func (c *Cache[K, T]) lookup(key K, now time.Time) (Item[T], bool) {
+	var item Item[T]
+	var exists bool
+
+	probe := c.probe(opGet)
+
+	// Hot path, unlock explicitly instead of deferring
+	if c.copyOnWrite {
+		probe.acquired()
+		item, exists = c.snapshot.Load().Get(key)
+	} else {
+		c.rlock()
+		probe.acquired()
+		item, exists = c.data.Get(key)
+		c.RUnlock()
+	}
+
+	probe.released()
+
+	if c.overflow != nil {
+		if !exists || item.expired(now) {
+			item, exists = c.promote(key, now)
+		} else {
+			c.overflowAccess.touch(key)
+		}
+	}
+
+	return item, exists && !item.expired(now)
+}
+
+func (c *Cache[K, T]) GetAll() []T {
+	if c.copyOnWrite {
+		return c.values(*c.snapshot.Load(), c.now())
+	}
+
+	c.rlock()
 	defer c.RUnlock()
 
-	res := make([]T, 0, len(c.data))
-	for _, item := range c.data {
-		if item.Expires.IsZero() || item.Expires.After(time.Now()) {
+	return c.values(c.data, c.now())
+}
+
+func (c *Cache[K, T]) values(data Store[K, T], now time.Time) []T {
+	res := make([]T, 0, data.Len())
+	for key, item := range data.Iterate {
+		if item.expired(now) {
+			continue
+		}
+
+		if len(c.getInterceptors) > 0 {
+			res = append(res, c.interceptGet(key, item.Value))
+		} else {
 			res = append(res, item.Value)
 		}
 	}
@@ -167,125 +540,452 @@ func (c *Cache[T]) GetAll() []T {
 	return res
 }
 
-func (c *Cache[T]) Delete(key any) {
-	c.Lock()
-	defer c.Unlock()
+func (c *Cache[K, T]) Delete(key K) {
+	c.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext is Delete carrying a context, e.g. the actor recorded by the audit log
+func (c *Cache[K, T]) DeleteContext(ctx context.Context, key K) {
+	probe := c.probe(opDelete)
+	c.lock()
+	probe.acquired()
 
-	item, exists := c.data[key]
+	var changes ChangeSet[K, T]
+	var walErr error
+
+	item, exists := c.data.Get(key)
 	if exists {
-		delete(c.data, key)
+		data := c.writable()
+		data.Delete(key)
+		c.commit(data)
+
+		walErr = c.appendWAL(walDelete, key, item)
+		c.markDirty(key)
+
+		c.updateMemoryUsage(key, item, false)
+		c.setMetric(metricItems, data.Len())
+		c.addMetric(metricDeletes, 1)
+		c.itemHits.forget(key)
+
+		if c.immediate {
+			changes.Deleted = []Change[K, T]{{Key: key, Value: item.Value}}
+		}
+	}
+
+	overflowErr := c.dropSpilled(key)
+	c.overflowAccess.forget(key)
 
-		c.updateMemoryUsage(item, false)
-		c.Metrics["items"] = len(c.data)
+	c.Unlock()
+	probe.released()
+
+	if walErr != nil {
+		c.reportError(walErr)
+	}
+
+	if overflowErr != nil {
+		c.reportError(overflowErr)
 	}
+
+	if exists {
+		c.audit(ctx, AuditDelete, key)
+		c.invalidate(ctx, false, key)
+	}
+
+	c.dispatch(ctx, changes)
 }
 
-func (c *Cache[T]) DeleteAll() {
-	c.Lock()
-	defer c.Unlock()
+func (c *Cache[K, T]) DeleteAll() {
+	c.deleteAll(context.Background())
+}
+
+// clearData removes every item and what is tracked about them, called with the lock held
+func (c *Cache[K, T]) clearData() error {
+	if c.copyOnWrite {
+		c.commit(make(MapStore[K, T]))
+	} else {
+		c.data.Clear()
+	}
+
+	c.setMetric(metricMemoryBytes, 0)
+	c.setMetric(metricItems, 0)
+
+	if c.namespaceStats != nil {
+		c.namespaceStats.clear()
+	}
+
+	c.itemHits.forget()
+	c.overflowAccess.forget()
+	c.needsFull = true
+
+	if c.overflow == nil {
+		return nil
+	}
+
+	clear(c.spilled)
+
+	return c.overflow.Clear()
+}
 
-	for k := range c.data {
-		delete(c.data, k)
+func (c *Cache[K, T]) deleteAll(ctx context.Context) {
+	c.lock()
+
+	var changes ChangeSet[K, T]
+	if c.immediate {
+		for key, item := range c.data.Iterate {
+			changes.Deleted = append(changes.Deleted, Change[K, T]{Key: key, Value: item.Value})
+		}
+	}
+
+	c.addMetric(metricDeletes, c.data.Len())
+
+	overflowErr := c.clearData()
+
+	var zero K
+	walErr := c.appendWAL(walDeleteAll, zero, Item[T]{})
+
+	c.Unlock()
+
+	if walErr != nil {
+		c.reportError(walErr)
 	}
 
-	c.Metrics["memoryUsageBytes"] = 0
-	c.Metrics["items"] = 0
+	if overflowErr != nil {
+		c.reportError(overflowErr)
+	}
+
+	if c.auditSink != nil {
+		c.writeAudit(AuditRecord{Time: time.Now(), Actor: ActorFromContext(ctx), Op: AuditDeleteAll})
+	}
+
+	c.invalidate(ctx, true)
+
+	c.dispatch(ctx, changes)
 }
 
-func (c *Cache[T]) Maintain() {
+// Open does the startup work of the options once they are all applied: restoring the snapshot of WithPersistence,
+// then replaying the log of WithWAL and starting the warmup of WithWarmup.
+// It runs once, the first Maintain calls it, call it before using a cache running without Maintain.
+func (c *Cache[K, T]) Open() error {
+	c.openOnce.Do(func() { c.openErr = c.startup() })
+
+	return c.openErr
+}
+
+func (c *Cache[K, T]) startup() error {
+	var errs []error
+
+	if c.persistOnStop {
+		if err := c.LoadFrom(c.snapshotTarget); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.walPath != "" {
+		errs = append(errs, c.openWAL())
+	}
+
+	if c.warmupLoad != nil {
+		c.startWarmup()
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Cache[K, T]) Maintain() {
+	// Stop returns once everything below has shut down
+	var stopped chan struct{}
+	defer func() {
+		if stopped != nil {
+			close(stopped)
+		}
+	}()
+
+	defer c.saveOnStop()
+
+	var background sync.WaitGroup
+	defer background.Wait()
+
+	for _, err := range c.startErrs {
+		c.reportError(err)
+	}
+
+	c.startErrs = nil
+
+	// Reported by the Maintain opening the cache, an Open called before returned it already
+	opened := false
+	c.openOnce.Do(func() { c.openErr, opened = c.startup(), true })
+
+	if opened && c.openErr != nil {
+		c.reportError(c.openErr)
+	}
+
 	ticker := time.NewTicker(c.interval)
 	defer ticker.Stop()
 
+	var clockTick <-chan time.Time
+	if c.clockResolution > 0 {
+		clockTicker := time.NewTicker(c.clockResolution)
+		defer clockTicker.Stop()
+
+		c.clock.Store(time.Now().UnixNano())
+		defer c.clock.Store(0)
+
+		clockTick = clockTicker.C
+	}
+
+	ctx, cancel := context.WithCancel(c.parentContext)
+	defer cancel()
+
+	c.lifecycle.Store(&ctx)
+	defer c.lifecycle.Store(nil)
+
+	// The baseline of the rates while fewer than 5 minutes of ticks are sampled
+	c.sampleRates()
+
+	// Counting as a tick, so a Maintain dying before its first one is noticed
+	c.janitor.lastTick.Store(time.Now().UnixNano())
+
+	c.logDebug("simplecache: maintain started", "interval", c.interval)
+
+	if c.autoSaveInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+
+		background.Add(1)
+		go func() {
+			defer background.Done()
+
+			c.runAutoSave(done)
+		}()
+	}
+
+	if c.incrementalInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+
+		background.Add(1)
+		go func() {
+			defer background.Done()
+
+			c.runIncrementalSave(done)
+		}()
+	}
+
+	if c.accurateMemory && c.memoryRecount > 0 {
+		done := make(chan struct{})
+		defer close(done)
+
+		background.Add(1)
+		go func() {
+			defer background.Done()
+
+			c.runMemoryRecount(done)
+		}()
+	}
+
+	for _, r := range c.statsReporters {
+		done := make(chan struct{})
+		defer close(done)
+
+		background.Add(1)
+		go func() {
+			defer background.Done()
+
+			c.runStatsReporter(r, done)
+		}()
+	}
+
+	if c.walCompactInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+
+		background.Add(1)
+		go func() {
+			defer background.Done()
+
+			c.runWALCompaction(done)
+		}()
+	}
+
 	for {
 		select {
-		case <-c.stopChan:
+		case stopped = <-c.stopChan:
+			// Stopped on purpose, no ticks are missed from now on
+			c.janitor.lastTick.Store(0)
+			c.logDebug("simplecache: maintain stopped")
 			return
 
+		case now := <-clockTick:
+			c.clock.Store(now.UnixNano())
+
 		case <-ticker.C:
-			for _, m := range c.beforeTickMiddleware {
-				m()
-			}
+			stats := TickStats{Start: time.Now()}
+			middlewares := c.snapshotMiddlewares()
 
-			c.Lock()
+			for _, m := range middlewares {
+				if m.OnBeforeTick != nil {
+					c.safely(m.OnBeforeTick)
+				}
+			}
 
-			processedDeletions := make(map[any]struct{})
+			c.lock()
 
-			// Remove expired items
-			for key, item := range c.data {
-				if !item.Expires.IsZero() && item.Expires.Before(time.Now()) {
-					c.updates["deleted"] = append(c.updates["deleted"], item.Value)
+			now := time.Now()
+			stats.LockWait = now.Sub(stats.Start)
+			stats.Scanned = c.data.Len()
 
-					for _, m := range c.expiryMiddlewares {
-						m(key.(string), item)
-					}
+			processedDeletions := make(map[K]struct{})
 
-					delete(c.data, key)
-					c.updateMemoryUsage(item, false)
-					c.Metrics["items"] = len(c.data)
+			// Remove expired items, collected first as stores needn't support deleting while iterating
+			var expired []Change[K, T]
+			var expiryJobs []func()
+			expire := func(key K, item Item[T]) {
+				expired = append(expired, Change[K, T]{Key: key, Value: item.Value})
 
-					processedDeletions[key] = struct{}{}
+				for _, m := range middlewares {
+					if m.OnExpiry != nil && c.matches(m, key, item.Value) {
+						expiryJobs = append(expiryJobs, func() { m.OnExpiry(key, item) })
+					}
 				}
+
+				c.itemHits.forget(key)
+				c.overflowAccess.forget(key)
+				c.markDirty(key)
 			}
 
-			// Check for created or updated records
-			for key, item := range c.data {
-				prevItem, exists := c.prev[key]
-				if !exists {
-					c.updates["created"] = append(c.updates["created"], item.Value)
-				} else if !c.compareFunc(item.Value, prevItem.Value) {
-					c.updates["updated"] = append(c.updates["updated"], item.Value)
-				}
+			for key, item := range c.expiredItems(now) {
+				expire(key, item)
+				c.updateMemoryUsage(key, item, false)
+
+				processedDeletions[key] = struct{}{}
 			}
 
-			// Check for deleted records excluding already processed
-			for key, prevValue := range c.prev {
-				if _, exists := c.data[key]; !exists {
-					_, processed := processedDeletions[key]
+			spilledErr := c.expireSpilled(now, expire)
 
-					if !processed {
-						c.updates["deleted"] = append(c.updates["deleted"], prevValue.Value)
-					}
+			if len(expired) > 0 {
+				data := c.writable()
+				for _, change := range expired {
+					data.Delete(change.Key)
 				}
+
+				c.commit(data)
+				c.setMetric(metricItems, data.Len())
+				c.changes.Expired = append(c.changes.Expired, expired...)
 			}
 
-			c.prev = make(map[any]Item[T], len(c.data))
-			for key, item := range c.data {
-				c.prev[key] = item
+			overflowErr := errors.Join(spilledErr, c.spill(processedDeletions))
+
+			// Immediate mode already reported writes as they happened
+			if !c.immediate {
+				c.detectChanges(processedDeletions)
 			}
 
 			c.Unlock()
 
-			// Call middlewares for created, updated, and deleted records
-			if len(c.updates["created"]) > 0 {
-				for _, m := range c.createMiddlewares {
-					m(c.updates["created"])
+			// Outside the lock, a slow or reentrant OnExpiry doesn't hold up or deadlock the cache
+			c.fanOut(EventExpired, expiryJobs)
+
+			if overflowErr != nil {
+				c.reportError(overflowErr)
+			}
+
+			stats.LockHeld = time.Since(now)
+			stats.Expired = len(c.changes.Expired)
+			c.addMetric(metricExpirations, stats.Expired)
+			stats.Created = len(c.changes.Created)
+			stats.Updated = len(c.changes.Updated)
+			stats.Deleted = len(c.changes.Deleted)
+
+			for _, change := range c.changes.Expired {
+				c.audit(c.parentContext, AuditExpire, change.Key)
+			}
+
+			if len(c.changes.Expired) > 0 && c.invalidationBus.Load() != nil {
+				keys := make([]K, len(c.changes.Expired))
+				for i, change := range c.changes.Expired {
+					keys[i] = change.Key
 				}
+
+				c.invalidate(c.parentContext, false, keys...)
 			}
 
-			if len(c.updates["updated"]) > 0 {
-				for _, m := range c.updateMiddlewares {
-					m(c.updates["updated"])
+			c.dispatch(context.Background(), c.changes)
+
+			// Clear changes for the new tick, releasing buffers grown past the retention cap
+			c.changes.Created = truncate(c.changes.Created, c.updatesRetention)
+			c.changes.Updated = truncate(c.changes.Updated, c.updatesRetention)
+			c.changes.Deleted = truncate(c.changes.Deleted, c.updatesRetention)
+			c.changes.Expired = truncate(c.changes.Expired, c.updatesRetention)
+
+			c.setMetric(metricCreatedBufferCap, cap(c.changes.Created))
+			c.setMetric(metricUpdatedBufferCap, cap(c.changes.Updated))
+			c.setMetric(metricDeletedBufferCap, cap(c.changes.Deleted))
+			c.setMetric(metricExpiredBufferCap, cap(c.changes.Expired))
+
+			for _, m := range middlewares {
+				if m.OnAfterTick != nil {
+					c.safely(m.OnAfterTick)
 				}
 			}
 
-			if len(c.updates["deleted"]) > 0 {
-				for _, m := range c.deleteMiddlewares {
-					m(c.updates["deleted"])
+			stats.Duration = time.Since(stats.Start)
+
+			if c.telemetry != nil {
+				c.telemetry.Observe("tick", stats.Duration)
+			}
+
+			c.sampleRates()
+			c.janitor.record(stats)
+			c.logTick(stats)
+
+			for _, m := range middlewares {
+				if m.OnTickStats != nil {
+					c.safely(func() { m.OnTickStats(stats) })
 				}
 			}
+		}
+	}
+}
+
+func (c *Cache[K, T]) detectChanges(processedDeletions map[K]struct{}) {
+	// Check for created or updated records
+	for key, item := range c.data.Iterate {
+		prevItem, exists := c.prev[key]
+		if !exists {
+			c.changes.Created = append(c.changes.Created, Change[K, T]{Key: key, Value: item.Value})
+		} else if !c.equal(item.Value, prevItem.Value) {
+			c.changes.Updated = append(c.changes.Updated, Change[K, T]{Key: key, Value: item.Value, Previous: prevItem.Value})
+		}
+	}
 
-			// Clear updates for the new tick
-			c.updates["created"] = c.updates["created"][:0]
-			c.updates["updated"] = c.updates["updated"][:0]
-			c.updates["deleted"] = c.updates["deleted"][:0]
+	// Check for deleted records excluding already processed
+	for key, prevValue := range c.prev {
+		if _, exists := c.data.Get(key); !exists {
+			_, processed := processedDeletions[key]
 
-			for _, m := range c.afterTickMiddleware {
-				m()
+			if !processed {
+				c.changes.Deleted = append(c.changes.Deleted, Change[K, T]{Key: key, Value: prevValue.Value})
 			}
 		}
 	}
+
+	c.prev = make(map[K]Item[T], c.data.Len())
+	for key, item := range c.data.Iterate {
+		c.prev[key] = item
+	}
+}
+
+// Close releases what outlives Maintain once writes have stopped: queued events are delivered and the async
+// dispatcher stopped, the invalidation bus and the WAL closed. Call Stop first when Maintain runs.
+func (c *Cache[K, T]) Close() error {
+	c.stopDispatcher()
+	c.CloseInvalidationBus()
+
+	return c.CloseWAL()
 }
 
-func (c *Cache[T]) Stop() {
-	c.stopChan <- struct{}{}
+func (c *Cache[K, T]) Stop() {
+	stopped := make(chan struct{})
+	c.stopChan <- stopped
+	<-stopped
 }
@@ -1,6 +1,7 @@
 package simplecache_test
 
 import (
+	"fmt"
 	cache "github.com/kamludwinski2/simplecache"
 	"testing"
 	"time"
@@ -15,7 +16,7 @@ type TestStruct struct {
 }
 
 func TestSetAndGet(t *testing.T) {
-	c := cache.New[TestStruct]()
+	c := cache.New[string, TestStruct]()
 	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
 	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
 
@@ -26,12 +27,12 @@ func TestSetAndGet(t *testing.T) {
 	_, exists = c.Get("nonexistent")
 	assert.False(t, exists)
 
-	assert.Equal(t, 1, c.Metrics["hits"])
-	assert.Equal(t, 1, c.Metrics["misses"])
+	assert.Equal(t, int64(1), c.Stats().Hits)
+	assert.Equal(t, int64(1), c.Stats().Misses)
 }
 
 func TestExpiration(t *testing.T) {
-	c := cache.New[TestStruct]().WithInterval(500 * time.Millisecond)
+	c := cache.New[string, TestStruct]().WithInterval(500 * time.Millisecond)
 	expiredItems := make([]string, 0)
 
 	go c.Maintain()
@@ -56,7 +57,7 @@ func TestCreateUpdateDeleteMiddlewares(t *testing.T) {
 	updatedItems := make([]TestStruct, 0)
 	deletedItems := make([]TestStruct, 0)
 
-	c := cache.New[TestStruct]().WithInterval(500 * time.Millisecond).
+	c := cache.New[string, TestStruct]().WithInterval(500 * time.Millisecond).
 		Equals(func(a, b TestStruct) bool {
 			return a.Name == b.Name && a.Age == b.Age
 		}).
@@ -89,23 +90,116 @@ func TestCreateUpdateDeleteMiddlewares(t *testing.T) {
 }
 
 func TestMetrics(t *testing.T) {
-	c := cache.New[TestStruct]()
+	c := cache.New[string, TestStruct]()
 	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
 	c.Get("item1")
 	c.Get("nonexistent")
 
-	assert.Equal(t, 1, c.Metrics["hits"])
-	assert.Equal(t, 1, c.Metrics["misses"])
-	assert.Equal(t, 1, c.Metrics["items"])
+	assert.Equal(t, int64(1), c.Stats().Hits)
+	assert.Equal(t, int64(1), c.Stats().Misses)
+	assert.Equal(t, int64(1), c.Stats().Items)
 }
 
 func TestMemoryUsage(t *testing.T) {
-	c := cache.New[TestStruct]()
-	sizeOfItem := int(unsafe.Sizeof(cache.Item[TestStruct]{})) + int(unsafe.Sizeof(TestStruct{})) + int(unsafe.Sizeof(time.Time{}))
+	c := cache.New[string, TestStruct]()
+	sizeOfItem := int64(unsafe.Sizeof(cache.Item[TestStruct]{}) + unsafe.Sizeof(TestStruct{}) + unsafe.Sizeof(time.Time{}))
 
 	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
-	assert.GreaterOrEqual(t, c.Metrics["memoryUsageBytes"], sizeOfItem)
+	assert.GreaterOrEqual(t, c.Stats().MemoryBytes, sizeOfItem)
 
 	c.Delete("item1")
-	assert.Equal(t, 0, c.Metrics["memoryUsageBytes"])
+	assert.Equal(t, int64(0), c.Stats().MemoryBytes)
+}
+
+func TestUpdatesRetention(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithInterval(100 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		WithUpdatesRetention(2)
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	time.Sleep(250 * time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		c.Set(fmt.Sprintf("bulk%d", i), TestStruct{Age: i})
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	c.Stop()
+
+	assert.Equal(t, int64(0), c.Stats().CreatedBufferCap)
+	assert.Equal(t, int64(0), c.Stats().DeletedBufferCap)
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithCopyOnWrite()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	val, exists := c.Get("item1")
+	assert.True(t, exists)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, val)
+	assert.Len(t, c.GetAll(), 2)
+
+	c.Delete("item1")
+	_, exists = c.Get("item1")
+	assert.False(t, exists)
+	assert.Equal(t, int64(1), c.Stats().Items)
+
+	c.DeleteAll()
+	assert.Empty(t, c.GetAll())
+	assert.Equal(t, int64(0), c.Stats().Items)
+}
+
+func TestCoarseClock(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithInterval(time.Hour).WithCoarseClock(time.Hour)
+
+	go c.Maintain()
+	time.Sleep(100 * time.Millisecond)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30}, time.Now().Add(100*time.Millisecond))
+	time.Sleep(200 * time.Millisecond)
+
+	// Clock has not advanced yet, so the item is still visible
+	_, exists := c.Get("item1")
+	assert.True(t, exists)
+
+	c.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	// Without Maintain running reads fall back to time.Now()
+	_, exists = c.Get("item1")
+	assert.False(t, exists)
+}
+
+func TestGetAllocations(t *testing.T) {
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		c.Get("item1")
+		c.Get("nonexistent")
+	})
+	assert.Zero(t, allocs)
+
+	ints := cache.New[int, int]().WithCopyOnWrite()
+	ints.Set(1, 100)
+
+	allocs = testing.AllocsPerRun(1000, func() {
+		ints.Get(1)
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkGet(b *testing.B) {
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.Get("item1")
+	}
 }
@@ -0,0 +1,297 @@
+package simplecache
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultMemcachedPrefix = "simplecache:"
+
+// memcachedBatch is the number of keys fetched by one get while iterating
+const memcachedBatch = 100
+
+// MemcachedStore is a Store keeping the items in memcached, speaking its text protocol.
+// Memcached can't list its keys, so Iterate, Len and Clear only cover the keys written through this store.
+// Items evicted by memcached to make room are gone from the cache without being reported as deleted.
+type MemcachedStore[K comparable, T any] struct {
+	Addr string
+
+	// Prefix is put in front of every key, defaults to "simplecache:"
+	Prefix string
+
+	// Codec defaults to GobCodec
+	Codec Codec[T]
+
+	// OnError receives failed commands, Store methods can't return them. A failed Get is a miss.
+	OnError func(error)
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+	keys map[string]struct{}
+}
+
+func (s *MemcachedStore[K, T]) Get(key K) (Item[T], bool) {
+	values, err := s.get(s.key(key))
+	if err != nil {
+		s.report(err)
+		return Item[T]{}, false
+	}
+
+	data, ok := values[s.key(key)]
+	if !ok {
+		return Item[T]{}, false
+	}
+
+	_, item, err := decodeItem[K](s.codec(), data)
+	if err != nil {
+		s.report(err)
+		return Item[T]{}, false
+	}
+
+	return item, true
+}
+
+func (s *MemcachedStore[K, T]) Set(key K, item Item[T]) {
+	data, err := encodeItem(s.codec(), key, item)
+	if err == nil {
+		err = s.set(s.key(key), data)
+	}
+
+	s.report(err)
+}
+
+func (s *MemcachedStore[K, T]) Delete(key K) {
+	s.report(s.delete(s.key(key)))
+}
+
+// Iterate fetches the known keys in batches, the ones memcached evicted are skipped
+func (s *MemcachedStore[K, T]) Iterate(yield func(K, Item[T]) bool) {
+	keys := s.known()
+
+	for len(keys) > 0 {
+		batch := keys[:min(memcachedBatch, len(keys))]
+		keys = keys[len(batch):]
+
+		values, err := s.get(batch...)
+		if err != nil {
+			s.report(err)
+			return
+		}
+
+		for _, data := range values {
+			key, item, err := decodeItem[K](s.codec(), data)
+			if err != nil {
+				s.report(err)
+				continue
+			}
+
+			if !yield(key, item) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of known keys, including ones memcached may have evicted since
+func (s *MemcachedStore[K, T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.keys)
+}
+
+func (s *MemcachedStore[K, T]) Clear() {
+	for _, key := range s.known() {
+		if err := s.delete(key); err != nil {
+			s.report(err)
+			return
+		}
+	}
+}
+
+// Close closes the connection, the next command opens a new one
+func (s *MemcachedStore[K, T]) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+func (s *MemcachedStore[K, T]) known() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (s *MemcachedStore[K, T]) get(keys ...string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+
+	err := s.do("get "+strings.Join(keys, " "), nil, func(r *bufio.Reader) error {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return err
+			}
+
+			line = strings.TrimSuffix(line, "\r\n")
+			if line == "END" {
+				return nil
+			}
+
+			// VALUE <key> <flags> <bytes>
+			fields := strings.Fields(line)
+			if len(fields) < 4 || fields[0] != "VALUE" {
+				return fmt.Errorf("unexpected reply %q", line)
+			}
+
+			size, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return err
+			}
+
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return err
+			}
+
+			values[fields[1]] = data[:size]
+		}
+	})
+
+	return values, err
+}
+
+func (s *MemcachedStore[K, T]) set(key string, data []byte) error {
+	err := s.do(fmt.Sprintf("set %s 0 0 %d", key, len(data)), data, expectReply("STORED"))
+	if err == nil {
+		s.mu.Lock()
+		if s.keys == nil {
+			s.keys = make(map[string]struct{})
+		}
+
+		s.keys[key] = struct{}{}
+		s.mu.Unlock()
+	}
+
+	return err
+}
+
+func (s *MemcachedStore[K, T]) delete(key string) error {
+	err := s.do("delete "+key, nil, expectReply("DELETED", "NOT_FOUND"))
+	if err == nil {
+		s.mu.Lock()
+		delete(s.keys, key)
+		s.mu.Unlock()
+	}
+
+	return err
+}
+
+func expectReply(expected ...string) func(r *bufio.Reader) error {
+	return func(r *bufio.Reader) error {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSuffix(line, "\r\n")
+		for _, e := range expected {
+			if line == e {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("unexpected reply %q", line)
+	}
+}
+
+// do sends a command line, and data as a block when not nil, then reads the reply with read.
+// A broken connection is dropped and redialed by the next command.
+func (s *MemcachedStore[K, T]) do(command string, data []byte, read func(r *bufio.Reader) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, _, _ := strings.Cut(command, " ")
+
+	if s.conn == nil {
+		conn, err := net.Dial("tcp", s.Addr)
+		if err != nil {
+			return fmt.Errorf("simplecache: memcached: %w", err)
+		}
+
+		s.conn, s.r, s.w = conn, bufio.NewReader(conn), bufio.NewWriter(conn)
+	}
+
+	s.w.WriteString(command + "\r\n")
+	if data != nil {
+		s.w.Write(data)
+		s.w.WriteString("\r\n")
+	}
+
+	err := s.w.Flush()
+	if err == nil {
+		err = read(s.r)
+	}
+
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+
+		return fmt.Errorf("simplecache: memcached %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// key maps a cache key to a valid memcached key, hashing ones that are too long or contain spaces or control characters
+func (s *MemcachedStore[K, T]) key(key K) string {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = defaultMemcachedPrefix
+	}
+
+	k := prefix + keyString(key)
+	if len(k) <= 250 && strings.IndexFunc(k, func(r rune) bool { return r <= ' ' || r == 0x7f }) < 0 {
+		return k
+	}
+
+	sum := sha256.Sum256([]byte(keyString(key)))
+
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+func (s *MemcachedStore[K, T]) codec() Codec[T] {
+	if s.Codec == nil {
+		return GobCodec[T]{}
+	}
+
+	return s.Codec
+}
+
+func (s *MemcachedStore[K, T]) report(err error) {
+	if err != nil && s.OnError != nil {
+		s.OnError(err)
+	}
+}
@@ -0,0 +1,108 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// startFakeMemcached serves get, set and delete of the text protocol
+func startFakeMemcached(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	items := make(map[string][]byte)
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			fields := strings.Fields(line)
+
+			mu.Lock()
+			switch fields[0] {
+			case "get":
+				for _, key := range fields[1:] {
+					if data, ok := items[key]; ok {
+						fmt.Fprintf(conn, "VALUE %s 0 %d\r\n%s\r\n", key, len(data), data)
+					}
+				}
+
+				io.WriteString(conn, "END\r\n")
+
+			case "set":
+				size, _ := strconv.Atoi(fields[4])
+				data := make([]byte, size+2)
+				io.ReadFull(r, data)
+				items[fields[1]] = data[:size]
+
+				io.WriteString(conn, "STORED\r\n")
+
+			case "delete":
+				if _, ok := items[fields[1]]; ok {
+					delete(items, fields[1])
+					io.WriteString(conn, "DELETED\r\n")
+				} else {
+					io.WriteString(conn, "NOT_FOUND\r\n")
+				}
+			}
+			mu.Unlock()
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestMemcachedStore(t *testing.T) {
+	addr := startFakeMemcached(t)
+
+	var errs []error
+	store := &cache.MemcachedStore[string, TestStruct]{Addr: addr, OnError: func(err error) { errs = append(errs, err) }}
+
+	c := cache.New[string, TestStruct]().WithStore(store)
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item 2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40})
+	c.Delete("item3")
+
+	item2, ok := cache.New[string, TestStruct]().WithStore(&cache.MemcachedStore[string, TestStruct]{Addr: addr}).Get("item 2")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Bob", Age: 25}, item2)
+
+	assert.ElementsMatch(t, []TestStruct{{Name: "Alice", Age: 30}, {Name: "Bob", Age: 25}}, c.GetAll())
+	assert.Equal(t, 2, store.Len())
+
+	c.DeleteAll()
+	_, ok = c.Get("item1")
+	assert.False(t, ok)
+	assert.Equal(t, 0, store.Len())
+
+	assert.Empty(t, errs)
+}
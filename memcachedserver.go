@@ -0,0 +1,258 @@
+package simplecache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits of memcached's defaults, the largest item (-I) and the longest command line
+const (
+	memcachedMaxItem = 1 << 20
+	memcachedMaxLine = 8 << 10
+)
+
+// memcachedMaxRelative is the longest exptime memcached treats as relative, larger ones are Unix timestamps
+const memcachedMaxRelative = 30 * 24 * 60 * 60
+
+// ListenMemcached serves get, gets, set, delete and touch over the memcached text protocol on addr, for pointing existing clients at the cache.
+// Values are encoded by the codec set with WithCodec (StringCodec for plain strings), keys need to be strings and flags are not kept.
+// Close the returned server to stop serving.
+func (c *Cache[K, T]) ListenMemcached(addr string) (*Server, error) {
+	listener, err := c.listen(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return serve(listener, c.serveMemcached), nil
+}
+
+func (c *Cache[K, T]) serveMemcached(conn net.Conn) {
+	creds, err := connCredentials(conn)
+	if err != nil {
+		return
+	}
+
+	// The text protocol has no auth command, only a client certificate can pass
+	if c.serverAuth != nil && c.serverAuth(creds) != nil {
+		io.WriteString(conn, "SERVER_ERROR authentication required\r\n")
+		return
+	}
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	for {
+		line, err := readLine(r, memcachedMaxLine)
+		if errors.Is(err, errLineTooLong) {
+			io.WriteString(conn, "CLIENT_ERROR line too long\r\n")
+			return
+		}
+
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "quit" {
+			return
+		}
+
+		reply, noreply, skip := c.execMemcached(fields, r, w)
+		if reply == "" {
+			return
+		}
+
+		if !noreply {
+			w.WriteString(reply)
+		}
+
+		if w.Flush() != nil {
+			return
+		}
+
+		if skip > 0 {
+			if _, err := io.CopyN(io.Discard, r, skip); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// execMemcached runs a command, returning its reply, whether the client asked for none and how many bytes to skip
+// after replying, an empty reply closes the connection. Values retrieved are written to w ahead of the reply.
+func (c *Cache[K, T]) execMemcached(fields []string, r *bufio.Reader, w *bufio.Writer) (reply string, noreply bool, skip int64) {
+	name, args := fields[0], fields[1:]
+
+	if n := len(args); n > 0 && args[n-1] == "noreply" {
+		args, noreply = args[:n-1], true
+	}
+
+	switch name {
+	case "version":
+		return "VERSION simplecache\r\n", false, 0
+
+	case "get", "gets":
+		if len(args) == 0 {
+			return "ERROR\r\n", false, 0
+		}
+
+		// Each value goes out as it's encoded, a multi-key get is never held whole
+		for _, arg := range args {
+			key, ok := any(arg).(K)
+			if !ok {
+				return "CLIENT_ERROR keys must be strings\r\n", false, 0
+			}
+
+			value, ok := c.Get(key)
+			if !ok {
+				continue
+			}
+
+			data, err := c.valueCodec().Encode(value)
+			if err != nil {
+				return "SERVER_ERROR " + err.Error() + "\r\n", false, 0
+			}
+
+			// Without CAS support gets reports 0 as the unique value
+			if name == "gets" {
+				_, err = fmt.Fprintf(w, "VALUE %s 0 %d 0\r\n%s\r\n", arg, len(data), data)
+			} else {
+				_, err = fmt.Fprintf(w, "VALUE %s 0 %d\r\n%s\r\n", arg, len(data), data)
+			}
+
+			if err != nil {
+				return "", false, 0
+			}
+		}
+
+		return "END\r\n", false, 0
+
+	case "set":
+		// set <key> <flags> <exptime> <bytes>
+		if len(args) != 4 {
+			return "ERROR\r\n", false, 0
+		}
+
+		exptime, err1 := strconv.ParseInt(args[2], 10, 64)
+		size, err2 := strconv.ParseInt(args[3], 10, 64)
+		if err1 != nil || err2 != nil || size < 0 {
+			return "CLIENT_ERROR bad command line format\r\n", false, 0
+		}
+
+		// Like memcached, the payload is swallowed after replying so the connection stays usable
+		if size > memcachedMaxItem {
+			return "SERVER_ERROR object too large for cache\r\n", noreply, min(size, math.MaxInt64-2) + 2
+		}
+
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return "", false, 0
+		}
+
+		if string(data[size:]) != "\r\n" {
+			return "CLIENT_ERROR bad data chunk\r\n", noreply, 0
+		}
+
+		key, ok := any(args[0]).(K)
+		if !ok {
+			return "CLIENT_ERROR keys must be strings\r\n", noreply, 0
+		}
+
+		value, err := c.valueCodec().Decode(data[:size])
+		if err != nil {
+			return "CLIENT_ERROR " + err.Error() + "\r\n", noreply, 0
+		}
+
+		expires, expired := memcachedExpiry(exptime)
+		if expired {
+			c.Delete(key)
+			return "STORED\r\n", noreply, 0
+		}
+
+		if err := c.SetE(key, value, expires); err != nil {
+			return "SERVER_ERROR " + err.Error() + "\r\n", noreply, 0
+		}
+
+		return "STORED\r\n", noreply, 0
+
+	case "delete":
+		if len(args) != 1 {
+			return "ERROR\r\n", false, 0
+		}
+
+		key, ok := any(args[0]).(K)
+		if !ok {
+			return "CLIENT_ERROR keys must be strings\r\n", noreply, 0
+		}
+
+		if _, exists := c.lookup(key, time.Now()); !exists {
+			return "NOT_FOUND\r\n", noreply, 0
+		}
+
+		c.Delete(key)
+
+		return "DELETED\r\n", noreply, 0
+
+	case "touch":
+		// touch <key> <exptime>
+		if len(args) != 2 {
+			return "ERROR\r\n", false, 0
+		}
+
+		exptime, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return "CLIENT_ERROR bad command line format\r\n", noreply, 0
+		}
+
+		key, ok := any(args[0]).(K)
+		if !ok {
+			return "CLIENT_ERROR keys must be strings\r\n", noreply, 0
+		}
+
+		item, exists := c.lookup(key, time.Now())
+		if !exists {
+			return "NOT_FOUND\r\n", noreply, 0
+		}
+
+		expires, expired := memcachedExpiry(exptime)
+		if expired {
+			c.Delete(key)
+			return "TOUCHED\r\n", noreply, 0
+		}
+
+		if err := c.SetE(key, item.Value, expires); err != nil {
+			return "SERVER_ERROR " + err.Error() + "\r\n", noreply, 0
+		}
+
+		return "TOUCHED\r\n", noreply, 0
+	}
+
+	return "ERROR\r\n", false, 0
+}
+
+// memcachedExpiry converts an exptime, 0 never expires, up to 30 days is relative, past that a Unix timestamp and negative is already expired
+func memcachedExpiry(exptime int64) (expires time.Time, expired bool) {
+	switch {
+	case exptime == 0:
+		return time.Time{}, false
+	case exptime < 0:
+		return time.Time{}, true
+	case exptime <= memcachedMaxRelative:
+		return time.Now().Add(time.Duration(exptime) * time.Second), false
+	}
+
+	expires = time.Unix(exptime, 0)
+
+	return expires, !expires.After(time.Now())
+}
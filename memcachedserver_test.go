@@ -0,0 +1,97 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenMemcached(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{})
+	c.Set("item1", "Alice")
+
+	server, err := c.ListenMemcached("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	call := func(command string, lines int) string {
+		fmt.Fprint(conn, command)
+
+		reply := ""
+		for range lines {
+			reply += mustReadLine(t, r)
+		}
+
+		return reply
+	}
+
+	assert.Equal(t, "VALUE item1 0 5\r\nAlice\r\nEND\r\n", call("get item1 item2\r\n", 3))
+	assert.Equal(t, "STORED\r\n", call("set item2 0 100 3\r\nBob\r\n", 1))
+	assert.Equal(t, "VALUE item1 0 5 0\r\nAlice\r\nVALUE item2 0 3 0\r\nBob\r\nEND\r\n", call("gets item1 item2\r\n", 5))
+
+	assert.Equal(t, "TOUCHED\r\n", call("touch item1 100\r\n", 1))
+	assert.Equal(t, "NOT_FOUND\r\n", call("touch item3 100\r\n", 1))
+
+	assert.Equal(t, "DELETED\r\n", call("delete item1\r\n", 1))
+	assert.Equal(t, "NOT_FOUND\r\n", call("delete item1\r\n", 1))
+
+	// noreply commands are answered by nothing, the next reply belongs to version
+	assert.Equal(t, "VERSION simplecache\r\n", call("set item3 0 0 5 noreply\r\nCarol\r\nversion\r\n", 1))
+	assert.Equal(t, "ERROR\r\n", call("incr item3 1\r\n", 1))
+
+	carol, ok := c.Get("item3")
+	assert.True(t, ok)
+	assert.Equal(t, "Carol", carol)
+
+	_, ok = c.Get("item1")
+	assert.False(t, ok)
+
+	// Values larger than the write buffer arrive whole and in order
+	large := strings.Repeat("x", 64<<10)
+	c.Set("large1", large)
+	c.Set("large2", large)
+
+	assert.Equal(t, "VALUE large1 0 65536\r\n"+large+"\r\nVALUE large2 0 65536\r\n"+large+"\r\nEND\r\n", call("get large1 large2\r\n", 5))
+}
+
+func TestListenMemcachedLimits(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{})
+
+	server, err := c.ListenMemcached("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	// The oversized payload is skipped and the connection keeps working
+	fmt.Fprintf(conn, "set item1 0 0 %d\r\n%s\r\n", 2<<20, strings.Repeat("x", 2<<20))
+	assert.Equal(t, "SERVER_ERROR object too large for cache\r\n", mustReadLine(t, r))
+
+	fmt.Fprint(conn, "set item1 0 0 5\r\nAlice\r\n")
+	assert.Equal(t, "STORED\r\n", mustReadLine(t, r))
+
+	// A huge announced size doesn't allocate
+	fmt.Fprint(conn, "set item2 0 0 9223372036854775807\r\n")
+	assert.Equal(t, "SERVER_ERROR object too large for cache\r\n", mustReadLine(t, r))
+
+	long, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer long.Close()
+
+	fmt.Fprint(long, "get "+strings.Repeat("k", 16<<10)+"\r\n")
+	assert.Equal(t, "CLIENT_ERROR line too long\r\n", mustReadLine(t, bufio.NewReader(long)))
+}
@@ -0,0 +1,63 @@
+package simplecache
+
+import (
+	"time"
+	"unsafe"
+)
+
+// WithAccurateMemory measures memoryUsageBytes with the sizer (DeepSize by default) instead of the fixed size of an item,
+// keys included. Values changed in place make the number drift, so while Maintain runs all items are measured again
+// every recount interval (none when 0) and the correction is reported as MemoryDrift.
+func (c *Cache[K, T]) WithAccurateMemory(recount time.Duration) *Cache[K, T] {
+	c.accurateMemory = true
+	c.memoryRecount = recount
+
+	return c
+}
+
+// memorySize is what an entry adds to memoryUsageBytes
+func (c *Cache[K, T]) memorySize(key K, item Item[T]) int {
+	if !c.accurateMemory {
+		return int(unsafe.Sizeof(item)) + int(unsafe.Sizeof(item.Value)) + int(unsafe.Sizeof(item.Expires))
+	}
+
+	return DeepSize(key) + c.itemSize(item)
+}
+
+func (c *Cache[K, T]) runMemoryRecount(done <-chan struct{}) {
+	ticker := time.NewTicker(c.memoryRecount)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.recountMemory()
+
+		case <-done:
+			return
+		}
+	}
+}
+
+// recountMemory measures every item again, writes made meanwhile may be off until the next recount
+func (c *Cache[K, T]) recountMemory() {
+	total := 0
+	namespaces := make(map[string]int64)
+
+	for _, e := range c.entries() {
+		size := c.memorySize(e.Key, Item[T]{Value: e.Value, Expires: e.Expires})
+
+		total += size
+		if c.namespaceStats != nil {
+			namespaces[c.namespaceOf(e.Key)] += int64(size)
+		}
+	}
+
+	drift := total - int(c.metrics[metricMemoryBytes].Load())
+	c.setMetric(metricMemoryBytes, total)
+	c.setMetric(metricMemoryDrift, drift)
+
+	if c.namespaceStats != nil {
+		c.namespaceStats.setMemory(namespaces)
+	}
+}
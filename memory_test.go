@@ -0,0 +1,33 @@
+package simplecache_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccurateMemory(t *testing.T) {
+	c := cache.New[string, map[string]string]().WithAccurateMemory(20 * time.Millisecond).WithInterval(time.Minute)
+
+	value := map[string]string{"name": strings.Repeat("a", 1000)}
+	c.Set("item1", value)
+
+	size := c.Stats().MemoryBytes
+	assert.Greater(t, size, int64(1000))
+
+	c.Delete("item1")
+	assert.Zero(t, c.Stats().MemoryBytes)
+
+	// Changed in place, found by the recount
+	c.Set("item1", value)
+	value["bio"] = strings.Repeat("a", 5000)
+
+	go c.Maintain()
+	defer c.Stop()
+
+	assert.Eventually(t, func() bool { return c.Stats().MemoryDrift > 5000 }, time.Second, 10*time.Millisecond)
+	assert.Greater(t, c.Stats().MemoryBytes, size+5000)
+}
@@ -0,0 +1,164 @@
+package simplecache
+
+import (
+	"runtime/debug"
+	"slices"
+	"sync"
+)
+
+type ErrorMiddleware func(error)
+type AccessMiddleware[K comparable] func(K)
+
+// Middlewares groups callbacks registered together through Subscribe, unset fields are ignored
+type Middlewares[K comparable, T any] struct {
+	OnCreate        Middleware[T]
+	OnUpdate        Middleware[T]
+	OnDelete        Middleware[T]
+	OnExpiry        ExpiryMiddleware[K, T]
+	OnCreateKeyed   KeyedMiddleware[K, T]
+	OnUpdateKeyed   KeyedMiddleware[K, T]
+	OnDeleteKeyed   KeyedMiddleware[K, T]
+	OnChanges       ChangesMiddleware[K, T]
+	OnCreateContext ContextMiddleware[K, T]
+	OnUpdateContext ContextMiddleware[K, T]
+	OnDeleteContext ContextMiddleware[K, T]
+	OnBeforeTick    TickMiddleware
+	OnAfterTick     TickMiddleware
+	OnTickStats     TickStatsMiddleware
+	OnError         ErrorMiddleware
+	OnHit           AccessMiddleware[K]
+	OnMiss          AccessMiddleware[K]
+
+	// Filter limits the change middlewares above to matching keys and values, tick middlewares are not filtered
+	Filter func(key K, value T) bool
+
+	// Namespace limits the change middlewares above to keys within a namespace, see WithNamespaceSeparator
+	Namespace string
+
+	// Priority orders middlewares, higher runs first, equal priorities run in registration order
+	Priority int
+}
+
+// Subscribe registers middlewares and returns a func removing them again
+func (c *Cache[K, T]) Subscribe(m Middlewares[K, T]) (unsubscribe func()) {
+	registered := &m
+
+	c.middlewaresMu.Lock()
+
+	i, _ := slices.BinarySearchFunc(c.middlewares, m.Priority, func(other *Middlewares[K, T], priority int) int {
+		// Treat equal priorities as higher so the new entry lands after them
+		if other.Priority >= priority {
+			return -1
+		}
+
+		return 1
+	})
+	c.middlewares = slices.Insert(slices.Clip(c.middlewares), i, registered)
+
+	if m.OnHit != nil || m.OnMiss != nil {
+		c.accessMiddlewares.Add(1)
+	}
+
+	c.middlewaresMu.Unlock()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			c.middlewaresMu.Lock()
+			defer c.middlewaresMu.Unlock()
+
+			c.middlewares = slices.DeleteFunc(slices.Clone(c.middlewares), func(other *Middlewares[K, T]) bool {
+				return other == registered
+			})
+
+			if m.OnHit != nil || m.OnMiss != nil {
+				c.accessMiddlewares.Add(-1)
+			}
+		})
+	}
+}
+
+func (c *Cache[K, T]) OnHit(m AccessMiddleware[K]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnHit: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) OnMiss(m AccessMiddleware[K]) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnMiss: m})
+
+	return c
+}
+
+func (c *Cache[K, T]) notifyAccess(key K, hit bool) {
+	for _, m := range c.snapshotMiddlewares() {
+		fn := m.OnMiss
+		if hit {
+			fn = m.OnHit
+		}
+
+		if fn != nil {
+			c.safely(func() { fn(key) })
+		}
+	}
+}
+
+// filterFor returns the combined namespace and Filter check of m, nil when m accepts everything
+func (c *Cache[K, T]) filterFor(m *Middlewares[K, T]) func(K, T) bool {
+	if m.Filter == nil && m.Namespace == "" {
+		return nil
+	}
+
+	return func(key K, value T) bool {
+		return c.matches(m, key, value)
+	}
+}
+
+func (c *Cache[K, T]) matches(m *Middlewares[K, T], key K, value T) bool {
+	if m.Namespace != "" && c.namespaceOf(key) != m.Namespace {
+		return false
+	}
+
+	return m.Filter == nil || m.Filter(key, value)
+}
+
+func (c *Cache[K, T]) OnError(m ErrorMiddleware) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnError: m})
+
+	return c
+}
+
+// safely runs a user callback, a panic is recovered and reported instead of killing the caller
+func (c *Cache[K, T]) safely(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.addMetric(metricMiddlewarePanics, 1)
+			c.reportError(&PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+
+	fn()
+}
+
+func (c *Cache[K, T]) reportError(err error) {
+	c.logError(err)
+
+	for _, m := range c.snapshotMiddlewares() {
+		if m.OnError != nil {
+			func() {
+				// A failing error handler has nowhere left to report to
+				defer func() { _ = recover() }()
+
+				m.OnError(err)
+			}()
+		}
+	}
+}
+
+func (c *Cache[K, T]) snapshotMiddlewares() []*Middlewares[K, T] {
+	c.middlewaresMu.RLock()
+	defer c.middlewaresMu.RUnlock()
+
+	return c.middlewares
+}
@@ -0,0 +1,156 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeAndUnsubscribe(t *testing.T) {
+	createdItems := make([]TestStruct, 0)
+	deletedKeys := make([]string, 0)
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications()
+
+	unsubscribe := c.Subscribe(cache.Middlewares[string, TestStruct]{
+		OnCreate: func(items []TestStruct) { createdItems = append(createdItems, items...) },
+		OnDeleteKeyed: func(changes []cache.Change[string, TestStruct]) {
+			for _, change := range changes {
+				deletedKeys = append(deletedKeys, change.Key)
+			}
+		},
+	})
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Delete("item1")
+
+	unsubscribe()
+	unsubscribe()
+
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Delete("item2")
+
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 30}}, createdItems)
+	assert.Equal(t, []string{"item1"}, deletedKeys)
+}
+
+func TestMiddlewarePriority(t *testing.T) {
+	order := make([]string, 0)
+	record := func(name string) cache.Middleware[TestStruct] {
+		return func([]TestStruct) { order = append(order, name) }
+	}
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().
+		OnCreate(record("default"))
+
+	c.Subscribe(cache.Middlewares[string, TestStruct]{OnCreate: record("persistence"), Priority: -10})
+	c.Subscribe(cache.Middlewares[string, TestStruct]{OnCreate: record("metrics"), Priority: 10})
+	c.OnCreate(record("default2"))
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	assert.Equal(t, []string{"metrics", "default", "default2", "persistence"}, order)
+}
+
+func TestMiddlewarePanicRecovered(t *testing.T) {
+	errs := make([]error, 0)
+	createdItems := make([]TestStruct, 0)
+
+	c := cache.New[string, TestStruct]().WithInterval(50 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		OnCreate(func([]TestStruct) { panic("boom") }).
+		OnCreate(func(items []TestStruct) { createdItems = append(createdItems, items...) }).
+		OnError(func(err error) { errs = append(errs, err) })
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30}, time.Now().Add(120*time.Millisecond))
+	time.Sleep(250 * time.Millisecond)
+
+	c.Stop()
+
+	// Maintain survived the panic and went on to expire the item
+	_, exists := c.Get("item1")
+	assert.False(t, exists)
+	assert.Equal(t, int64(0), c.Stats().Items)
+
+	assert.Len(t, createdItems, 1)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, int64(1), c.Stats().MiddlewarePanics)
+
+	var panicErr *cache.PanicError
+	assert.ErrorAs(t, errs[0], &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+}
+
+func TestMiddlewareFilter(t *testing.T) {
+	createdItems := make([]TestStruct, 0)
+	changeSets := 0
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications()
+
+	c.Subscribe(cache.Middlewares[string, TestStruct]{
+		OnCreate:  func(items []TestStruct) { createdItems = append(createdItems, items...) },
+		OnChanges: func(cache.ChangeSet[string, TestStruct]) { changeSets++ },
+		Filter:    func(key string, value TestStruct) bool { return value.Age >= 30 },
+	})
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 30}}, createdItems)
+	assert.Equal(t, 1, changeSets)
+}
+
+func TestOnHitAndOnMiss(t *testing.T) {
+	hits := make([]string, 0)
+	misses := make([]string, 0)
+
+	c := cache.New[string, TestStruct]().
+		OnHit(func(key string) { hits = append(hits, key) }).
+		OnMiss(func(key string) { misses = append(misses, key) })
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Get("item1")
+	c.Get("nonexistent")
+
+	assert.Equal(t, []string{"item1"}, hits)
+	assert.Equal(t, []string{"nonexistent"}, misses)
+}
+
+func TestNamespaceMiddlewares(t *testing.T) {
+	tenantA := make([]string, 0)
+	tenantB := make([]string, 0)
+
+	record := func(keys *[]string) cache.KeyedMiddleware[string, TestStruct] {
+		return func(changes []cache.Change[string, TestStruct]) {
+			for _, change := range changes {
+				*keys = append(*keys, change.Key)
+			}
+		}
+	}
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications()
+	c.Subscribe(cache.Middlewares[string, TestStruct]{Namespace: "a", OnCreateKeyed: record(&tenantA)})
+	c.Subscribe(cache.Middlewares[string, TestStruct]{Namespace: "b", OnCreateKeyed: record(&tenantB)})
+
+	c.Set("a:user:1", TestStruct{Name: "Alice"})
+	c.Set("b:user:1", TestStruct{Name: "Bob"})
+	c.Set("ab:user:1", TestStruct{Name: "Carol"})
+	c.Set("a", TestStruct{Name: "Dave"})
+
+	assert.Equal(t, []string{"a:user:1"}, tenantA)
+	assert.Equal(t, []string{"b:user:1"}, tenantB)
+
+	slashed := make([]string, 0)
+
+	c = cache.New[string, TestStruct]().WithImmediateNotifications().WithNamespaceSeparator("/")
+	c.Subscribe(cache.Middlewares[string, TestStruct]{Namespace: "a", OnCreateKeyed: record(&slashed)})
+
+	c.Set("a/user/1", TestStruct{Name: "Alice"})
+	c.Set("a:user:1", TestStruct{Name: "Alice"})
+
+	assert.Equal(t, []string{"a/user/1"}, slashed)
+}
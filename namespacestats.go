@@ -0,0 +1,98 @@
+package simplecache
+
+import (
+	"maps"
+	"sync"
+)
+
+// NamespaceStats are the counters of the keys within one namespace, see WithNamespaceStats
+type NamespaceStats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Items       int64 `json:"items"`
+	MemoryBytes int64 `json:"memoryUsageBytes"`
+}
+
+type namespaceStats struct {
+	mu    sync.Mutex
+	stats map[string]NamespaceStats
+}
+
+// WithNamespaceStats breaks hits, misses, items and memory down by namespace, see WithNamespaceSeparator.
+// Items already in a store handed to WithStore aren't counted.
+func (c *Cache[K, T]) WithNamespaceStats() *Cache[K, T] {
+	c.namespaceStats = &namespaceStats{stats: make(map[string]NamespaceStats)}
+
+	return c
+}
+
+// NamespaceStats returns the counters by namespace, keys without one are under "", nil without WithNamespaceStats
+func (c *Cache[K, T]) NamespaceStats() map[string]NamespaceStats {
+	if c.namespaceStats == nil {
+		return nil
+	}
+
+	c.namespaceStats.mu.Lock()
+	defer c.namespaceStats.mu.Unlock()
+
+	return maps.Clone(c.namespaceStats.stats)
+}
+
+func (n *namespaceStats) read(namespace string, hit bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stats := n.stats[namespace]
+	if hit {
+		stats.Hits++
+	} else {
+		stats.Misses++
+	}
+
+	n.stats[namespace] = stats
+}
+
+// resize adds items and size bytes to the namespace, negative when they leave it
+func (n *namespaceStats) resize(namespace string, items, size int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stats := n.stats[namespace]
+	stats.Items += int64(items)
+	stats.MemoryBytes += int64(size)
+
+	n.stats[namespace] = stats
+}
+
+// clear zeroes the items and memory of every namespace, keeping the hits and misses
+func (n *namespaceStats) clear() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for namespace, stats := range n.stats {
+		stats.Items, stats.MemoryBytes = 0, 0
+		n.stats[namespace] = stats
+	}
+}
+
+// reset zeroes the hits and misses of every namespace, keeping the items and memory
+func (n *namespaceStats) reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for namespace, stats := range n.stats {
+		stats.Hits, stats.Misses = 0, 0
+		n.stats[namespace] = stats
+	}
+}
+
+// setMemory replaces the memory of every namespace by a recount
+func (n *namespaceStats) setMemory(memory map[string]int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for namespace, stats := range n.stats {
+		stats.MemoryBytes = memory[namespace]
+		n.stats[namespace] = stats
+	}
+}
@@ -0,0 +1,45 @@
+package simplecache_test
+
+import (
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceStats(t *testing.T) {
+	c := cache.New[string, int]().WithNamespaceStats()
+
+	c.Set("acme:user:1", 1)
+	c.Set("acme:user:2", 2)
+	c.Set("acme:user:2", 3)
+	c.Set("globex:user:1", 1)
+	c.Set("config", 1)
+
+	c.Get("acme:user:1")
+	c.Get("acme:user:3")
+	c.Get("globex:user:1")
+	c.Delete("globex:user:1")
+
+	stats := c.NamespaceStats()
+	assert.Equal(t, int64(1), stats["acme"].Hits)
+	assert.Equal(t, int64(1), stats["acme"].Misses)
+	assert.Equal(t, int64(2), stats["acme"].Items)
+	assert.Equal(t, int64(1), stats["globex"].Hits)
+	assert.Equal(t, int64(0), stats["globex"].Items)
+	assert.Zero(t, stats["globex"].MemoryBytes)
+	assert.Equal(t, int64(1), stats[""].Items)
+
+	// The namespaces add up to the whole cache
+	assert.Equal(t, c.Stats().MemoryBytes, stats["acme"].MemoryBytes+stats[""].MemoryBytes)
+
+	c.DeleteAll()
+	assert.Equal(t, cache.NamespaceStats{Hits: 1, Misses: 1}, c.NamespaceStats()["acme"])
+}
+
+func TestNamespaceStatsDisabled(t *testing.T) {
+	c := cache.New[string, int]()
+	c.Set("acme:user:1", 1)
+
+	assert.Nil(t, c.NamespaceStats())
+}
@@ -0,0 +1,345 @@
+package simplecache
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultNATSSubject = "simplecache.invalidations"
+
+// defaultNATSMaxPayload is the server's default, used when INFO doesn't announce max_payload
+const defaultNATSMaxPayload = 1 << 20
+
+// NATSBus is an InvalidationBus publishing on a NATS subject, speaking the NATS client protocol.
+// Delivery is at-most-once, invalidations published while a replica is disconnected are lost to it.
+type NATSBus[K comparable] struct {
+	Addr string
+
+	// Subject defaults to "simplecache.invalidations"
+	Subject string
+
+	User     string
+	Password string
+	Token    string
+
+	// Compression compresses the messages, every replica needs the same
+	Compression Compression
+
+	// OnError receives connection and decoding errors of the background reader
+	OnError func(error)
+
+	// DialTimeout bounds dialing the server and reading its greeting, defaults to 5s
+	DialTimeout time.Duration
+
+	mu       sync.Mutex
+	conn     net.Conn
+	w        *bufio.Writer
+	handlers map[int]func(Invalidation[K])
+	nextSID  int
+	closed   bool
+}
+
+func (b *NATSBus[K]) Publish(inv Invalidation[K]) error {
+	payload, err := encodeInvalidation(inv, b.Compression)
+	if err != nil {
+		return err
+	}
+
+	if err := b.connect(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Dropped since connect
+	if b.conn == nil {
+		return fmt.Errorf("simplecache: nats publish: %w", net.ErrClosed)
+	}
+
+	fmt.Fprintf(b.w, "PUB %s %d\r\n", b.subject(), len(payload))
+	b.w.Write(payload)
+	b.w.WriteString("\r\n")
+
+	if err := b.w.Flush(); err != nil {
+		b.drop()
+		return fmt.Errorf("simplecache: nats publish: %w", err)
+	}
+
+	return nil
+}
+
+func (b *NATSBus[K]) Subscribe(fn func(Invalidation[K])) (func(), error) {
+	b.mu.Lock()
+
+	if b.handlers == nil {
+		b.handlers = make(map[int]func(Invalidation[K]))
+	}
+
+	b.nextSID++
+	sid := b.nextSID
+	b.handlers[sid] = fn
+
+	// connect subscribes every handler when it dials, an open connection needs the new one sent
+	var err error
+	connected := b.conn != nil
+	if connected {
+		fmt.Fprintf(b.w, "SUB %s %d\r\n", b.subject(), sid)
+		err = b.w.Flush()
+	}
+
+	b.mu.Unlock()
+
+	if !connected {
+		err = b.connect()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		delete(b.handlers, sid)
+		return nil, err
+	}
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.handlers, sid)
+
+		if b.conn != nil {
+			fmt.Fprintf(b.w, "UNSUB %d\r\n", sid)
+			b.w.Flush()
+		}
+	}, nil
+}
+
+// Close disconnects, the bus can't be used afterwards
+func (b *NATSBus[K]) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.drop()
+
+	return nil
+}
+
+// connect dials unless connected, sending CONNECT and the subscriptions. Called without mu, which isn't held while dialing.
+func (b *NATSBus[K]) connect() error {
+	b.mu.Lock()
+	closed, connected := b.closed, b.conn != nil
+	b.mu.Unlock()
+
+	switch {
+	case closed:
+		return errBusClosed
+	case connected:
+		return nil
+	}
+
+	timeout := cmp.Or(b.DialTimeout, busDialTimeout)
+
+	conn, err := net.DialTimeout("tcp", b.Addr, timeout)
+	if err != nil {
+		return fmt.Errorf("simplecache: nats: %w", err)
+	}
+
+	r := bufio.NewReader(conn)
+
+	// The server greets with INFO
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	greeting, err := r.ReadString('\n')
+	conn.SetReadDeadline(time.Time{})
+
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("simplecache: nats: %w", err)
+	}
+
+	var info struct {
+		MaxPayload int64 `json:"max_payload"`
+	}
+
+	if body, ok := strings.CutPrefix(greeting, "INFO "); ok {
+		json.Unmarshal([]byte(body), &info)
+	}
+
+	if info.MaxPayload <= 0 {
+		info.MaxPayload = defaultNATSMaxPayload
+	}
+
+	options, _ := json.Marshal(map[string]any{
+		"verbose":    false,
+		"pedantic":   false,
+		"name":       "simplecache",
+		"lang":       "go",
+		"user":       b.User,
+		"pass":       b.Password,
+		"auth_token": b.Token,
+	})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Connected by another caller or closed while dialing
+	if b.closed || b.conn != nil {
+		conn.Close()
+
+		if b.closed {
+			return errBusClosed
+		}
+
+		return nil
+	}
+
+	b.conn, b.w = conn, bufio.NewWriter(conn)
+	fmt.Fprintf(b.w, "CONNECT %s\r\n", options)
+
+	for sid := range b.handlers {
+		fmt.Fprintf(b.w, "SUB %s %d\r\n", b.subject(), sid)
+	}
+
+	if err := b.w.Flush(); err != nil {
+		b.drop()
+		return fmt.Errorf("simplecache: nats: %w", err)
+	}
+
+	go b.read(conn, r, info.MaxPayload)
+
+	return nil
+}
+
+// drop closes the connection, called with mu held
+func (b *NATSBus[K]) drop() {
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+	}
+}
+
+// read handles the messages of conn until it fails, then redials while there are subscriptions
+func (b *NATSBus[K]) read(conn net.Conn, r *bufio.Reader, maxPayload int64) {
+	err := b.readLoop(conn, r, maxPayload)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Replaced or closed on purpose
+	if b.conn != conn {
+		return
+	}
+
+	b.drop()
+	b.report(err)
+
+	go b.reconnect()
+}
+
+func (b *NATSBus[K]) reconnect() {
+	for {
+		time.Sleep(busReconnectDelay)
+
+		b.mu.Lock()
+		done := b.closed || b.conn != nil || len(b.handlers) == 0
+		b.mu.Unlock()
+
+		if done {
+			return
+		}
+
+		err := b.connect()
+		if err == nil {
+			return
+		}
+
+		b.report(err)
+	}
+}
+
+// readLoop handles the messages of conn, payloads are bounded by the max_payload the server announced
+func (b *NATSBus[K]) readLoop(conn net.Conn, r *bufio.Reader, maxPayload int64) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSuffix(line, "\r\n")
+		op, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(op) {
+		case "PING":
+			b.mu.Lock()
+			if b.conn == conn {
+				b.w.WriteString("PONG\r\n")
+				b.w.Flush()
+			}
+			b.mu.Unlock()
+
+		case "-ERR":
+			b.report(fmt.Errorf("simplecache: nats: %s", args))
+
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <size>
+			fields := strings.Fields(args)
+			if len(fields) < 3 {
+				return fmt.Errorf("simplecache: nats: malformed MSG %q", line)
+			}
+
+			sid, _ := strconv.Atoi(fields[1])
+			size, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+			if err != nil || size < 0 || size > maxPayload {
+				return fmt.Errorf("simplecache: nats: malformed MSG %q", line)
+			}
+
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return err
+			}
+
+			b.deliver(sid, payload[:size])
+		}
+	}
+}
+
+func (b *NATSBus[K]) deliver(sid int, payload []byte) {
+	b.mu.Lock()
+	fn := b.handlers[sid]
+	b.mu.Unlock()
+
+	if fn == nil {
+		return
+	}
+
+	inv, err := decodeInvalidation[K](payload, b.Compression)
+	if err != nil {
+		b.report(fmt.Errorf("simplecache: nats: %w", err))
+		return
+	}
+
+	fn(inv)
+}
+
+func (b *NATSBus[K]) subject() string {
+	if b.Subject == "" {
+		return defaultNATSSubject
+	}
+
+	return b.Subject
+}
+
+func (b *NATSBus[K]) report(err error) {
+	if err != nil && b.OnError != nil {
+		b.OnError(err)
+	}
+}
@@ -0,0 +1,195 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// startFakeNATS relays PUB to the SUBs of every connection
+func startFakeNATS(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	subs := make(map[net.Conn][]string)
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+
+		io.WriteString(conn, "INFO {}\r\n")
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			fields := strings.Fields(line)
+
+			mu.Lock()
+			switch fields[0] {
+			case "SUB":
+				subs[conn] = append(subs[conn], fields[2])
+
+			case "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				io.ReadFull(r, payload)
+
+				for c, sids := range subs {
+					for _, sid := range sids {
+						fmt.Fprintf(c, "MSG %s %s %d\r\n%s", fields[1], sid, size, payload)
+					}
+				}
+
+			case "PING":
+				io.WriteString(conn, "PONG\r\n")
+			}
+			mu.Unlock()
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestNATSBus(t *testing.T) {
+	addr := startFakeNATS(t)
+
+	bus1 := &cache.NATSBus[string]{Addr: addr}
+	bus2 := &cache.NATSBus[string]{Addr: addr}
+	defer bus1.Close()
+	defer bus2.Close()
+
+	invalidated := make(chan string, 1)
+
+	replica1 := cache.New[string, TestStruct]().WithInvalidationBus(bus1)
+	replica2 := cache.New[string, TestStruct]().WithInvalidationBus(bus2).WithImmediateNotifications().
+		OnDeleteKeyed(func(changes []cache.Change[string, TestStruct]) {
+			invalidated <- changes[0].Key
+		})
+
+	seen := make(chan cache.Invalidation[string], 4)
+	_, err := bus1.Subscribe(func(inv cache.Invalidation[string]) { seen <- inv })
+	assert.NoError(t, err)
+
+	// Let replica1 see the write of replica2 before writing over it
+	replica2.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	<-seen
+
+	replica1.Set("item1", TestStruct{Name: "Alice", Age: 31})
+
+	select {
+	case key := <-invalidated:
+		assert.Equal(t, "item1", key)
+	case <-time.After(time.Second):
+		t.Fatal("invalidation not received")
+	}
+
+	_, ok := replica2.Get("item1")
+	assert.False(t, ok)
+
+	item1, ok := replica1.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 31}, item1)
+}
+
+func TestNATSBusRejectsOversizedMessages(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		io.WriteString(conn, `INFO {"max_payload":16}`+"\r\n")
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if strings.HasPrefix(line, "SUB") {
+				io.WriteString(conn, "MSG simplecache.invalidations 1 9223372036854775807\r\n")
+			}
+		}
+	}()
+
+	errs := make(chan error, 4)
+	bus := &cache.NATSBus[string]{Addr: ln.Addr().String(), OnError: func(err error) { errs <- err }}
+	defer bus.Close()
+
+	_, err = bus.Subscribe(func(cache.Invalidation[string]) {})
+	assert.NoError(t, err)
+
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "malformed MSG")
+	case <-time.After(time.Second):
+		t.Fatal("oversized MSG not rejected")
+	}
+}
+
+func TestNATSBusDialDoesNotBlockClose(t *testing.T) {
+	// Accepts connections but never greets
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	bus := &cache.NATSBus[string]{Addr: ln.Addr().String(), DialTimeout: 200 * time.Millisecond}
+
+	published := make(chan error, 1)
+	go func() {
+		published <- bus.Publish(cache.Invalidation[string]{Keys: []string{"item1"}})
+	}()
+
+	// The bus isn't locked while the dial waits
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		bus.Close()
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Close waited for the dial")
+	}
+
+	select {
+	case err := <-published:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Publish didn't time out")
+	}
+}
@@ -0,0 +1,60 @@
+package simplecache
+
+// Observer is an alternative to registering separate middlewares, it receives one call per change
+type Observer[K comparable, T any] interface {
+	OnCreated(Change[K, T])
+	OnUpdated(Change[K, T])
+	OnDeleted(Change[K, T])
+	OnExpired(Change[K, T])
+}
+
+// Attach registers o, observers are tracked by identity so they have to be comparable (e.g. pointers)
+func (c *Cache[K, T]) Attach(o Observer[K, T]) *Cache[K, T] {
+	unsubscribe := c.Subscribe(Middlewares[K, T]{
+		OnChanges: func(changes ChangeSet[K, T]) {
+			for _, change := range changes.Created {
+				o.OnCreated(change)
+			}
+
+			for _, change := range changes.Updated {
+				o.OnUpdated(change)
+			}
+
+			for _, change := range changes.Deleted {
+				o.OnDeleted(change)
+			}
+
+			for _, change := range changes.Expired {
+				o.OnExpired(change)
+			}
+		},
+	})
+
+	c.observersMu.Lock()
+	defer c.observersMu.Unlock()
+
+	if c.observers == nil {
+		c.observers = make(map[Observer[K, T]]func())
+	}
+
+	// Attaching twice replaces the earlier registration
+	if previous, ok := c.observers[o]; ok {
+		previous()
+	}
+
+	c.observers[o] = unsubscribe
+
+	return c
+}
+
+func (c *Cache[K, T]) Detach(o Observer[K, T]) *Cache[K, T] {
+	c.observersMu.Lock()
+	defer c.observersMu.Unlock()
+
+	if unsubscribe, ok := c.observers[o]; ok {
+		unsubscribe()
+		delete(c.observers, o)
+	}
+
+	return c
+}
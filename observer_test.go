@@ -0,0 +1,43 @@
+package simplecache_test
+
+import (
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	calls []string
+}
+
+func (o *recordingObserver) OnCreated(c cache.Change[string, TestStruct]) {
+	o.calls = append(o.calls, "created:"+c.Key)
+}
+
+func (o *recordingObserver) OnUpdated(c cache.Change[string, TestStruct]) {
+	o.calls = append(o.calls, "updated:"+c.Key)
+}
+
+func (o *recordingObserver) OnDeleted(c cache.Change[string, TestStruct]) {
+	o.calls = append(o.calls, "deleted:"+c.Key)
+}
+
+func (o *recordingObserver) OnExpired(c cache.Change[string, TestStruct]) {
+	o.calls = append(o.calls, "expired:"+c.Key)
+}
+
+func TestObserverAttachDetach(t *testing.T) {
+	observer := &recordingObserver{}
+
+	c := cache.New[string, TestStruct]().WithImmediateNotifications().Attach(observer)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Delete("item1")
+
+	c.Detach(observer)
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	assert.Equal(t, []string{"created:item1", "updated:item1", "deleted:item1"}, observer.calls)
+}
@@ -0,0 +1,240 @@
+package simplecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by a RemoteOrigin that doesn't have a key, GetOrFetch caches it as a negative entry
+var ErrNotFound = errors.New("simplecache: not found at origin")
+
+var errNoOrigin = errors.New("simplecache: no origin set, see WithOrigin")
+
+// negativePruneSize is how many negative entries are kept before expired ones are swept
+const negativePruneSize = 1024
+
+// RemoteOrigin fills misses from an upstream service, a zero expires falls back to OriginOptions.TTL
+type RemoteOrigin[K comparable, T any] interface {
+	Fetch(ctx context.Context, key K) (value T, expires time.Time, err error)
+}
+
+type OriginOptions struct {
+	// Timeout bounds each attempt, none when 0
+	Timeout time.Duration
+
+	// Retries is how many more attempts a failing fetch gets, Backoff the delay between them
+	Retries int
+	Backoff time.Duration
+
+	// TTL is how long fetched values are kept when the origin doesn't say, forever when 0
+	TTL time.Duration
+
+	// NegativeTTL is how long an ErrNotFound is remembered, not at all when 0
+	NegativeTTL time.Duration
+}
+
+// WithOrigin makes GetOrFetch fill misses from origin, turning the cache into a caching proxy of an upstream service
+func (c *Cache[K, T]) WithOrigin(origin RemoteOrigin[K, T], options OriginOptions) *Cache[K, T] {
+	c.origin = origin
+	c.originOptions = options
+
+	return c
+}
+
+// GetOrFetch returns the cached value or fetches it from the origin, concurrent misses on a key share one fetch.
+// Keys the origin doesn't have return ErrNotFound, without asking it again within the NegativeTTL.
+func (c *Cache[K, T]) GetOrFetch(ctx context.Context, key K) (T, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	var zero T
+	if c.origin == nil {
+		return zero, errNoOrigin
+	}
+
+	if c.negativeHit(key) {
+		c.addMetric(metricNegativeHits, 1)
+		return zero, ErrNotFound
+	}
+
+	item, err := c.originFlights.do(keyString(key), func() (Item[T], error) {
+		return c.fetch(ctx, key)
+	})
+
+	return item.Value, err
+}
+
+func (c *Cache[K, T]) fetch(ctx context.Context, key K) (Item[T], error) {
+	options := c.originOptions
+
+	var err error
+	for attempt := 0; attempt <= options.Retries; attempt++ {
+		if attempt > 0 && options.Backoff > 0 {
+			select {
+			case <-ctx.Done():
+				return Item[T]{}, ctx.Err()
+			case <-time.After(options.Backoff):
+			}
+		}
+
+		c.addMetric(metricOriginFetches, 1)
+
+		var item Item[T]
+		if item, err = c.fetchOnce(ctx, key); err == nil {
+			if item.Expires.IsZero() && options.TTL > 0 {
+				item.Expires = time.Now().Add(options.TTL)
+			}
+
+			return item, c.SetContext(ctx, key, item.Value, item.Expires)
+		}
+
+		if errors.Is(err, ErrNotFound) {
+			c.addNegative(key)
+			return Item[T]{}, err
+		}
+
+		if ctx.Err() != nil {
+			return Item[T]{}, err
+		}
+	}
+
+	return Item[T]{}, err
+}
+
+func (c *Cache[K, T]) fetchOnce(ctx context.Context, key K) (Item[T], error) {
+	if c.originOptions.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.originOptions.Timeout)
+		defer cancel()
+	}
+
+	ctx, end := c.span(ctx, "fetch")
+	value, expires, err := c.origin.Fetch(ctx, key)
+	end(err)
+
+	return Item[T]{Value: value, Expires: expires}, err
+}
+
+func (c *Cache[K, T]) negativeHit(key K) bool {
+	c.negativeMu.Lock()
+	defer c.negativeMu.Unlock()
+
+	expires, ok := c.negatives[key]
+
+	return ok && time.Now().Before(expires)
+}
+
+func (c *Cache[K, T]) addNegative(key K) {
+	if c.originOptions.NegativeTTL <= 0 {
+		return
+	}
+
+	c.negativeMu.Lock()
+	defer c.negativeMu.Unlock()
+
+	now := time.Now()
+
+	if c.negatives == nil {
+		c.negatives = make(map[K]time.Time)
+	}
+
+	if len(c.negatives) >= negativePruneSize {
+		for k, expires := range c.negatives {
+			if !now.Before(expires) {
+				delete(c.negatives, k)
+			}
+		}
+	}
+
+	c.negatives[key] = now.Add(c.originOptions.NegativeTTL)
+}
+
+// HTTPOrigin fetches values from an HTTP service, GET URL with the escaped key in place of {key} (or appended).
+// 404 means ErrNotFound, other non-2xx responses are errors worth retrying. Cache-Control max-age or Expires set the expiry.
+type HTTPOrigin[K comparable, T any] struct {
+	URL string
+
+	// Header is added to every request, e.g. for credentials
+	Header http.Header
+
+	// Codec decodes the body, defaults to JSONCodec
+	Codec Codec[T]
+
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+func (o *HTTPOrigin[K, T]) Fetch(ctx context.Context, key K) (T, time.Time, error) {
+	var zero T
+
+	target := o.URL + url.PathEscape(keyString(key))
+	if strings.Contains(o.URL, "{key}") {
+		target = strings.ReplaceAll(o.URL, "{key}", url.PathEscape(keyString(key)))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return zero, time.Time{}, err
+	}
+
+	for name, values := range o.Header {
+		req.Header[name] = values
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return zero, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return zero, time.Time{}, ErrNotFound
+	case resp.StatusCode/100 != 2:
+		return zero, time.Time{}, fmt.Errorf("simplecache: origin %s: %s", target, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return zero, time.Time{}, err
+	}
+
+	codec := o.Codec
+	if codec == nil {
+		codec = JSONCodec[T]{}
+	}
+
+	value, err := codec.Decode(body)
+
+	return value, httpExpiry(resp.Header), err
+}
+
+// httpExpiry reads Cache-Control max-age, then Expires, zero when neither is set
+func httpExpiry(header http.Header) time.Time {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(directive), "max-age="); ok {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				return time.Now().Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expires
+	}
+
+	return time.Time{}
+}
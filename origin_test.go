@@ -0,0 +1,98 @@
+package simplecache_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrFetch(t *testing.T) {
+	var requests atomic.Int32
+	var flaky atomic.Int32
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "token", r.Header.Get("X-Token"))
+
+		switch r.URL.Path {
+		case "/users/a b":
+			time.Sleep(20 * time.Millisecond)
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			w.Write([]byte(`{"Name":"Alice","Age":30}`))
+
+		case "/users/flaky":
+			if flaky.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+
+			w.Write([]byte(`{"Name":"Bob"}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	c := cache.New[string, TestStruct]().WithOrigin(
+		&cache.HTTPOrigin[string, TestStruct]{URL: upstream.URL + "/users/{key}", Header: http.Header{"X-Token": {"token"}}},
+		cache.OriginOptions{Timeout: time.Second, Retries: 1, NegativeTTL: time.Minute},
+	)
+
+	// Concurrent misses share one request
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			value, err := c.GetOrFetch(context.Background(), "a b")
+			assert.NoError(t, err)
+			assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, value)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests.Load())
+
+	// Then it's served from the cache
+	_, err := c.GetOrFetch(context.Background(), "a b")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// Failures are retried
+	value, err := c.GetOrFetch(context.Background(), "flaky")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob", value.Name)
+	assert.Equal(t, int32(3), requests.Load())
+
+	// Missing keys are remembered
+	_, err = c.GetOrFetch(context.Background(), "missing")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	_, err = c.GetOrFetch(context.Background(), "missing")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	assert.Equal(t, int32(4), requests.Load())
+	assert.Equal(t, int64(1), c.Stats().NegativeHits)
+}
+
+func TestGetOrFetchTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	c := cache.New[string, int]().WithOrigin(
+		&cache.HTTPOrigin[string, int]{URL: upstream.URL + "/"},
+		cache.OriginOptions{Timeout: 20 * time.Millisecond},
+	)
+
+	_, err := c.GetOrFetch(context.Background(), "key")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,121 @@
+// Package otelcache instruments a simplecache with OpenTelemetry, pass New to Cache.WithTelemetry.
+package otelcache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/kamludwinski2/simplecache"
+
+type telemetry struct {
+	meter  metric.Meter
+	tracer trace.Tracer
+
+	// Instruments are created on first use, the cache reports the metrics it knows by name
+	mu         sync.Mutex
+	counters   map[string]metric.Int64Counter
+	gauges     map[string]metric.Int64Gauge
+	histograms map[string]metric.Float64Histogram
+}
+
+// New reports the metrics of the cache as simplecache.* counters and gauges (e.g. simplecache.memory_usage_bytes),
+// durations as simplecache.<operation>.duration histograms in seconds and traces them as simplecache.<operation> spans,
+// a dispatch of WithAsyncDispatch in a trace of its own linked to the write that queued it
+func New(meters metric.MeterProvider, tracers trace.TracerProvider) cache.Telemetry {
+	return &telemetry{
+		meter:      meters.Meter(instrumentationName),
+		tracer:     tracers.Tracer(instrumentationName),
+		counters:   make(map[string]metric.Int64Counter),
+		gauges:     make(map[string]metric.Int64Gauge),
+		histograms: make(map[string]metric.Float64Histogram),
+	}
+}
+
+func (t *telemetry) Count(name string, delta int) {
+	counter, err := instrument(t, t.counters, name, func(name string) (metric.Int64Counter, error) {
+		return t.meter.Int64Counter(name)
+	})
+	if err == nil {
+		counter.Add(context.Background(), int64(delta))
+	}
+}
+
+func (t *telemetry) Gauge(name string, value int) {
+	gauge, err := instrument(t, t.gauges, name, func(name string) (metric.Int64Gauge, error) {
+		return t.meter.Int64Gauge(name)
+	})
+	if err == nil {
+		gauge.Record(context.Background(), int64(value))
+	}
+}
+
+func (t *telemetry) Observe(name string, d time.Duration) {
+	histogram, err := instrument(t, t.histograms, name+".duration", func(name string) (metric.Float64Histogram, error) {
+		return t.meter.Float64Histogram(name, metric.WithUnit("s"))
+	})
+	if err == nil {
+		histogram.Record(context.Background(), d.Seconds())
+	}
+}
+
+func (t *telemetry) Start(ctx context.Context, name string) (context.Context, func(error)) {
+	return t.start(ctx, name)
+}
+
+func (t *telemetry) StartLinked(ctx context.Context, name string) (context.Context, func(error)) {
+	return t.start(ctx, name, trace.WithNewRoot(), trace.WithLinks(trace.LinkFromContext(ctx)))
+}
+
+func (t *telemetry) start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, "simplecache."+name, opts...)
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}
+}
+
+// instrument returns the instrument of name from instruments, creating it when missing
+func instrument[I any](t *telemetry, instruments map[string]I, name string, create func(string) (I, error)) (I, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if i, ok := instruments[name]; ok {
+		return i, nil
+	}
+
+	i, err := create("simplecache." + snakeCase(name))
+	if err == nil {
+		instruments[name] = i
+	}
+
+	return i, err
+}
+
+// snakeCase turns the camel case names of Metrics into the OpenTelemetry style, memoryUsageBytes into memory_usage_bytes
+func snakeCase(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
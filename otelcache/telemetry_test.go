@@ -0,0 +1,100 @@
+package otelcache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/kamludwinski2/simplecache/otelcache"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type failingOrigin struct{}
+
+func (failingOrigin) Fetch(context.Context, string) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("origin down")
+}
+
+func TestTelemetry(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	spans := tracetest.NewSpanRecorder()
+
+	c := cache.New[string, int]().WithImmediateNotifications().
+		WithTelemetry(otelcache.New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)), sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))).
+		WithOrigin(failingOrigin{}, cache.OriginOptions{}).
+		OnCreate(func([]int) {})
+
+	c.Set("item1", 1)
+	c.Get("item1")
+	c.Get("item1")
+	c.Get("missing")
+
+	_, err := c.GetOrFetch(context.Background(), "remote")
+	assert.Error(t, err)
+
+	var data metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &data))
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	assert.Equal(t, int64(2), metrics["simplecache.hits"].(metricdata.Sum[int64]).DataPoints[0].Value)
+	assert.Equal(t, int64(1), metrics["simplecache.items"].(metricdata.Gauge[int64]).DataPoints[0].Value)
+	assert.Contains(t, metrics, "simplecache.memory_usage_bytes")
+	assert.Contains(t, metrics, "simplecache.dispatch.duration")
+	assert.Contains(t, metrics, "simplecache.fetch.duration")
+
+	names := make(map[string]codes.Code)
+	for _, span := range spans.Ended() {
+		names[span.Name()] = span.Status().Code
+	}
+
+	assert.Equal(t, codes.Unset, names["simplecache.dispatch"])
+	assert.Equal(t, codes.Error, names["simplecache.fetch"])
+}
+
+func TestAsyncDispatchLinksSpans(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tracers := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+
+	dispatched := make(chan trace.SpanContext, 1)
+
+	c := cache.New[string, int]().WithImmediateNotifications().WithAsyncDispatch(8).WithInterval(time.Minute).
+		WithTelemetry(otelcache.New(sdkmetric.NewMeterProvider(), tracers)).
+		OnCreateContext(func(ctx context.Context, _ []cache.Change[string, int]) {
+			dispatched <- trace.SpanContextFromContext(ctx)
+		})
+
+	go c.Maintain()
+	defer c.Stop()
+
+	ctx, write := tracers.Tracer("test").Start(context.Background(), "write")
+	c.SetContext(ctx, "item1", 1)
+	write.End()
+
+	handler := <-dispatched
+	assert.True(t, handler.IsValid())
+	assert.NotEqual(t, write.SpanContext().TraceID(), handler.TraceID())
+
+	assert.Eventually(t, func() bool {
+		for _, span := range spans.Ended() {
+			if span.Name() == "simplecache.dispatch" {
+				return len(span.Links()) == 1 && span.Links()[0].SpanContext.Equal(write.SpanContext())
+			}
+		}
+
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
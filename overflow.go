@@ -0,0 +1,341 @@
+package simplecache
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OverflowTier stores entries spilled out of memory, keyed by the key's string form.
+// Adapters for embedded stores such as Bolt or Badger only need to map these calls onto a bucket.
+type OverflowTier interface {
+	Get(key string) ([]byte, bool, error)
+	Put(key string, data []byte) error
+	Delete(key string) error
+	Clear() error
+}
+
+// WithOverflow keeps at most maxItems in memory, each Maintain tick spills the excess to tier and a Get for a spilled key reads it back.
+// The entries read or written least recently spill first, they still expire, with OnExpiry, on the tick after their expiration.
+// The tier is cleared first, entries left over from a previous run are not used.
+func (c *Cache[K, T]) WithOverflow(tier OverflowTier, maxItems int) *Cache[K, T] {
+	if err := tier.Clear(); err != nil {
+		c.startErrs = append(c.startErrs, err)
+	}
+
+	c.overflow = tier
+	c.overflowMax = maxItems
+	c.overflowAccess = &overflowAccess[K]{last: make(map[K]int64)}
+	c.spilled = make(map[K]time.Time)
+
+	return c
+}
+
+// overflowAccess orders the keys in memory by their last read or write, on a logical clock
+type overflowAccess[K comparable] struct {
+	mu    sync.Mutex
+	clock int64
+	last  map[K]int64
+}
+
+// touch records a read or write of key, a nil a does nothing
+func (a *overflowAccess[K]) touch(key K) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.clock++
+	a.last[key] = a.clock
+	a.mu.Unlock()
+}
+
+// forget drops keys that left memory, all of them without keys
+func (a *overflowAccess[K]) forget(keys ...K) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(keys) == 0 {
+		clear(a.last)
+	}
+
+	for _, key := range keys {
+		delete(a.last, key)
+	}
+}
+
+// leastRecentFirst sorts keys from the least to the most recently used, keys never touched first
+func (a *overflowAccess[K]) leastRecentFirst(keys []K) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	slices.SortFunc(keys, func(x, y K) int { return cmp.Compare(a.last[x], a.last[y]) })
+}
+
+// spill moves entries past the limit to the overflow tier, called by Maintain with the lock held
+func (c *Cache[K, T]) spill(processedDeletions map[K]struct{}) error {
+	excess := c.data.Len() - c.overflowMax
+	if c.overflow == nil || excess <= 0 {
+		return nil
+	}
+
+	data := c.writable()
+
+	keys := make([]K, 0, data.Len())
+	for key := range data.Iterate {
+		keys = append(keys, key)
+	}
+
+	c.overflowAccess.leastRecentFirst(keys)
+
+	var errs []error
+	var victims []K
+	for _, key := range keys {
+		if len(victims) == excess {
+			break
+		}
+
+		item, _ := data.Get(key)
+		encoded, err := c.encodeSpilled(key, item)
+		if err == nil {
+			err = c.overflow.Put(keyString(key), encoded)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		victims = append(victims, key)
+		c.spilled[key] = item.Expires
+
+		// Leaves memory but is still an item of its namespace
+		c.account(key, 0, -c.memorySize(key, item))
+
+		// Spilled entries are still in the cache, they are not reported as deleted
+		processedDeletions[key] = struct{}{}
+	}
+
+	for _, key := range victims {
+		data.Delete(key)
+	}
+
+	c.overflowAccess.forget(victims...)
+
+	c.commit(data)
+	c.setMetric(metricItems, data.Len())
+	c.addMetric(metricEvictions, len(victims))
+
+	return errors.Join(errs...)
+}
+
+// promote reads a spilled entry back into memory
+func (c *Cache[K, T]) promote(key K, now time.Time) (Item[T], bool) {
+	// Most misses were never spilled, checked under the read lock first
+	c.rlock()
+	_, spilled := c.spilled[key]
+	c.RUnlock()
+
+	if !spilled {
+		return Item[T]{}, false
+	}
+
+	c.lock()
+
+	if item, exists := c.data.Get(key); exists {
+		c.Unlock()
+		return item, !item.expired(now)
+	}
+
+	if _, spilled := c.spilled[key]; !spilled {
+		c.Unlock()
+		return Item[T]{}, false
+	}
+
+	// Expired entries stay spilled for the tick to expire them
+	item, found, err := c.readSpilled(key)
+	switch {
+	case err == nil && !found:
+		// Lost from the tier, it's a miss
+		err = c.dropSpilled(key)
+	case err == nil && !item.expired(now):
+		delete(c.spilled, key)
+		err = c.overflow.Delete(keyString(key))
+	}
+
+	promoted := err == nil && found && !item.expired(now)
+	if promoted {
+		data := c.writable()
+		data.Set(key, item)
+		c.commit(data)
+
+		c.account(key, 0, c.memorySize(key, item))
+		c.setMetric(metricItems, data.Len())
+
+		// Coming back from the tier is not a change
+		if c.prev != nil {
+			c.prev[key] = item
+		}
+
+		c.overflowAccess.touch(key)
+		c.addMetric(metricOverflowHits, 1)
+	}
+
+	c.Unlock()
+
+	if err != nil {
+		c.reportError(err)
+	}
+
+	return item, promoted
+}
+
+// dropSpilled removes a spilled copy of key, called with the lock held before key is written or deleted
+func (c *Cache[K, T]) dropSpilled(key K) error {
+	if _, spilled := c.spilled[key]; !spilled {
+		return nil
+	}
+
+	delete(c.spilled, key)
+	c.account(key, -1, 0)
+
+	return c.overflow.Delete(keyString(key))
+}
+
+// expireSpilled removes the spilled entries expired by now and passes them to expire, called by Maintain with the lock held
+func (c *Cache[K, T]) expireSpilled(now time.Time, expire func(K, Item[T])) error {
+	var errs []error
+	for key, expires := range c.spilled {
+		if expires.IsZero() || !expires.Before(now) {
+			continue
+		}
+
+		item, found, err := c.readSpilled(key)
+		if err == nil {
+			err = c.dropSpilled(key)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Lost from the tier, there's nothing left to report
+		if found {
+			expire(key, item)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Cache[K, T]) encodeSpilled(key K, item Item[T]) ([]byte, error) {
+	value, err := c.valueCodec().Encode(item.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(record[K]{Key: key, Value: value, Expires: item.Expires}); err != nil {
+		return nil, err
+	}
+
+	return c.pack(buf.Bytes())
+}
+
+// readSpilled reads back the entry of key, found is false when the tier doesn't have it
+func (c *Cache[K, T]) readSpilled(key K) (Item[T], bool, error) {
+	data, ok, err := c.overflow.Get(keyString(key))
+	if err != nil || !ok {
+		return Item[T]{}, false, err
+	}
+
+	data, err = c.unpack(data)
+	if err != nil {
+		return Item[T]{}, false, err
+	}
+
+	var rec record[K]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rec); err != nil {
+		return Item[T]{}, false, err
+	}
+
+	value, err := c.valueCodec().Decode(rec.Value)
+	if err != nil {
+		return Item[T]{}, false, err
+	}
+
+	return Item[T]{Value: value, Expires: rec.Expires}, true, nil
+}
+
+const dirTierExt = ".entry"
+
+// DirTier is an OverflowTier keeping one file per entry in a directory
+type DirTier string
+
+func (d DirTier) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(string(d), hex.EncodeToString(sum[:])+dirTierExt)
+}
+
+func (d DirTier) Get(key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+
+	return data, err == nil, err
+}
+
+func (d DirTier) Put(key string, data []byte) error {
+	if err := os.MkdirAll(string(d), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(d.path(key), data, 0o600)
+}
+
+func (d DirTier) Delete(key string) error {
+	if err := os.Remove(d.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Clear removes the entry files, leaving anything else in the directory alone
+func (d DirTier) Clear() error {
+	entries, err := os.ReadDir(string(d))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), dirTierExt) {
+			if err := os.Remove(filepath.Join(string(d), entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
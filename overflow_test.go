@@ -0,0 +1,204 @@
+package simplecache_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverflow(t *testing.T) {
+	dir := t.TempDir()
+	deleted := make([]TestStruct, 0)
+
+	c := cache.New[string, TestStruct]().WithInterval(50*time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		WithOverflow(cache.DirTier(dir), 1).
+		OnDelete(func(items []TestStruct) { deleted = append(deleted, items...) })
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40})
+
+	go c.Maintain()
+	time.Sleep(80 * time.Millisecond)
+	c.Stop()
+
+	assert.Equal(t, int64(1), c.Stats().Items)
+	assert.Equal(t, int64(2), c.Stats().Evictions)
+	assert.Equal(t, 2, c.MetricsSnapshot()["evictions"])
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// Spilled entries are read back transparently
+	for key, want := range map[string]TestStruct{
+		"item1": {Name: "Alice", Age: 30},
+		"item2": {Name: "Bob", Age: 25},
+		"item3": {Name: "Carol", Age: 40},
+	} {
+		got, ok := c.Get(key)
+		assert.True(t, ok)
+		assert.Equal(t, want, got)
+	}
+
+	assert.Equal(t, int64(3), c.Stats().Items)
+	assert.Equal(t, int64(2), c.Stats().OverflowHits)
+	assert.Empty(t, deleted)
+}
+
+func TestOverflowDelete(t *testing.T) {
+	dir := t.TempDir()
+
+	c := cache.New[string, TestStruct]().WithInterval(50*time.Millisecond).
+		WithOverflow(cache.DirTier(dir), 0)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	go c.Maintain()
+	time.Sleep(80 * time.Millisecond)
+	c.Stop()
+
+	assert.Equal(t, int64(0), c.Stats().Items)
+
+	c.Delete("item1")
+
+	_, ok := c.Get("item1")
+	assert.False(t, ok)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Unrelated files in the directory are left alone
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "keep.txt"), nil, 0o600))
+	c.DeleteAll()
+
+	_, err = os.Stat(filepath.Join(dir, "keep.txt"))
+	assert.NoError(t, err)
+}
+
+func TestOverflowExpiry(t *testing.T) {
+	dir := t.TempDir()
+
+	var mu sync.Mutex
+	expired := make([]string, 0)
+
+	c := cache.New[string, TestStruct]().WithInterval(50*time.Millisecond).
+		WithNamespaceStats().
+		WithOverflow(cache.DirTier(dir), 0).
+		OnExpiry(func(key string, _ cache.Item[TestStruct]) {
+			mu.Lock()
+			defer mu.Unlock()
+			expired = append(expired, key)
+		})
+
+	c.Set("user:1", TestStruct{Name: "Alice", Age: 30}, time.Now().Add(100*time.Millisecond))
+	c.Set("user:2", TestStruct{Name: "Bob", Age: 25})
+
+	go c.Maintain()
+	time.Sleep(80 * time.Millisecond)
+
+	// Spilled entries still count as items of their namespace
+	assert.Equal(t, int64(0), c.Stats().Items)
+	assert.Equal(t, int64(2), c.NamespaceStats()["user"].Items)
+	assert.Equal(t, int64(0), c.NamespaceStats()["user"].MemoryBytes)
+
+	time.Sleep(100 * time.Millisecond)
+	c.Stop()
+
+	mu.Lock()
+	assert.Equal(t, []string{"user:1"}, expired)
+	mu.Unlock()
+
+	_, ok := c.Get("user:1")
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), c.Stats().Expirations)
+	assert.Equal(t, int64(1), c.NamespaceStats()["user"].Items)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	c.Delete("user:2")
+	assert.Equal(t, int64(0), c.NamespaceStats()["user"].Items)
+}
+
+// mapTier is an OverflowTier in a map, recording the keys put
+type mapTier struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	puts []string
+}
+
+func (m *mapTier) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.data[key]
+
+	return data, ok, nil
+}
+
+func (m *mapTier) Put(key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.data[key] = data
+	m.puts = append(m.puts, key)
+
+	return nil
+}
+
+func (m *mapTier) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.data, key)
+
+	return nil
+}
+
+func (m *mapTier) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clear(m.data)
+
+	return nil
+}
+
+func TestOverflowSpillsLeastRecentlyUsed(t *testing.T) {
+	tier := &mapTier{data: make(map[string][]byte)}
+
+	c := cache.New[string, TestStruct]().WithInterval(50*time.Millisecond).
+		WithNamespaceStats().
+		WithOverflow(tier, 2)
+
+	c.Set("user:1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("user:2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("user:3", TestStruct{Name: "Carol", Age: 40})
+
+	c.Get("user:1")
+	c.Get("user:3")
+
+	go c.Maintain()
+	time.Sleep(80 * time.Millisecond)
+	c.Stop()
+
+	assert.Equal(t, []string{"user:2"}, tier.puts)
+
+	// An entry lost from the tier is a miss, not a zero value
+	assert.NoError(t, tier.Delete("user:2"))
+
+	got, ok := c.Get("user:2")
+	assert.False(t, ok)
+	assert.Zero(t, got)
+	assert.Equal(t, int64(2), c.NamespaceStats()["user"].Items)
+	assert.Equal(t, int64(0), c.Stats().OverflowHits)
+}
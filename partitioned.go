@@ -0,0 +1,344 @@
+package simplecache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PartitionedClient spreads string keys over several caches served with ListenRESP (or Redis servers), so a dataset larger
+// than one node's memory can be cached. Keys are placed on a HashRing, adding or removing a node moves about 1/N of them.
+// While rebalancing a miss on a key's new node is looked up on its previous node and moved over, FinishRebalance ends that.
+type PartitionedClient[T any] struct {
+	Password string
+
+	// TLS connects over TLS when set
+	TLS *tls.Config
+
+	// VirtualNodes is the number of ring points per node, defaults to 100
+	VirtualNodes int
+
+	// Codec must match the servers' codec, defaults to GobCodec
+	Codec Codec[T]
+
+	mu    sync.RWMutex
+	ring  *HashRing
+	prev  *HashRing
+	conns map[string]*respConn
+}
+
+// AddNode adds servers, their keys are taken over from the nodes owning them before
+func (p *PartitionedClient[T]) AddNode(addrs ...string) {
+	p.rebalance(func(ring *HashRing) { ring.Add(addrs...) })
+}
+
+// RemoveNode removes servers, their keys move to the remaining nodes as they are read
+func (p *PartitionedClient[T]) RemoveNode(addrs ...string) {
+	p.rebalance(func(ring *HashRing) { ring.Remove(addrs...) })
+}
+
+// SetNodes replaces the servers with addrs, e.g. as found by DiscoverPeers
+func (p *PartitionedClient[T]) SetNodes(addrs ...string) {
+	p.rebalance(func(ring *HashRing) {
+		ring.Remove(slices.DeleteFunc(ring.Nodes(), func(node string) bool { return slices.Contains(addrs, node) })...)
+		ring.Add(addrs...)
+	})
+}
+
+// FinishRebalance stops looking up keys on the nodes owning them before the last AddNode or RemoveNode
+func (p *PartitionedClient[T]) FinishRebalance() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prev = nil
+}
+
+func (p *PartitionedClient[T]) Nodes() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.ring == nil {
+		return nil
+	}
+
+	return p.ring.Nodes()
+}
+
+// Node returns the server owning key
+func (p *PartitionedClient[T]) Node(key string) string {
+	owner, _ := p.owners(key)
+	return owner
+}
+
+func (p *PartitionedClient[T]) Get(key string) (T, bool, error) {
+	var zero T
+
+	owner, prev := p.owners(key)
+	if owner == "" {
+		return zero, false, errNoNodes
+	}
+
+	data, err := p.conn(owner).get(key)
+	if err != nil || data != nil || prev == "" || prev == owner {
+		return p.decode(data, err)
+	}
+
+	// Still on the node owning it before the ring changed
+	data, err = p.conn(prev).get(key)
+	if err != nil || data == nil {
+		return p.decode(data, err)
+	}
+
+	if err := p.move(key, data, prev, owner); err != nil {
+		return zero, false, err
+	}
+
+	return p.decode(data, nil)
+}
+
+// Set stores value on the key's node, expiring after ttl when positive
+func (p *PartitionedClient[T]) Set(key string, value T, ttl time.Duration) error {
+	data, err := p.codec().Encode(value)
+	if err != nil {
+		return err
+	}
+
+	owner, prev := p.owners(key)
+	if owner == "" {
+		return errNoNodes
+	}
+
+	if err := p.conn(owner).set(key, data, ttl); err != nil {
+		return err
+	}
+
+	// Don't let a stale copy be moved over later
+	if prev != "" && prev != owner {
+		return p.conn(prev).del(key)
+	}
+
+	return nil
+}
+
+func (p *PartitionedClient[T]) Delete(key string) error {
+	owner, prev := p.owners(key)
+	if owner == "" {
+		return errNoNodes
+	}
+
+	if err := p.conn(owner).del(key); err != nil {
+		return err
+	}
+
+	if prev != "" && prev != owner {
+		return p.conn(prev).del(key)
+	}
+
+	return nil
+}
+
+// Close closes the connections, the next command opens new ones
+func (p *PartitionedClient[T]) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for _, conn := range p.conns {
+		if e := conn.close(); e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// move copies a key with its remaining ttl from the node owning it before
+func (p *PartitionedClient[T]) move(key string, data []byte, from, to string) error {
+	ttl, err := p.conn(from).ttl(key)
+	if err != nil {
+		return err
+	}
+
+	// Expired meanwhile
+	if ttl == -2 {
+		return nil
+	}
+
+	if err := p.conn(to).set(key, data, ttl); err != nil {
+		return err
+	}
+
+	return p.conn(from).del(key)
+}
+
+func (p *PartitionedClient[T]) rebalance(change func(*HashRing)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ring == nil {
+		p.ring = NewHashRing(p.VirtualNodes)
+	}
+
+	// A change during a rebalance keeps looking up the nodes from before the first one
+	if p.prev == nil && len(p.ring.points) > 0 {
+		p.prev = p.ring
+	}
+
+	ring := p.ring.clone()
+	change(ring)
+	p.ring = ring
+}
+
+func (p *PartitionedClient[T]) owners(key string) (owner, prev string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.ring == nil {
+		return "", ""
+	}
+
+	if p.prev != nil {
+		prev = p.prev.Node(key)
+	}
+
+	return p.ring.Node(key), prev
+}
+
+func (p *PartitionedClient[T]) conn(addr string) *respConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conns == nil {
+		p.conns = make(map[string]*respConn)
+	}
+
+	conn, ok := p.conns[addr]
+	if !ok {
+		conn = &respConn{addr: addr, password: p.Password, tls: p.TLS}
+		p.conns[addr] = conn
+	}
+
+	return conn
+}
+
+func (p *PartitionedClient[T]) decode(data []byte, err error) (T, bool, error) {
+	var zero T
+	if err != nil || data == nil {
+		return zero, false, err
+	}
+
+	value, err := p.codec().Decode(data)
+
+	return value, err == nil, err
+}
+
+func (p *PartitionedClient[T]) codec() Codec[T] {
+	if p.Codec == nil {
+		return GobCodec[T]{}
+	}
+
+	return p.Codec
+}
+
+var errNoNodes = errors.New("simplecache: partitioned client has no nodes")
+
+// respConn is a lazily dialed connection to one node
+type respConn struct {
+	addr     string
+	password string
+	tls      *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// get returns nil for a missing key
+func (c *respConn) get(key string) ([]byte, error) {
+	reply, err := c.do("GET", key)
+	data, _ := reply.([]byte)
+
+	return data, err
+}
+
+func (c *respConn) set(key string, data []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(data)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+
+	_, err := c.do(args...)
+
+	return err
+}
+
+func (c *respConn) del(key string) error {
+	_, err := c.do("DEL", key)
+	return err
+}
+
+// ttl returns the remaining time to live, 0 without one and -2 for a missing key
+func (c *respConn) ttl(key string) (time.Duration, error) {
+	reply, err := c.do("TTL", key)
+	seconds, _ := reply.(int64)
+
+	switch {
+	case err != nil:
+		return 0, err
+	case seconds == -2:
+		return -2, nil
+	case seconds < 0:
+		return 0, nil
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func (c *respConn) do(args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := dialRedis(c.addr, c.password, 0, c.tls)
+		if err != nil {
+			return nil, err
+		}
+
+		c.conn, c.r, c.w = conn, bufio.NewReader(conn), bufio.NewWriter(conn)
+	}
+
+	reply, err := redisRoundTrip(c.r, c.w, args...)
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+
+		return nil, fmt.Errorf("simplecache: %s %s: %w", c.addr, args[0], err)
+	}
+
+	if err, ok := reply.(RESPError); ok {
+		return nil, fmt.Errorf("simplecache: %s %s: %w", c.addr, args[0], err)
+	}
+
+	return reply, nil
+}
+
+func (c *respConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+
+	return err
+}
@@ -0,0 +1,82 @@
+package simplecache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionedClient(t *testing.T) {
+	caches := make(map[string]*cache.Cache[string, string])
+	var addrs []string
+
+	for range 4 {
+		c := cache.New[string, string]().WithCodec(cache.StringCodec{})
+
+		server, err := c.ListenRESP("127.0.0.1:0")
+		assert.NoError(t, err)
+		defer server.Close()
+
+		caches[server.Addr().String()] = c
+		addrs = append(addrs, server.Addr().String())
+	}
+
+	client := &cache.PartitionedClient[string]{Codec: cache.StringCodec{}}
+	defer client.Close()
+
+	_, _, err := client.Get("key")
+	assert.Error(t, err)
+
+	client.AddNode(addrs[:3]...)
+
+	for i := range 100 {
+		assert.NoError(t, client.Set(fmt.Sprint("key", i), fmt.Sprint("value", i), time.Minute))
+	}
+
+	// Every key lives on its own node only
+	for i := range 100 {
+		key := fmt.Sprint("key", i)
+		for addr, c := range caches {
+			_, ok := c.Get(key)
+			assert.Equal(t, addr == client.Node(key), ok)
+		}
+	}
+
+	for _, addr := range addrs[:3] {
+		assert.NotEmpty(t, caches[addr].GetAll())
+	}
+
+	// Keys taken over by the new node are moved as they are read
+	client.AddNode(addrs[3])
+	for i := range 100 {
+		value, ok, err := client.Get(fmt.Sprint("key", i))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, fmt.Sprint("value", i), value)
+	}
+
+	client.FinishRebalance()
+	assert.NotEmpty(t, caches[addrs[3]].GetAll())
+
+	total := 0
+	for _, c := range caches {
+		total += len(c.GetAll())
+	}
+	assert.Equal(t, 100, total)
+
+	client.SetNodes(addrs[1:]...)
+	assert.ElementsMatch(t, addrs[1:], client.Nodes())
+
+	value, ok, err := client.Get("key1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value1", value)
+
+	assert.NoError(t, client.Delete("key1"))
+	_, ok, err = client.Get("key1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
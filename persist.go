@@ -0,0 +1,357 @@
+package simplecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// entry is a cached item as exported by the persistence features
+type entry[K comparable, T any] struct {
+	Key     K         `json:"key"`
+	Value   T         `json:"value"`
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// entries returns the items that haven't expired yet, in copy-on-write mode without taking the lock
+func (c *Cache[K, T]) entries() []entry[K, T] {
+	if c.copyOnWrite {
+		return entriesOf(*c.snapshot.Load())
+	}
+
+	c.rlock()
+	data := c.frozen()
+	c.RUnlock()
+
+	return entriesOf(data)
+}
+
+// frozen returns a map that won't change under the caller, called with the lock held.
+// Only a shallow copy is made while writers wait, encoding and IO happen after the lock is released.
+func (c *Cache[K, T]) frozen() map[K]Item[T] {
+	if c.copyOnWrite {
+		return c.data.(MapStore[K, T])
+	}
+
+	data := make(map[K]Item[T], c.data.Len())
+	for key, item := range c.data.Iterate {
+		data[key] = item
+	}
+
+	return data
+}
+
+func entriesOf[K comparable, T any](data map[K]Item[T]) []entry[K, T] {
+	now := time.Now()
+
+	res := make([]entry[K, T], 0, len(data))
+	for key, item := range data {
+		if item.expired(now) {
+			continue
+		}
+
+		res = append(res, entry[K, T]{Key: key, Value: item.Value, Expires: item.Expires})
+	}
+
+	return res
+}
+
+// record is an entry with its value encoded by the codec
+type record[K comparable] struct {
+	Key     K
+	Value   []byte
+	Expires time.Time
+}
+
+// restore installs entries directly, as applyRecovered does, skipping the ones that expired in the meantime
+func (c *Cache[K, T]) restore(entries []entry[K, T]) error {
+	now := time.Now()
+
+	var errs []error
+	discarded := 0
+	for _, e := range entries {
+		item := Item[T]{Value: e.Value, Expires: e.Expires}
+		if item.expired(now) {
+			discarded++
+			continue
+		}
+
+		item.Value = c.loaded(e.Key, e.Value)
+		if err := c.applyRecovered(walSet, e.Key, item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	c.reportDiscarded(discarded)
+
+	return errors.Join(errs...)
+}
+
+// Save writes the cached items and their expirations to w, values are encoded by the codec set with WithCodec
+func (c *Cache[K, T]) Save(w io.Writer) error {
+	return c.save(w, c.entries())
+}
+
+// save writes a snapshot followed by its checksum
+func (c *Cache[K, T]) save(w io.Writer, entries []entry[K, T]) error {
+	h := sha256.New()
+	if err := c.encodeSnapshot(io.MultiWriter(w, h), entries); err != nil {
+		return err
+	}
+
+	_, err := w.Write(h.Sum(nil))
+
+	return err
+}
+
+func (c *Cache[K, T]) encodeSnapshot(w io.Writer, entries []entry[K, T]) error {
+	codec := c.valueCodec()
+
+	records := make([]record[K], len(entries))
+	for i, e := range entries {
+		value, err := codec.Encode(c.saving(e.Key, e.Value))
+		if err != nil {
+			return fmt.Errorf("simplecache: encode %v: %w", e.Key, err)
+		}
+
+		records[i] = record[K]{Key: e.Key, Value: value, Expires: e.Expires}
+	}
+
+	// Encrypted snapshots are sealed as a whole
+	var buf bytes.Buffer
+
+	out := w
+	if c.encrypted() {
+		out = &buf
+	}
+
+	var zw io.WriteCloser
+	if c.compression != nil {
+		var err error
+		if zw, err = c.compression.NewWriter(out); err != nil {
+			return err
+		}
+
+		out = zw
+	}
+
+	enc := gob.NewEncoder(out)
+	if err := enc.Encode(c.snapshotHeader()); err != nil {
+		return err
+	}
+
+	if err := enc.Encode(records); err != nil {
+		return err
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	if !c.encrypted() {
+		return nil
+	}
+
+	sealed, err := c.seal(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(sealed)
+
+	return err
+}
+
+// Load adds the items written by Save, expired ones are skipped. Loaded items are installed directly, interceptors, audit and invalidations
+// don't see them and they aren't reported as changes, AfterLoad can rewrite them.
+// Snapshots written for another version of the value type are passed through the migration set with WithMigration.
+// Nothing is loaded from a snapshot failing its checksum, a *CorruptSnapshotError is returned instead.
+func (c *Cache[K, T]) Load(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	data, err = verifyChecksum(data)
+	if err != nil {
+		return err
+	}
+
+	return c.decodeSnapshot(data)
+}
+
+func (c *Cache[K, T]) decodeSnapshot(data []byte) error {
+	data, err := c.open(data)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+
+	if c.compression != nil {
+		zr, err := c.compression.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		r = zr
+	}
+
+	dec := gob.NewDecoder(r)
+
+	var header SnapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+
+	decode, err := c.valueDecoder(header)
+	if err != nil {
+		return err
+	}
+
+	var records []record[K]
+	if err := dec.Decode(&records); err != nil {
+		return err
+	}
+
+	entries := make([]entry[K, T], len(records))
+	for i, rec := range records {
+		value, err := decode(rec.Value)
+		if err != nil {
+			return fmt.Errorf("simplecache: decode %v: %w", rec.Key, err)
+		}
+
+		entries[i] = entry[K, T]{Key: rec.Key, Value: value, Expires: rec.Expires}
+	}
+
+	return c.restore(entries)
+}
+
+// WriteTo implements io.WriterTo, writing the same snapshot as Save
+func (c *Cache[K, T]) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := c.Save(cw)
+
+	return cw.n, err
+}
+
+// ReadFrom implements io.ReaderFrom, loading a snapshot like Load
+func (c *Cache[K, T]) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	err := c.Load(cr)
+
+	return cr.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+
+	return n, err
+}
+
+// SnapshotTarget is where snapshots are saved to and loaded from.
+// Write should only replace the previous snapshot once fn returns without an error,
+// Read should return an error wrapping fs.ErrNotExist when there is no snapshot yet.
+type SnapshotTarget interface {
+	Write(fn func(io.Writer) error) error
+	Read(fn func(io.Reader) error) error
+}
+
+// FileTarget is a SnapshotTarget writing to a file path
+type FileTarget string
+
+func (f FileTarget) Write(fn func(io.Writer) error) error {
+	path := string(f)
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := fn(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (f FileTarget) Read(fn func(io.Reader) error) error {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return fn(file)
+}
+
+func (c *Cache[K, T]) SaveTo(target SnapshotTarget) error {
+	return target.Write(c.Save)
+}
+
+func (c *Cache[K, T]) LoadFrom(target SnapshotTarget) error {
+	return target.Read(c.Load)
+}
+
+// SaveFile saves to path, replacing it only once the snapshot is fully written
+func (c *Cache[K, T]) SaveFile(path string) error {
+	return c.SaveTo(FileTarget(path))
+}
+
+func (c *Cache[K, T]) LoadFile(path string) error {
+	return c.LoadFrom(FileTarget(path))
+}
+
+// MarshalJSON exports the cached items as a list of key, value and expiry objects
+func (c *Cache[K, T]) MarshalJSON() ([]byte, error) {
+	entries := c.entries()
+	for i, e := range entries {
+		entries[i].Value = c.saving(e.Key, e.Value)
+	}
+
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON adds the items exported by MarshalJSON, expired ones are skipped
+func (c *Cache[K, T]) UnmarshalJSON(data []byte) error {
+	var entries []entry[K, T]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	return c.restore(entries)
+}
@@ -0,0 +1,159 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	expires := time.Now().Add(time.Hour).Round(0)
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, expires)
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40}, time.Now().Add(50*time.Millisecond))
+
+	assert.NoError(t, c.SaveFile(path))
+
+	time.Sleep(100 * time.Millisecond)
+
+	restored := cache.New[string, TestStruct]()
+	assert.NoError(t, restored.LoadFile(path))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+
+	item2, ok := restored.Get("item2")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Bob", Age: 25}, item2)
+
+	// Expired by the time it was loaded
+	_, ok = restored.Get("item3")
+	assert.False(t, ok)
+	assert.Equal(t, int64(2), restored.Stats().Items)
+}
+
+func TestLoadBypassesWrites(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.Save(&buf))
+
+	created := 0
+	restored := cache.New[string, TestStruct]().WithImmediateNotifications().
+		InterceptSet(func(key string, value TestStruct) (TestStruct, error) {
+			return value, errors.New("rejected")
+		}).
+		OnCreate(func(items []TestStruct) { created += len(items) })
+	assert.NoError(t, restored.Load(&buf))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+	assert.Zero(t, created)
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	c := cache.New[string, TestStruct]()
+
+	assert.ErrorIs(t, c.LoadFile(filepath.Join(t.TempDir(), "missing.gob")), os.ErrNotExist)
+}
+
+func TestJSON(t *testing.T) {
+	expires := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30}, expires)
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"key":"item1","value":{"Name":"Alice","Age":30},"expires":"2100-01-01T00:00:00Z"}]`, string(data))
+
+	edited := `[
+		{"key":"item1","value":{"Name":"Alice","Age":31}},
+		{"key":"item2","value":{"Name":"Bob","Age":25},"expires":"2000-01-01T00:00:00Z"}
+	]`
+
+	restored := cache.New[string, TestStruct]()
+	assert.NoError(t, json.Unmarshal([]byte(edited), restored))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 31}, item1)
+
+	_, ok = restored.Get("item2")
+	assert.False(t, ok)
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	// Stream through a compressing writer
+	zw := gzip.NewWriter(&buf)
+	_, err := c.WriteTo(zw)
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	zr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+
+	restored := cache.New[string, TestStruct]()
+	n, err := restored.ReadFrom(zr)
+	assert.NoError(t, err)
+	assert.Positive(t, n)
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+
+	var plain bytes.Buffer
+	written, err := c.WriteTo(&plain)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(plain.Len()), written)
+
+	var _ io.WriterTo = c
+	var _ io.ReaderFrom = c
+}
+
+func TestSaveDoesNotBlockWriters(t *testing.T) {
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+
+	// Nobody reads the pipe until the writes are done
+	r, w := io.Pipe()
+	saved := make(chan error, 1)
+	go func() { saved <- c.Save(w) }()
+
+	time.Sleep(20 * time.Millisecond)
+
+	written := make(chan struct{})
+	go func() {
+		c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+		close(written)
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("Set blocked by a running Save")
+	}
+
+	go io.Copy(io.Discard, r)
+	assert.NoError(t, <-saved)
+}
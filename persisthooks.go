@@ -0,0 +1,55 @@
+package simplecache
+
+// DiscardMiddleware is told how many expired items a load skipped
+type DiscardMiddleware func(discarded int)
+
+// PersistMiddleware rewrites a value on its way to or from disk
+type PersistMiddleware[K comparable, T any] func(key K, value T) T
+
+// BeforeSave rewrites values before they are written to snapshots, the write-ahead log or JSON, e.g. to strip sensitive fields
+func (c *Cache[K, T]) BeforeSave(m PersistMiddleware[K, T]) *Cache[K, T] {
+	c.beforeSave = append(c.beforeSave, m)
+
+	return c
+}
+
+// AfterLoad rewrites values read back from snapshots, the write-ahead log or JSON, e.g. to re-derive computed fields
+func (c *Cache[K, T]) AfterLoad(m PersistMiddleware[K, T]) *Cache[K, T] {
+	c.afterLoad = append(c.afterLoad, m)
+
+	return c
+}
+
+func (c *Cache[K, T]) saving(key K, value T) T {
+	for _, m := range c.beforeSave {
+		value = m(key, value)
+	}
+
+	return value
+}
+
+func (c *Cache[K, T]) loaded(key K, value T) T {
+	for _, m := range c.afterLoad {
+		value = m(key, value)
+	}
+
+	return value
+}
+
+// OnLoadDiscard is triggered when a snapshot, delta, log replay, LoadMany or warmup skipped items that expired before they were loaded.
+// Register it before WithWAL or WithPersistence to hear about what they load while the cache is built.
+func (c *Cache[K, T]) OnLoadDiscard(m DiscardMiddleware) *Cache[K, T] {
+	c.discardMiddlewares = append(c.discardMiddlewares, m)
+
+	return c
+}
+
+func (c *Cache[K, T]) reportDiscarded(discarded int) {
+	if discarded == 0 {
+		return
+	}
+
+	for _, m := range c.discardMiddlewares {
+		c.safely(func() { m(discarded) })
+	}
+}
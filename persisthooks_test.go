@@ -0,0 +1,90 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistHooks(t *testing.T) {
+	// Age stands in for a sensitive field, it is dropped on save and derived again on load
+	strip := func(key string, value TestStruct) TestStruct {
+		value.Age = 0
+		return value
+	}
+
+	derive := func(key string, value TestStruct) TestStruct {
+		value.Age = len(value.Name)
+		return value
+	}
+
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]().BeforeSave(strip)
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.Save(&buf))
+
+	// The cache itself is untouched
+	item1, _ := c.Get("item1")
+	assert.Equal(t, 30, item1.Age)
+
+	restored := cache.New[string, TestStruct]()
+	assert.NoError(t, restored.Load(bytes.NewReader(buf.Bytes())))
+
+	item1, _ = restored.Get("item1")
+	assert.Equal(t, TestStruct{Name: "Alice"}, item1)
+
+	restored = cache.New[string, TestStruct]().AfterLoad(derive)
+	assert.NoError(t, restored.Load(bytes.NewReader(buf.Bytes())))
+
+	item1, _ = restored.Get("item1")
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 5}, item1)
+
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c = cache.New[string, TestStruct]().BeforeSave(strip).WithWAL(path)
+	assert.NoError(t, c.Open())
+	c.Set("item1", TestStruct{Name: "Bob", Age: 25})
+	assert.NoError(t, c.CloseWAL())
+
+	recovered := cache.New[string, TestStruct]().AfterLoad(derive).WithWAL(path)
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	item1, _ = recovered.Get("item1")
+	assert.Equal(t, TestStruct{Name: "Bob", Age: 3}, item1)
+}
+
+func TestLoadDiscard(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30}, time.Now().Add(50*time.Millisecond))
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, time.Now().Add(250*time.Millisecond))
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40})
+	assert.NoError(t, c.Save(&buf))
+
+	time.Sleep(100 * time.Millisecond)
+
+	discarded := 0
+
+	restored := cache.New[string, TestStruct]().WithInterval(50 * time.Millisecond).
+		OnLoadDiscard(func(n int) { discarded += n })
+	assert.NoError(t, restored.Load(&buf))
+
+	assert.Equal(t, 1, discarded)
+	assert.Equal(t, int64(2), restored.Stats().Items)
+
+	// The absolute expiry survived the round trip
+	go restored.Maintain()
+	time.Sleep(250 * time.Millisecond)
+	restored.Stop()
+
+	_, ok := restored.Get("item2")
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), restored.Stats().Items)
+}
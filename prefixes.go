@@ -0,0 +1,55 @@
+package simplecache
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// PrefixCount is the share of the cache held by one key prefix, see KeyPrefixes
+type PrefixCount struct {
+	Prefix      string `json:"prefix"`
+	Items       int    `json:"items"`
+	MemoryBytes int    `json:"memoryBytes"`
+}
+
+// KeyPrefixes groups the items by the first depth segments of their keys split on delimiter, largest group first,
+// to see which areas of a shared cache take the space. The last segment is never part of the prefix, with ":" and
+// depth 2 "tenant:user:1" counts under "tenant:user", "tenant:7" under "tenant" and "global" under "".
+func (c *Cache[K, T]) KeyPrefixes(delimiter string, depth int) []PrefixCount {
+	groups := make(map[string]*PrefixCount)
+
+	for _, e := range c.entries() {
+		prefix := keyPrefix(keyString(e.Key), delimiter, depth)
+
+		group, ok := groups[prefix]
+		if !ok {
+			group = &PrefixCount{Prefix: prefix}
+			groups[prefix] = group
+		}
+
+		group.Items++
+		group.MemoryBytes += c.memorySize(e.Key, Item[T]{Value: e.Value, Expires: e.Expires})
+	}
+
+	counts := make([]PrefixCount, 0, len(groups))
+	for _, group := range groups {
+		counts = append(counts, *group)
+	}
+
+	slices.SortFunc(counts, func(a, b PrefixCount) int {
+		return cmp.Or(cmp.Compare(b.Items, a.Items), cmp.Compare(a.Prefix, b.Prefix))
+	})
+
+	return counts
+}
+
+func keyPrefix(key, delimiter string, depth int) string {
+	if delimiter == "" || depth <= 0 {
+		return ""
+	}
+
+	segments := strings.Split(key, delimiter)
+
+	return strings.Join(segments[:min(depth, len(segments)-1)], delimiter)
+}
@@ -0,0 +1,36 @@
+package simplecache_test
+
+import (
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPrefixes(t *testing.T) {
+	c := cache.New[string, TestStruct]()
+	c.Set("acme:user:1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("acme:user:2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("acme:order:1", TestStruct{Name: "Carol", Age: 41})
+	c.Set("acme:7", TestStruct{Name: "Dave", Age: 52})
+	c.Set("globex/user/1", TestStruct{Name: "Eve", Age: 19})
+	c.Set("settings", TestStruct{Name: "Frank", Age: 60})
+
+	prefixes := c.KeyPrefixes(":", 2)
+
+	var names []string
+	var items []int
+	for _, p := range prefixes {
+		names = append(names, p.Prefix)
+		items = append(items, p.Items)
+		assert.Positive(t, p.MemoryBytes)
+	}
+
+	assert.Equal(t, []string{"", "acme:user", "acme", "acme:order"}, names)
+	assert.Equal(t, []int{2, 2, 1, 1}, items)
+
+	top := c.KeyPrefixes(":", 1)
+	assert.Equal(t, cache.PrefixCount{Prefix: "acme", Items: 4, MemoryBytes: top[0].MemoryBytes}, top[0])
+
+	assert.Equal(t, "globex/user", c.KeyPrefixes("/", 2)[1].Prefix)
+}
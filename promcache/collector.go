@@ -0,0 +1,109 @@
+// Package promcache exposes the metrics of a simplecache to Prometheus, register a Collector with a prometheus.Registerer.
+package promcache
+
+import (
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector reading the metrics of a cache at scrape time
+type Collector[K comparable, T any] struct {
+	cache *cache.Cache[K, T]
+
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	items     *prometheus.Desc
+	memory    *prometheus.Desc
+	deletes   *prometheus.Desc
+	evictions *prometheus.Desc
+	expired   *prometheus.Desc
+
+	// Janitor health, alerting on missed ticks catches a dead Maintain
+	lastTick    *prometheus.Desc
+	missedTicks *prometheus.Desc
+
+	// Per key namespace with WithNamespaceStats, labelled key_namespace
+	namespaceHits   *prometheus.Desc
+	namespaceMisses *prometheus.Desc
+	namespaceItems  *prometheus.Desc
+	namespaceMemory *prometheus.Desc
+
+	// Tick durations are only reported per tick
+	ticks prometheus.Histogram
+}
+
+// NewCollector follows the tick durations of c from now on, namespace prefixes every metric name (e.g. "myapp_cache")
+func NewCollector[K comparable, T any](c *cache.Cache[K, T], namespace string) *Collector[K, T] {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+	}
+
+	col := &Collector[K, T]{
+		cache:     c,
+		hits:      desc("hits_total", "Lookups that found an item."),
+		misses:    desc("misses_total", "Lookups that found no item."),
+		items:     desc("items", "Items currently cached."),
+		memory:    desc("memory_bytes", "Estimated memory used by the cached items."),
+		deletes:   desc("deletes_total", "Items removed by Delete and DeleteAll."),
+		evictions: desc("evictions_total", "Items evicted from memory to the overflow tier."),
+		expired:   desc("expired_total", "Items removed by Maintain after expiring."),
+
+		lastTick:    desc("last_tick_timestamp_seconds", "Start of the latest Maintain tick."),
+		missedTicks: desc("missed_ticks", "Maintain ticks overdue since the latest one."),
+
+		namespaceHits:   desc("namespace_hits_total", "Lookups that found an item, by key namespace.", "key_namespace"),
+		namespaceMisses: desc("namespace_misses_total", "Lookups that found no item, by key namespace.", "key_namespace"),
+		namespaceItems:  desc("namespace_items", "Items currently cached, by key namespace.", "key_namespace"),
+		namespaceMemory: desc("namespace_memory_bytes", "Estimated memory used by the cached items, by key namespace.", "key_namespace"),
+
+		ticks: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tick_duration_seconds",
+			Help:      "Duration of the Maintain ticks.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}),
+	}
+
+	c.OnTickStats(func(stats cache.TickStats) {
+		col.ticks.Observe(stats.Duration.Seconds())
+	})
+
+	return col
+}
+
+func (col *Collector[K, T]) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		col.hits, col.misses, col.items, col.memory, col.deletes, col.evictions, col.expired, col.lastTick, col.missedTicks,
+		col.namespaceHits, col.namespaceMisses, col.namespaceItems, col.namespaceMemory,
+	} {
+		ch <- desc
+	}
+
+	col.ticks.Describe(ch)
+}
+
+func (col *Collector[K, T]) Collect(ch chan<- prometheus.Metric) {
+	stats := col.cache.Stats()
+
+	ch <- prometheus.MustNewConstMetric(col.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(col.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(col.items, prometheus.GaugeValue, float64(stats.Items))
+	ch <- prometheus.MustNewConstMetric(col.memory, prometheus.GaugeValue, float64(stats.MemoryBytes))
+	ch <- prometheus.MustNewConstMetric(col.deletes, prometheus.CounterValue, float64(stats.Deletes))
+	ch <- prometheus.MustNewConstMetric(col.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(col.expired, prometheus.CounterValue, float64(stats.Expirations))
+
+	if !stats.LastTick.IsZero() {
+		ch <- prometheus.MustNewConstMetric(col.lastTick, prometheus.GaugeValue, float64(stats.LastTick.UnixNano())/1e9)
+		ch <- prometheus.MustNewConstMetric(col.missedTicks, prometheus.GaugeValue, float64(stats.MissedTicks))
+	}
+
+	for namespace, stats := range col.cache.NamespaceStats() {
+		ch <- prometheus.MustNewConstMetric(col.namespaceHits, prometheus.CounterValue, float64(stats.Hits), namespace)
+		ch <- prometheus.MustNewConstMetric(col.namespaceMisses, prometheus.CounterValue, float64(stats.Misses), namespace)
+		ch <- prometheus.MustNewConstMetric(col.namespaceItems, prometheus.GaugeValue, float64(stats.Items), namespace)
+		ch <- prometheus.MustNewConstMetric(col.namespaceMemory, prometheus.GaugeValue, float64(stats.MemoryBytes), namespace)
+	}
+
+	col.ticks.Collect(ch)
+}
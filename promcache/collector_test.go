@@ -0,0 +1,68 @@
+package promcache_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/kamludwinski2/simplecache/promcache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	ticks := make(chan cache.TickStats, 16)
+
+	c := cache.New[string, int]().WithInterval(50 * time.Millisecond).WithNamespaceStats()
+	collector := promcache.NewCollector(c, "app_cache")
+	c.OnTickStats(func(stats cache.TickStats) { ticks <- stats })
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, registry.Register(collector))
+
+	c.Set("acme:item1", 1)
+	c.Set("acme:item2", 2, time.Now().Add(10*time.Millisecond))
+	c.Get("acme:item1")
+	c.Get("missing")
+
+	go c.Maintain()
+	defer c.Stop()
+	<-ticks
+
+	expected := `
+# HELP app_cache_expired_total Items removed by Maintain after expiring.
+# TYPE app_cache_expired_total counter
+app_cache_expired_total 1
+# HELP app_cache_hits_total Lookups that found an item.
+# TYPE app_cache_hits_total counter
+app_cache_hits_total 1
+# HELP app_cache_items Items currently cached.
+# TYPE app_cache_items gauge
+app_cache_items 1
+# HELP app_cache_misses_total Lookups that found no item.
+# TYPE app_cache_misses_total counter
+app_cache_misses_total 1
+# HELP app_cache_namespace_items Items currently cached, by key namespace.
+# TYPE app_cache_namespace_items gauge
+app_cache_namespace_items{key_namespace=""} 0
+app_cache_namespace_items{key_namespace="acme"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"app_cache_expired_total", "app_cache_hits_total", "app_cache_items", "app_cache_misses_total", "app_cache_namespace_items"))
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+
+	assert.Contains(t, names, "app_cache_tick_duration_seconds")
+	assert.Contains(t, names, "app_cache_memory_bytes")
+	assert.Contains(t, names, "app_cache_evictions_total")
+	assert.Contains(t, names, "app_cache_deletes_total")
+	assert.Contains(t, names, "app_cache_missed_ticks")
+}
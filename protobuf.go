@@ -0,0 +1,377 @@
+package simplecache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ProtobufContentType selects the protobuf wire format described in proto/simplecache.proto
+const ProtobufContentType = "application/x-protobuf"
+
+const (
+	protoVarint = 0
+	protoBytes  = 2
+)
+
+// maxProtoMessage bounds a length-delimited message read from a stream
+const maxProtoMessage = 64 << 20
+
+var errProtoMessage = errors.New("simplecache: malformed protobuf message")
+
+func appendProtoTag(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+// appendProtoVarint leaves out zero values as proto3 does
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	return binary.AppendUvarint(appendProtoTag(b, field, protoVarint), v)
+}
+
+// appendProtoBytes writes a length-delimited field, embedded messages included
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(appendProtoTag(b, field, protoBytes), uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendProtoTime(b []byte, field int, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+
+	return appendProtoVarint(b, field, uint64(t.UnixNano()))
+}
+
+// protoField is a decoded field, varint holds varint values and bytes length-delimited ones
+type protoField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+func (f protoField) time() time.Time {
+	if f.varint == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, int64(f.varint))
+}
+
+// parseProto calls fn for every field of a message, skipping fixed-size ones
+func parseProto(b []byte, fn func(protoField) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errProtoMessage
+		}
+		b = b[n:]
+
+		f := protoField{num: int(tag >> 3)}
+		switch tag & 7 {
+		case protoVarint:
+			if f.varint, n = binary.Uvarint(b); n <= 0 {
+				return errProtoMessage
+			}
+			b = b[n:]
+
+		case protoBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errProtoMessage
+			}
+
+			f.bytes, b = b[n:n+int(size)], b[n+int(size):]
+
+		case 1:
+			if len(b) < 8 {
+				return errProtoMessage
+			}
+			b = b[8:]
+			continue
+
+		case 5:
+			if len(b) < 4 {
+				return errProtoMessage
+			}
+			b = b[4:]
+			continue
+
+		default:
+			return errProtoMessage
+		}
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeProtoDelimited writes a message preceded by its varint length
+func writeProtoDelimited(w io.Writer, msg []byte) error {
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(len(msg)))); err != nil {
+		return err
+	}
+
+	_, err := w.Write(msg)
+
+	return err
+}
+
+func readProtoDelimited(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > maxProtoMessage {
+		return nil, errProtoMessage
+	}
+
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// protoKey encodes a key, string keys as their bytes so other languages can read them
+func protoKey[K comparable](key K) ([]byte, error) {
+	if s, ok := any(key).(string); ok {
+		return []byte(s), nil
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(&key)
+
+	return buf.Bytes(), err
+}
+
+func parseProtoKey[K comparable](data []byte) (K, error) {
+	var key K
+	if _, ok := any(key).(string); ok {
+		return any(string(data)).(K), nil
+	}
+
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&key)
+
+	return key, err
+}
+
+// protoItem encodes an Item message, value is already encoded by the codec
+func protoItem[K comparable](key K, value []byte, expires time.Time) ([]byte, error) {
+	k, err := protoKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := appendProtoBytes(nil, 1, k)
+	msg = appendProtoBytes(msg, 2, value)
+
+	return appendProtoTime(msg, 3, expires), nil
+}
+
+func parseProtoItem[K comparable](msg []byte) (K, []byte, time.Time, error) {
+	var key K
+	var value []byte
+	var expires time.Time
+
+	err := parseProto(msg, func(f protoField) error {
+		var err error
+		switch f.num {
+		case 1:
+			key, err = parseProtoKey[K](f.bytes)
+		case 2:
+			value = f.bytes
+		case 3:
+			expires = f.time()
+		}
+
+		return err
+	})
+
+	return key, value, expires, err
+}
+
+// protoChange encodes a Change message, value and previous are already encoded by the codec
+func protoChange[K comparable](seq uint64, t EventType, key K, value, previous []byte, at, expires time.Time) ([]byte, error) {
+	k, err := protoKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := appendProtoVarint(nil, 1, seq)
+	msg = appendProtoVarint(msg, 2, uint64(t))
+	msg = appendProtoBytes(msg, 3, k)
+
+	if value != nil {
+		msg = appendProtoBytes(msg, 4, value)
+	}
+
+	if previous != nil {
+		msg = appendProtoBytes(msg, 5, previous)
+	}
+
+	msg = appendProtoTime(msg, 6, at)
+
+	return appendProtoTime(msg, 7, expires), nil
+}
+
+// encodeProtoFrame encodes a replication frame as a Frame message
+func encodeProtoFrame[K comparable](frame replicationFrame[K]) ([]byte, error) {
+	switch frame.Kind {
+	case frameItem:
+		item, err := protoItem(frame.Key, frame.Value, frame.Expires)
+		return appendProtoBytes(nil, 1, item), err
+
+	case frameSnapshotEnd:
+		// Set members of a oneof are written even when zero
+		return binary.AppendUvarint(appendProtoTag(nil, 2, protoVarint), frame.Seq), nil
+
+	case frameEvent:
+		change, err := protoChange(frame.Seq, frame.Type, frame.Key, frame.Value, nil, time.Time{}, frame.Expires)
+		return appendProtoBytes(nil, 3, change), err
+	}
+
+	return nil, fmt.Errorf("simplecache: unknown frame kind %d", frame.Kind)
+}
+
+func decodeProtoFrame[K comparable](msg []byte) (replicationFrame[K], error) {
+	var frame replicationFrame[K]
+
+	err := parseProto(msg, func(f protoField) error {
+		var err error
+		switch f.num {
+		case 1:
+			frame.Kind = frameItem
+			frame.Key, frame.Value, frame.Expires, err = parseProtoItem[K](f.bytes)
+
+		case 2:
+			frame.Kind = frameSnapshotEnd
+			frame.Seq = f.varint
+
+		case 3:
+			frame.Kind = frameEvent
+			err = parseProto(f.bytes, func(f protoField) error {
+				var err error
+				switch f.num {
+				case 1:
+					frame.Seq = f.varint
+				case 2:
+					frame.Type = EventType(f.varint)
+				case 3:
+					frame.Key, err = parseProtoKey[K](f.bytes)
+				case 4:
+					frame.Value = f.bytes
+				case 7:
+					frame.Expires = f.time()
+				}
+
+				return err
+			})
+		}
+
+		return err
+	})
+
+	return frame, err
+}
+
+// MarshalChangeSet encodes a batch of changes as a ChangeSet message of proto/simplecache.proto, e.g. in OnChangesE
+// handlers forwarding changes to services in other languages
+func (c *Cache[K, T]) MarshalChangeSet(changes ChangeSet[K, T]) ([]byte, error) {
+	var msg []byte
+	for _, event := range eventsOf(changes, time.Now()) {
+		var value, previous []byte
+		var err error
+
+		if event.Type == EventCreated || event.Type == EventUpdated {
+			if value, err = c.valueCodec().Encode(event.Value); err != nil {
+				return nil, err
+			}
+		}
+
+		if event.Type == EventUpdated {
+			if previous, err = c.valueCodec().Encode(event.Previous); err != nil {
+				return nil, err
+			}
+		}
+
+		change, err := protoChange(event.Seq, event.Type, event.Key, value, previous, event.Time, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+
+		msg = appendProtoBytes(msg, 1, change)
+	}
+
+	return msg, nil
+}
+
+// SaveProto writes the live items as a Snapshot message of proto/simplecache.proto, readable without Go.
+// Unlike Save it has no header, checksum, compression or encryption.
+func (c *Cache[K, T]) SaveProto(w io.Writer) error {
+	var msg []byte
+	for _, e := range c.entries() {
+		value, err := c.valueCodec().Encode(e.Value)
+		if err != nil {
+			return err
+		}
+
+		item, err := protoItem(e.Key, value, e.Expires)
+		if err != nil {
+			return err
+		}
+
+		msg = appendProtoBytes(msg, 1, item)
+	}
+
+	_, err := w.Write(msg)
+
+	return err
+}
+
+// LoadProto reads a snapshot written by SaveProto, skipping expired items
+func (c *Cache[K, T]) LoadProto(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var entries []entry[K, T]
+	err = parseProto(data, func(f protoField) error {
+		if f.num != 1 {
+			return nil
+		}
+
+		key, data, expires, err := parseProtoItem[K](f.bytes)
+		if err != nil {
+			return err
+		}
+
+		value, err := c.valueCodec().Decode(data)
+		if err != nil {
+			return fmt.Errorf("simplecache: decode %v: %w", key, err)
+		}
+
+		entries = append(entries, entry[K, T]{Key: key, Value: value, Expires: expires})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.restore(entries)
+}
@@ -0,0 +1,95 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveProto(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{})
+	c.Set("key", "v")
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.SaveProto(&buf))
+
+	// Snapshot{items: [Item{key: "key", value: "v"}]}
+	assert.Equal(t, []byte{0x0a, 0x08, 0x0a, 0x03, 'k', 'e', 'y', 0x12, 0x01, 'v'}, buf.Bytes())
+
+	restored := cache.New[string, string]().WithCodec(cache.StringCodec{})
+	assert.NoError(t, restored.LoadProto(&buf))
+
+	value, ok := restored.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "v", value)
+}
+
+func TestSaveProtoNonStringKeys(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+
+	c := cache.New[int, TestStruct]()
+	c.Set(1, TestStruct{Name: "Alice", Age: 30}, expires)
+	c.Set(2, TestStruct{Name: "Bob", Age: 40}, time.Now().Add(50*time.Millisecond))
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.SaveProto(&buf))
+
+	time.Sleep(60 * time.Millisecond)
+
+	restored := cache.New[int, TestStruct]()
+	assert.NoError(t, restored.LoadProto(&buf))
+
+	value, ok := restored.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, value)
+
+	_, ok = restored.Get(2)
+	assert.False(t, ok)
+}
+
+func TestMarshalChangeSet(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{})
+
+	msg, err := c.MarshalChangeSet(cache.ChangeSet[string, string]{
+		Deleted: []cache.Change[string, string]{{Seq: 3, Key: "k"}},
+	})
+	assert.NoError(t, err)
+
+	// ChangeSet{changes: [Change{seq: 3, type: DELETED, key: "k", time: ...}]}
+	assert.Equal(t, []byte{0x0a}, msg[:1])
+	assert.Equal(t, []byte{0x08, 0x03, 0x10, 0x02, 0x1a, 0x01, 'k', 0x30}, msg[2:10])
+}
+
+func TestReplicationProtobuf(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{})
+	c.Set("key", "v")
+
+	server := httptest.NewServer(c.ReplicationHandler())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept", cache.ProtobufContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, cache.ProtobufContentType, resp.Header.Get("Content-Type"))
+
+	// Frame{item: Item{key: "key", value: "v"}}, then Frame{snapshot_end: 0}
+	frames := make([]byte, 13)
+	_, err = io.ReadFull(bufio.NewReader(resp.Body), frames)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x0a, 0x0a, 0x08, 0x0a, 0x03, 'k', 'e', 'y', 0x12, 0x01, 'v', 0x02, 0x10}, frames)
+}
@@ -0,0 +1,230 @@
+// Package raftcache is an opt-in strongly consistent mode for simplecache, writes go through a hashicorp/raft log
+// replicated to every member, so a small cluster serves identical contents.
+package raftcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/hashicorp/raft"
+	cache "github.com/kamludwinski2/simplecache"
+)
+
+const defaultApplyTimeout = 5 * time.Second
+
+type op uint8
+
+const (
+	opSet op = iota + 1
+	opDelete
+	opDeleteAll
+)
+
+// command is a write as stored in the Raft log
+type command[K comparable] struct {
+	Op      op
+	Key     K
+	Value   []byte
+	Expires time.Time
+}
+
+// Config is what a member needs besides its local cache, the stores and transport come from raft or its adapters (e.g. raft-boltdb)
+type Config[T any] struct {
+	Raft      *raft.Config
+	Logs      raft.LogStore
+	Stable    raft.StableStore
+	Snapshots raft.SnapshotStore
+	Transport raft.Transport
+
+	// Codec encodes values in the log, defaults to simplecache.GobCodec
+	Codec cache.Codec[T]
+
+	// ApplyTimeout bounds how long a write waits to be committed, defaults to 5s
+	ApplyTimeout time.Duration
+}
+
+// Cache is a member of a Raft cluster holding the same cache contents as the others.
+// Writes are accepted by the leader only and applied by every member in log order, reads are served from the local copy.
+// The local cache must only be written through Cache, expirations are absolute times and so expire on all members alike.
+type Cache[K comparable, T any] struct {
+	local   *cache.Cache[K, T]
+	raft    *raft.Raft
+	codec   cache.Codec[T]
+	timeout time.Duration
+}
+
+// New starts a member applying the log to local
+func New[K comparable, T any](local *cache.Cache[K, T], config Config[T]) (*Cache[K, T], error) {
+	c := &Cache[K, T]{local: local, codec: config.Codec, timeout: config.ApplyTimeout}
+
+	if c.codec == nil {
+		c.codec = cache.GobCodec[T]{}
+	}
+
+	if c.timeout <= 0 {
+		c.timeout = defaultApplyTimeout
+	}
+
+	r, err := raft.NewRaft(config.Raft, (*fsm[K, T])(c), config.Logs, config.Stable, config.Snapshots, config.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	c.raft = r
+
+	return c, nil
+}
+
+// Bootstrap forms a new cluster of servers, called once on one member of a fresh cluster
+func (c *Cache[K, T]) Bootstrap(servers []raft.Server) error {
+	return c.raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error()
+}
+
+// Raft gives access to the underlying node, e.g. for adding voters or checking the leader
+func (c *Cache[K, T]) Raft() *raft.Raft {
+	return c.raft
+}
+
+func (c *Cache[K, T]) Get(key K) (T, bool) {
+	return c.local.Get(key)
+}
+
+func (c *Cache[K, T]) GetAll() []T {
+	return c.local.GetAll()
+}
+
+// Set replicates the write and returns once a majority committed it and it was applied here,
+// followers return raft.ErrNotLeader
+func (c *Cache[K, T]) Set(key K, value T, expires ...time.Time) error {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	cmd := command[K]{Op: opSet, Key: key, Value: data}
+	if len(expires) > 0 {
+		cmd.Expires = expires[0]
+	}
+
+	return c.apply(cmd)
+}
+
+func (c *Cache[K, T]) Delete(key K) error {
+	return c.apply(command[K]{Op: opDelete, Key: key})
+}
+
+func (c *Cache[K, T]) DeleteAll() error {
+	return c.apply(command[K]{Op: opDeleteAll})
+}
+
+// Shutdown stops the member, the local cache keeps its contents
+func (c *Cache[K, T]) Shutdown() error {
+	return c.raft.Shutdown().Error()
+}
+
+func (c *Cache[K, T]) apply(cmd command[K]) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cmd); err != nil {
+		return err
+	}
+
+	future := c.raft.Apply(buf.Bytes(), c.timeout)
+	if err := future.Error(); err != nil {
+		return err
+	}
+
+	// Errors of the local write, e.g. a failing WAL append
+	if err, ok := future.Response().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// fsm applies the log to the local cache on behalf of a Cache. Entries go straight to the store, so interceptors,
+// persistence hooks and invalidations of the local cache can't make members diverge, and aren't reported as changes.
+type fsm[K comparable, T any] Cache[K, T]
+
+func (f *fsm[K, T]) Apply(log *raft.Log) any {
+	var cmd command[K]
+	if err := gob.NewDecoder(bytes.NewReader(log.Data)).Decode(&cmd); err != nil {
+		return err
+	}
+
+	switch cmd.Op {
+	case opSet:
+		value, err := f.codec.Decode(cmd.Value)
+		if err != nil {
+			return err
+		}
+
+		return f.local.ApplyReplicated(cmd.Key, cache.Item[T]{Value: value, Expires: cmd.Expires}, false)
+
+	case opDelete:
+		return f.local.ApplyReplicated(cmd.Key, cache.Item[T]{}, true)
+
+	case opDeleteAll:
+		return f.local.ResetReplicated(nil)
+	}
+
+	return nil
+}
+
+// Snapshot encodes the raw local items right away with the codec of the log, Apply waits meanwhile so the snapshot matches the log index
+func (f *fsm[K, T]) Snapshot() (raft.FSMSnapshot, error) {
+	items := f.local.Items()
+
+	entries := make([]command[K], 0, len(items))
+	for key, item := range items {
+		data, err := f.codec.Encode(item.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, command[K]{Op: opSet, Key: key, Value: data, Expires: item.Expires})
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entries); err != nil {
+		return nil, err
+	}
+
+	return snapshot(buf.Bytes()), nil
+}
+
+// Restore replaces the local contents with a snapshot
+func (f *fsm[K, T]) Restore(r io.ReadCloser) error {
+	defer r.Close()
+
+	var entries []command[K]
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return err
+	}
+
+	items := make(map[K]cache.Item[T], len(entries))
+	for _, e := range entries {
+		value, err := f.codec.Decode(e.Value)
+		if err != nil {
+			return err
+		}
+
+		items[e.Key] = cache.Item[T]{Value: value, Expires: e.Expires}
+	}
+
+	return f.local.ResetReplicated(items)
+}
+
+type snapshot []byte
+
+func (s snapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := sink.Write(s); err != nil {
+		return errors.Join(err, sink.Cancel())
+	}
+
+	return sink.Close()
+}
+
+func (s snapshot) Release() {}
@@ -0,0 +1,113 @@
+package raftcache_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/kamludwinski2/simplecache/raftcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster(t *testing.T) {
+	var members []*raftcache.Cache[string, int]
+	var transports []*raft.InmemTransport
+	var servers []raft.Server
+
+	// Local hooks rewriting values must not make a member diverge
+	doubling := func() *cache.Cache[string, int] {
+		return cache.New[string, int]().
+			InterceptSet(func(_ string, value int) (int, error) { return value * 2, nil }).
+			BeforeSave(func(string, int) int { return 0 })
+	}
+
+	join := func(i int, local *cache.Cache[string, int]) (*raftcache.Cache[string, int], raft.Server) {
+		addr, transport := raft.NewInmemTransport("")
+
+		for _, other := range transports {
+			transport.Connect(other.LocalAddr(), other)
+			other.Connect(addr, transport)
+		}
+
+		transports = append(transports, transport)
+
+		config := raft.DefaultConfig()
+		config.LocalID = raft.ServerID(fmt.Sprint("node", i))
+		config.Logger = hclog.New(&hclog.LoggerOptions{Output: io.Discard})
+		config.HeartbeatTimeout = 50 * time.Millisecond
+		config.ElectionTimeout = 50 * time.Millisecond
+		config.LeaderLeaseTimeout = 50 * time.Millisecond
+		config.TrailingLogs = 1
+
+		member, err := raftcache.New(local, raftcache.Config[int]{
+			Raft:      config,
+			Logs:      raft.NewInmemStore(),
+			Stable:    raft.NewInmemStore(),
+			Snapshots: raft.NewInmemSnapshotStore(),
+			Transport: transport,
+		})
+		assert.NoError(t, err)
+		t.Cleanup(func() { member.Shutdown() })
+
+		return member, raft.Server{ID: config.LocalID, Address: addr}
+	}
+
+	for i := range 3 {
+		local := cache.New[string, int]()
+		if i == 1 {
+			local = doubling()
+		}
+
+		member, server := join(i, local)
+		members = append(members, member)
+		servers = append(servers, server)
+	}
+
+	assert.NoError(t, members[0].Bootstrap(servers))
+
+	var leader *raftcache.Cache[string, int]
+	assert.Eventually(t, func() bool {
+		for _, member := range members {
+			if member.Raft().State() == raft.Leader {
+				leader = member
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, leader.Set("item1", 1))
+	assert.NoError(t, leader.Set("item2", 2))
+	assert.NoError(t, leader.Delete("item2"))
+
+	for _, member := range members {
+		if member != leader {
+			assert.ErrorIs(t, member.Set("item3", 3), raft.ErrNotLeader)
+		}
+
+		assert.Eventually(t, func() bool {
+			value, ok := member.Get("item1")
+			_, deleted := member.Get("item2")
+
+			return ok && value == 1 && !deleted
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// A member joining after the log was compacted is restored from a snapshot of the raw items
+	assert.NoError(t, leader.Raft().Snapshot().Error())
+
+	member, server := join(3, doubling())
+	assert.NoError(t, leader.Raft().AddVoter(server.ID, server.Address, 0, 5*time.Second).Error())
+
+	assert.Eventually(t, func() bool {
+		value, ok := member.Get("item1")
+		_, deleted := member.Get("item2")
+
+		return ok && value == 1 && !deleted
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,99 @@
+package simplecache
+
+import (
+	"sync"
+	"time"
+)
+
+// Samples are kept at this resolution, for the rates of the last 1 and 5 minutes
+const (
+	rateResolution = 5 * time.Second
+	rateRetention  = 5 * time.Minute
+)
+
+type rateSample struct {
+	at   time.Time
+	hits int64
+	sets int64
+}
+
+// rates remembers counter samples taken by Maintain ticks
+type rates struct {
+	mu      sync.Mutex
+	samples []rateSample
+}
+
+// observe records a sample unless the latest one is more recent than the resolution
+func (r *rates) observe(sample rateSample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := len(r.samples); n > 0 && sample.at.Sub(r.samples[n-1].at) < rateResolution {
+		return
+	}
+
+	r.samples = append(r.samples, sample)
+
+	// Keeping the newest sample older than the retention so the 5m window stays covered
+	drop := 0
+	for drop+1 < len(r.samples) && sample.at.Sub(r.samples[drop+1].at) >= rateRetention {
+		drop++
+	}
+
+	r.samples = append(r.samples[:0], r.samples[drop:]...)
+}
+
+// reset forgets the samples, they were taken of counters since zeroed
+func (r *rates) reset() {
+	r.mu.Lock()
+	r.samples = nil
+	r.mu.Unlock()
+}
+
+// perSecond returns the hits and sets per second from the newest sample at least window old, or the oldest one
+func (r *rates) perSecond(current rateSample, window time.Duration) (hits, sets float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.samples) == 0 {
+		return 0, 0
+	}
+
+	from := r.samples[0]
+	for _, sample := range r.samples[1:] {
+		if current.at.Sub(sample.at) < window {
+			break
+		}
+
+		from = sample
+	}
+
+	elapsed := current.at.Sub(from.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+
+	return float64(current.hits-from.hits) / elapsed, float64(current.sets-from.sets) / elapsed
+}
+
+// sampleRates records the current hits and sets and returns them as a sample
+func (c *Cache[K, T]) sampleRates() rateSample {
+	sample := rateSample{at: time.Now(), hits: c.metrics[metricHits].Load(), sets: c.metrics[metricSets].Load()}
+	c.rates.observe(sample)
+
+	return sample
+}
+
+// withRates fills the derived figures of stats
+func (c *Cache[K, T]) withRates(stats Stats) Stats {
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+
+	// Measured up to now without being recorded, only ticks sample
+	current := rateSample{at: time.Now(), hits: stats.Hits, sets: stats.Sets}
+	stats.HitRate1m, stats.SetRate1m = c.rates.perSecond(current, time.Minute)
+	stats.HitRate5m, stats.SetRate5m = c.rates.perSecond(current, 5*time.Minute)
+
+	return stats
+}
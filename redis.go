@@ -0,0 +1,263 @@
+package simplecache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultRedisKey = "simplecache"
+
+// redisScanCount is the batch size hinted to HSCAN
+const redisScanCount = 100
+
+// RedisStore is a Store keeping the items in a Redis hash, so caches on several replicas share them.
+// Every replica keeps its own change diffing and metrics, expired items are removed by whichever replica runs Maintain first.
+type RedisStore[K comparable, T any] struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// TLS connects over TLS when set
+	TLS *tls.Config
+
+	// Key is the hash holding the items, defaults to "simplecache"
+	Key string
+
+	// Codec defaults to GobCodec
+	Codec Codec[T]
+
+	// OnError receives failed commands, Store methods can't return them. A failed Get is a miss.
+	OnError func(error)
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func (s *RedisStore[K, T]) Get(key K) (Item[T], bool) {
+	reply, err := s.do("HGET", s.hash(), keyString(key))
+	if err != nil || reply == nil {
+		s.report(err)
+		return Item[T]{}, false
+	}
+
+	data, _ := reply.([]byte)
+
+	_, item, err := decodeItem[K](s.codec(), data)
+	if err != nil {
+		s.report(err)
+		return Item[T]{}, false
+	}
+
+	return item, true
+}
+
+func (s *RedisStore[K, T]) Set(key K, item Item[T]) {
+	data, err := encodeItem(s.codec(), key, item)
+	if err == nil {
+		_, err = s.do("HSET", s.hash(), keyString(key), string(data))
+	}
+
+	s.report(err)
+}
+
+func (s *RedisStore[K, T]) Delete(key K) {
+	_, err := s.do("HDEL", s.hash(), keyString(key))
+	s.report(err)
+}
+
+// Iterate walks the hash with HSCAN, items written meanwhile may or may not be visited
+func (s *RedisStore[K, T]) Iterate(yield func(K, Item[T]) bool) {
+	cursor := "0"
+	for {
+		reply, err := s.do("HSCAN", s.hash(), cursor, "COUNT", strconv.Itoa(redisScanCount))
+		if err != nil {
+			s.report(err)
+			return
+		}
+
+		parts, _ := reply.([]any)
+		if len(parts) != 2 {
+			s.report(fmt.Errorf("simplecache: redis: unexpected HSCAN reply %v", reply))
+			return
+		}
+
+		// Fields and values alternate
+		pairs, _ := parts[1].([]any)
+		for i := 1; i < len(pairs); i += 2 {
+			data, _ := pairs[i].([]byte)
+
+			key, item, err := decodeItem[K](s.codec(), data)
+			if err != nil {
+				s.report(err)
+				continue
+			}
+
+			if !yield(key, item) {
+				return
+			}
+		}
+
+		next, _ := parts[0].([]byte)
+		if cursor = string(next); cursor == "0" || cursor == "" {
+			return
+		}
+	}
+}
+
+func (s *RedisStore[K, T]) Len() int {
+	reply, err := s.do("HLEN", s.hash())
+	s.report(err)
+
+	n, _ := reply.(int64)
+
+	return int(n)
+}
+
+func (s *RedisStore[K, T]) Clear() {
+	_, err := s.do("DEL", s.hash())
+	s.report(err)
+}
+
+// Close closes the connection, the next command opens a new one
+func (s *RedisStore[K, T]) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// do sends a command and reads its reply, a broken connection is dropped and redialed by the next command
+func (s *RedisStore[K, T]) do(args ...string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := s.roundTrip(args...)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+
+		return nil, fmt.Errorf("simplecache: redis %s: %w", args[0], err)
+	}
+
+	if err, ok := reply.(RESPError); ok {
+		return nil, fmt.Errorf("simplecache: redis %s: %w", args[0], err)
+	}
+
+	return reply, nil
+}
+
+func (s *RedisStore[K, T]) connect() error {
+	conn, err := dialRedis(s.Addr, s.Password, s.DB, s.TLS)
+	if err != nil {
+		return err
+	}
+
+	s.conn, s.r, s.w = conn, bufio.NewReader(conn), bufio.NewWriter(conn)
+
+	return nil
+}
+
+func (s *RedisStore[K, T]) roundTrip(args ...string) (any, error) {
+	return redisRoundTrip(s.r, s.w, args...)
+}
+
+// dialRedis connects to addr, over TLS when config is set, authenticating and selecting db when set
+func dialRedis(addr, password string, db int, config *tls.Config) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+
+	dialer := &net.Dialer{Timeout: busDialTimeout}
+	if config != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, config)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("simplecache: redis: %w", err)
+	}
+
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+
+	conn.SetDeadline(time.Now().Add(busDialTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	var setup [][]string
+	if password != "" {
+		setup = append(setup, []string{"AUTH", password})
+	}
+
+	if db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(db)})
+	}
+
+	for _, args := range setup {
+		reply, err := redisRoundTrip(r, w, args...)
+		if respErr, ok := reply.(RESPError); ok {
+			err = respErr
+		}
+
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("simplecache: redis %s: %w", args[0], err)
+		}
+	}
+
+	// Nothing is buffered yet, callers can wrap conn in new readers
+	return conn, nil
+}
+
+func redisRoundTrip(r *bufio.Reader, w *bufio.Writer, args ...string) (any, error) {
+	encoded := make([][]byte, len(args))
+	for i, arg := range args {
+		encoded[i] = []byte(arg)
+	}
+
+	if err := writeRESP(w, encoded...); err != nil {
+		return nil, err
+	}
+
+	return readRESP(r)
+}
+
+func (s *RedisStore[K, T]) hash() string {
+	if s.Key == "" {
+		return defaultRedisKey
+	}
+
+	return s.Key
+}
+
+func (s *RedisStore[K, T]) codec() Codec[T] {
+	if s.Codec == nil {
+		return GobCodec[T]{}
+	}
+
+	return s.Codec
+}
+
+func (s *RedisStore[K, T]) report(err error) {
+	if err != nil && s.OnError != nil {
+		s.OnError(err)
+	}
+}
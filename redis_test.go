@@ -0,0 +1,269 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis serves the hash commands used by RedisStore, pub/sub and the commands used by RedisLeaser
+type fakeRedis struct {
+	mu          sync.Mutex
+	values      map[string]string
+	hashes      map[string]map[string]string
+	subscribers map[net.Conn]string
+}
+
+func startFakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{values: make(map[string]string), hashes: make(map[string]map[string]string), subscribers: make(map[net.Conn]string)}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go f.serve(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		var n int
+		if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+			return
+		}
+
+		args := make([]string, n)
+		for i := range args {
+			var size int
+			if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+				return
+			}
+
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+
+			args[i] = string(buf[:size])
+		}
+
+		io.WriteString(conn, f.exec(conn, args))
+	}
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func (f *fakeRedis) exec(conn net.Conn, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	hash := f.hashes[args[1]]
+
+	switch strings.ToUpper(args[0]) {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+
+	case "SUBSCRIBE":
+		f.subscribers[conn] = args[1]
+		return "*3\r\n" + bulk("subscribe") + bulk(args[1]) + ":1\r\n"
+
+	case "PUBLISH":
+		n := 0
+		for sub, channel := range f.subscribers {
+			if channel == args[1] {
+				io.WriteString(sub, "*3\r\n"+bulk("message")+bulk(channel)+bulk(args[2]))
+				n++
+			}
+		}
+
+		return ":" + strconv.Itoa(n) + "\r\n"
+
+	case "HGET":
+		value, ok := hash[args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+
+		return bulk(value)
+
+	case "HSET":
+		if hash == nil {
+			hash = make(map[string]string)
+			f.hashes[args[1]] = hash
+		}
+
+		hash[args[2]] = args[3]
+
+		return ":1\r\n"
+
+	case "HDEL":
+		delete(hash, args[2])
+		return ":1\r\n"
+
+	case "HLEN":
+		return ":" + strconv.Itoa(len(hash)) + "\r\n"
+
+	case "HSCAN":
+		var b strings.Builder
+		for field, value := range hash {
+			b.WriteString(bulk(field) + bulk(value))
+		}
+
+		return "*2\r\n" + bulk("0") + "*" + strconv.Itoa(2*len(hash)) + "\r\n" + b.String()
+
+	case "DEL":
+		delete(f.hashes, args[1])
+		delete(f.values, args[1])
+		return ":1\r\n"
+
+	// SET key value NX PX ms, expiry isn't simulated
+	case "SET":
+		if _, exists := f.values[args[1]]; exists {
+			return "$-1\r\n"
+		}
+
+		f.values[args[1]] = args[2]
+
+		return "+OK\r\n"
+
+	// Only the lease release script, EVAL script 1 key token
+	case "EVAL":
+		if f.values[args[3]] != args[4] {
+			return ":0\r\n"
+		}
+
+		delete(f.values, args[3])
+
+		return ":1\r\n"
+	}
+
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisStore(t *testing.T) {
+	addr := startFakeRedis(t)
+
+	var errs []error
+	onError := func(err error) { errs = append(errs, err) }
+
+	replica1 := cache.New[string, TestStruct]().WithStore(&cache.RedisStore[string, TestStruct]{Addr: addr, Password: "secret", OnError: onError})
+	replica2 := cache.New[string, TestStruct]().WithStore(&cache.RedisStore[string, TestStruct]{Addr: addr, DB: 1, OnError: onError})
+
+	replica1.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	replica1.Set("item2", TestStruct{Name: "Bob", Age: 25})
+
+	item1, ok := replica2.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+
+	replica2.Delete("item2")
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 30}}, replica1.GetAll())
+
+	replica2.DeleteAll()
+	_, ok = replica1.Get("item1")
+	assert.False(t, ok)
+
+	assert.Empty(t, errs)
+}
+
+func TestRedisBus(t *testing.T) {
+	addr := startFakeRedis(t)
+
+	bus1 := &cache.RedisBus[string]{Addr: addr, Compression: cache.Gzip{}}
+	bus2 := &cache.RedisBus[string]{Addr: addr, Password: "secret", Compression: cache.Gzip{}}
+	defer bus1.Close()
+	defer bus2.Close()
+
+	invalidated := make(chan string, 1)
+
+	replica1 := cache.New[string, TestStruct]().WithInvalidationBus(bus1)
+	replica2 := cache.New[string, TestStruct]().WithInvalidationBus(bus2).WithImmediateNotifications().
+		OnDeleteKeyed(func(changes []cache.Change[string, TestStruct]) {
+			invalidated <- changes[0].Key
+		})
+
+	replica2.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	replica1.Set("item1", TestStruct{Name: "Alice", Age: 31})
+
+	select {
+	case key := <-invalidated:
+		assert.Equal(t, "item1", key)
+	case <-time.After(time.Second):
+		t.Fatal("invalidation not received")
+	}
+
+	_, ok := replica2.Get("item1")
+	assert.False(t, ok)
+}
+
+func TestRedisBusPublishTimeout(t *testing.T) {
+	// Accepts connections but never answers
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			defer conn.Close()
+		}
+	}()
+
+	bus := &cache.RedisBus[string]{Addr: ln.Addr().String(), Timeout: 200 * time.Millisecond}
+
+	published := make(chan error, 1)
+	go func() {
+		published <- bus.Publish(cache.Invalidation[string]{Keys: []string{"item1"}})
+	}()
+
+	select {
+	case err := <-published:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("PUBLISH didn't time out")
+	}
+
+	// Close isn't held up by a round trip in progress
+	go bus.Publish(cache.Invalidation[string]{Keys: []string{"item1"}})
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		bus.Close()
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Close waited for the round trip")
+	}
+}
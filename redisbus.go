@@ -0,0 +1,267 @@
+package simplecache
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const defaultRedisChannel = "simplecache:invalidations"
+
+// RedisBus is an InvalidationBus on Redis pub/sub, for using the cache as a local L1 in front of data shared through Redis.
+// Delivery is at-most-once, invalidations published while a replica is disconnected are lost to it.
+type RedisBus[K comparable] struct {
+	Addr     string
+	Password string
+
+	// TLS connects over TLS when set
+	TLS *tls.Config
+
+	// Channel defaults to "simplecache:invalidations"
+	Channel string
+
+	// Compression compresses the messages, every replica needs the same
+	Compression Compression
+
+	// OnError receives connection and decoding errors of the background reader
+	OnError func(error)
+
+	// Timeout bounds each PUBLISH round trip and the SUBSCRIBE confirmation, defaults to 5s
+	Timeout time.Duration
+
+	// pubMu serializes the publishers on the pub connection, mu guards the fields and is never held across network I/O
+	pubMu    sync.Mutex
+	mu       sync.Mutex
+	pub      net.Conn
+	pubR     *bufio.Reader
+	pubW     *bufio.Writer
+	sub      net.Conn
+	handlers map[int]func(Invalidation[K])
+	nextID   int
+	closed   bool
+}
+
+func (b *RedisBus[K]) Publish(inv Invalidation[K]) error {
+	payload, err := encodeInvalidation(inv, b.Compression)
+	if err != nil {
+		return err
+	}
+
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	b.mu.Lock()
+	closed, conn, r, w := b.closed, b.pub, b.pubR, b.pubW
+	b.mu.Unlock()
+
+	if closed {
+		return errBusClosed
+	}
+
+	if conn == nil {
+		if conn, err = dialRedis(b.Addr, b.Password, 0, b.TLS); err != nil {
+			return err
+		}
+
+		r, w = bufio.NewReader(conn), bufio.NewWriter(conn)
+
+		b.mu.Lock()
+		closed = b.closed
+		if !closed {
+			b.pub, b.pubR, b.pubW = conn, r, w
+		}
+		b.mu.Unlock()
+
+		if closed {
+			conn.Close()
+			return errBusClosed
+		}
+	}
+
+	// Close closes conn to interrupt a round trip in progress
+	conn.SetDeadline(time.Now().Add(cmp.Or(b.Timeout, busDialTimeout)))
+
+	reply, err := redisRoundTrip(r, w, "PUBLISH", b.channel(), string(payload))
+	if err != nil {
+		conn.Close()
+
+		b.mu.Lock()
+		if b.pub == conn {
+			b.pub = nil
+		}
+		b.mu.Unlock()
+
+		return fmt.Errorf("simplecache: redis PUBLISH: %w", err)
+	}
+
+	if err, ok := reply.(RESPError); ok {
+		return fmt.Errorf("simplecache: redis PUBLISH: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe receives the invalidations on Channel over a dedicated connection
+func (b *RedisBus[K]) Subscribe(fn func(Invalidation[K])) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errBusClosed
+	}
+
+	if b.sub == nil {
+		if err := b.subscribe(); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.handlers == nil {
+		b.handlers = make(map[int]func(Invalidation[K]))
+	}
+
+	b.nextID++
+	id := b.nextID
+	b.handlers[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.handlers, id)
+	}, nil
+}
+
+// Close disconnects, the bus can't be used afterwards
+func (b *RedisBus[K]) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for _, conn := range []net.Conn{b.pub, b.sub} {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+
+	b.pub, b.sub = nil, nil
+
+	return nil
+}
+
+// subscribe opens the subscriber connection and starts reading it, called with mu held
+func (b *RedisBus[K]) subscribe() error {
+	conn, err := dialRedis(b.Addr, b.Password, 0, b.TLS)
+	if err != nil {
+		return err
+	}
+
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+
+	// The confirmation is the first push of the subscription, the pushes after it come whenever they come
+	conn.SetDeadline(time.Now().Add(cmp.Or(b.Timeout, busDialTimeout)))
+	if _, err := redisRoundTrip(r, w, "SUBSCRIBE", b.channel()); err != nil {
+		conn.Close()
+		return fmt.Errorf("simplecache: redis SUBSCRIBE: %w", err)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	b.sub = conn
+
+	go b.read(conn, r)
+
+	return nil
+}
+
+// read delivers the messages of conn until it fails, then resubscribes while the bus is open
+func (b *RedisBus[K]) read(conn net.Conn, r *bufio.Reader) {
+	for {
+		reply, err := readRESP(r)
+		if err != nil {
+			break
+		}
+
+		// ["message", channel, payload]
+		parts, _ := reply.([]any)
+		if len(parts) != 3 {
+			continue
+		}
+
+		kind, _ := parts[0].([]byte)
+		payload, _ := parts[2].([]byte)
+		if string(kind) != "message" {
+			continue
+		}
+
+		inv, err := decodeInvalidation[K](payload, b.Compression)
+		if err != nil {
+			b.report(fmt.Errorf("simplecache: redis: %w", err))
+			continue
+		}
+
+		b.mu.Lock()
+		handlers := make([]func(Invalidation[K]), 0, len(b.handlers))
+		for _, fn := range b.handlers {
+			handlers = append(handlers, fn)
+		}
+		b.mu.Unlock()
+
+		for _, fn := range handlers {
+			fn(inv)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Closed on purpose
+	if b.sub != conn {
+		return
+	}
+
+	b.sub.Close()
+	b.sub = nil
+
+	go b.resubscribe()
+}
+
+func (b *RedisBus[K]) resubscribe() {
+	for {
+		time.Sleep(busReconnectDelay)
+
+		b.mu.Lock()
+		if b.closed || b.sub != nil {
+			b.mu.Unlock()
+			return
+		}
+
+		err := b.subscribe()
+		b.mu.Unlock()
+
+		if err == nil {
+			return
+		}
+
+		b.report(err)
+	}
+}
+
+func (b *RedisBus[K]) channel() string {
+	if b.Channel == "" {
+		return defaultRedisChannel
+	}
+
+	return b.Channel
+}
+
+func (b *RedisBus[K]) report(err error) {
+	if err != nil && b.OnError != nil {
+		b.OnError(err)
+	}
+}
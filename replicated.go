@@ -0,0 +1,41 @@
+package simplecache
+
+import "errors"
+
+// Items returns the cached items with their expirations as stored, including expired ones Maintain hasn't removed yet,
+// e.g. to snapshot the contents of a replica
+func (c *Cache[K, T]) Items() map[K]Item[T] {
+	c.rlock()
+	defer c.RUnlock()
+
+	items := make(map[K]Item[T], c.data.Len())
+	for key, item := range c.data.Iterate {
+		items[key] = item
+	}
+
+	return items
+}
+
+// ApplyReplicated installs a write every replica agreed on, e.g. a committed Raft log entry, directly in the store.
+// Interceptors, audit, invalidations and the persistence hooks are skipped so that all replicas end up with the same
+// contents, and like Load the write isn't reported as a change. With deleted set the key is removed instead.
+func (c *Cache[K, T]) ApplyReplicated(key K, item Item[T], deleted bool) error {
+	if deleted {
+		return c.applyRecovered(walDelete, key, Item[T]{})
+	}
+
+	return c.applyRecovered(walSet, key, item)
+}
+
+// ResetReplicated replaces the contents with items the way ApplyReplicated writes them, expired ones included,
+// e.g. to restore a replica from a snapshot of Items or to replicate DeleteAll with no items
+func (c *Cache[K, T]) ResetReplicated(items map[K]Item[T]) error {
+	var zero K
+
+	errs := []error{c.applyRecovered(walDeleteAll, zero, Item[T]{})}
+	for key, item := range items {
+		errs = append(errs, c.applyRecovered(walSet, key, item))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,42 @@
+package simplecache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyReplicated(t *testing.T) {
+	created := 0
+	c := cache.New[string, int]().
+		WithImmediateNotifications().
+		InterceptSet(func(string, int) (int, error) { return 0, errors.New("rejected") }).
+		OnCreate(func(values []int) { created += len(values) })
+
+	expired := time.Now().Add(-time.Minute)
+
+	// Installed as agreed on, neither intercepted nor reported
+	assert.NoError(t, c.ApplyReplicated("item1", cache.Item[int]{Value: 1}, false))
+	assert.NoError(t, c.ApplyReplicated("item2", cache.Item[int]{Value: 2, Expires: expired}, false))
+
+	value, ok := c.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Zero(t, created)
+
+	assert.Equal(t, map[string]cache.Item[int]{"item1": {Value: 1}, "item2": {Value: 2, Expires: expired}}, c.Items())
+
+	assert.NoError(t, c.ApplyReplicated("item1", cache.Item[int]{}, true))
+	assert.Equal(t, map[string]cache.Item[int]{"item2": {Value: 2, Expires: expired}}, c.Items())
+
+	assert.NoError(t, c.ResetReplicated(map[string]cache.Item[int]{"item3": {Value: 3}}))
+	assert.Equal(t, map[string]cache.Item[int]{"item3": {Value: 3}}, c.Items())
+	assert.Equal(t, int64(1), c.Stats().Items)
+
+	assert.NoError(t, c.ResetReplicated(nil))
+	assert.Empty(t, c.Items())
+	assert.Zero(t, created)
+}
@@ -0,0 +1,302 @@
+package simplecache
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type frameKind uint8
+
+const (
+	frameItem frameKind = iota + 1
+	frameSnapshotEnd
+	frameEvent
+)
+
+// replicationFrame is what a primary streams to its followers, first the items of a snapshot and then the changes after it
+type replicationFrame[K comparable] struct {
+	Kind    frameKind
+	Seq     uint64
+	Type    EventType
+	Key     K
+	Value   []byte
+	Expires time.Time
+}
+
+// ReplicationHandler streams the cache to followers started with ReplicateFrom, as a snapshot followed by the change feed.
+// A follower resuming from a sequence number the feed still retains (see WithChangeFeedRetention) skips the snapshot.
+// Changes are streamed when they are published, on each tick unless WithImmediateNotifications is set.
+// Frames are gob encoded, or Frame messages of proto/simplecache.proto when the request accepts ProtobufContentType.
+func (c *Cache[K, T]) ReplicationHandler() http.Handler {
+	return c.secured(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeHTTPError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+			return
+		}
+
+		since, _ := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+
+		// A primary restarted since has numbered its changes anew
+		snapshot := since == 0 || since > c.LastSeq()
+
+		var events <-chan Event[K, T]
+		var cancel func()
+		var err error
+
+		if !snapshot {
+			events, cancel, err = c.streamSince(since)
+			snapshot = errors.Is(err, ErrChangesTruncated)
+		}
+
+		// Subscribing before taking the snapshot replays changes it may already hold, applying them again is harmless
+		if snapshot {
+			since = c.LastSeq()
+			events, cancel, err = c.streamSince(since)
+		}
+
+		if err != nil {
+			writeHTTPError(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer cancel()
+
+		encode := frameEncoder[K](w, r.Header.Get("Accept") == ProtobufContentType)
+
+		if snapshot {
+			if err := c.streamSnapshot(encode, since); err != nil {
+				c.reportError(err)
+				return
+			}
+		}
+
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+
+				frame := replicationFrame[K]{Kind: frameEvent, Seq: event.Seq, Type: event.Type, Key: event.Key}
+
+				if event.Type == EventCreated || event.Type == EventUpdated {
+					if frame.Value, err = c.valueCodec().Encode(event.Value); err != nil {
+						c.reportError(err)
+						return
+					}
+
+					// Events don't carry the expiry, the current one is right unless a later event follows anyway
+					if item, ok := c.lookup(event.Key, time.Now()); ok {
+						frame.Expires = item.Expires
+					}
+				}
+
+				if encode(frame) != nil {
+					return
+				}
+
+				flusher.Flush()
+			}
+		}
+	}))
+}
+
+// frameEncoder sets the content type and returns how frames are written, as gob or as length-delimited Frame messages
+func frameEncoder[K comparable](w http.ResponseWriter, protobuf bool) func(replicationFrame[K]) error {
+	if !protobuf {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		enc := gob.NewEncoder(w)
+		return func(frame replicationFrame[K]) error { return enc.Encode(frame) }
+	}
+
+	w.Header().Set("Content-Type", ProtobufContentType)
+
+	return func(frame replicationFrame[K]) error {
+		msg, err := encodeProtoFrame(frame)
+		if err != nil {
+			return err
+		}
+
+		return writeProtoDelimited(w, msg)
+	}
+}
+
+// frameDecoder reads frames in the format the primary answered with, primaries predating protobuf send gob
+func frameDecoder[K comparable](resp *http.Response) func(*replicationFrame[K]) error {
+	if resp.Header.Get("Content-Type") != ProtobufContentType {
+		dec := gob.NewDecoder(resp.Body)
+		return func(frame *replicationFrame[K]) error { return dec.Decode(frame) }
+	}
+
+	r := bufio.NewReader(resp.Body)
+
+	return func(frame *replicationFrame[K]) error {
+		msg, err := readProtoDelimited(r)
+		if err != nil {
+			return err
+		}
+
+		*frame, err = decodeProtoFrame[K](msg)
+
+		return err
+	}
+}
+
+func (c *Cache[K, T]) streamSnapshot(encode func(replicationFrame[K]) error, seq uint64) error {
+	for _, e := range c.entries() {
+		value, err := c.valueCodec().Encode(e.Value)
+		if err != nil {
+			return err
+		}
+
+		if err := encode(replicationFrame[K]{Kind: frameItem, Key: e.Key, Value: value, Expires: e.Expires}); err != nil {
+			return err
+		}
+	}
+
+	return encode(replicationFrame[K]{Kind: frameSnapshotEnd, Seq: seq})
+}
+
+// ReplicateFrom makes the cache a read replica of the primary serving ReplicationHandler at url, client may be nil.
+// The follower resumes from the last change it applied after a dropped connection.
+// Nothing else should write to the follower. Call the returned func to stop replicating.
+func (c *Cache[K, T]) ReplicateFrom(url string, client *http.Client) func() {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		var seq uint64
+		for {
+			err := c.replicate(ctx, client, url, &seq)
+			if ctx.Err() != nil {
+				return
+			}
+
+			c.reportError(fmt.Errorf("simplecache: replication from %s: %w", url, err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(busReconnectDelay):
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+var errStreamEnded = errors.New("stream ended")
+
+// replicate applies one stream until it breaks, seq is the last change applied
+func (c *Cache[K, T]) replicate(ctx context.Context, client *http.Client, primary string, seq *uint64) error {
+	target, err := url.Parse(primary)
+	if err != nil {
+		return err
+	}
+
+	query := target.Query()
+	query.Set("since", strconv.FormatUint(*seq, 10))
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", ProtobufContentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	decode := frameDecoder[K](resp)
+
+	var snapshot map[K]Item[T]
+	for {
+		var frame replicationFrame[K]
+		if err := decode(&frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errStreamEnded
+			}
+
+			return err
+		}
+
+		var value T
+		if len(frame.Value) > 0 {
+			if value, err = c.valueCodec().Decode(frame.Value); err != nil {
+				return err
+			}
+		}
+
+		switch frame.Kind {
+		case frameItem:
+			if snapshot == nil {
+				snapshot = make(map[K]Item[T])
+			}
+
+			snapshot[frame.Key] = Item[T]{Value: value, Expires: frame.Expires}
+
+		case frameSnapshotEnd:
+			c.replaceWith(snapshot)
+			snapshot = nil
+			*seq = frame.Seq
+
+		case frameEvent:
+			switch frame.Type {
+			case EventCreated, EventUpdated:
+				err = c.SetE(frame.Key, value, frame.Expires)
+			case EventDeleted, EventExpired:
+				c.Delete(frame.Key)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			*seq = frame.Seq
+		}
+	}
+}
+
+// replaceWith makes items the whole contents, deleting only what they don't hold so reads never see the cache empty
+func (c *Cache[K, T]) replaceWith(items map[K]Item[T]) {
+	for _, e := range c.entries() {
+		if _, ok := items[e.Key]; !ok {
+			c.Delete(e.Key)
+		}
+	}
+
+	c.LoadMany(items, true)
+}
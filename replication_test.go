@@ -0,0 +1,55 @@
+package simplecache_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplication(t *testing.T) {
+	primary := cache.New[string, int]().WithImmediateNotifications().WithChangeFeed()
+	primary.Set("item1", 1)
+	primary.Set("item2", 2, time.Now().Add(time.Hour))
+
+	server := httptest.NewServer(primary.ReplicationHandler())
+	defer server.Close()
+
+	var errs []error
+	follower := cache.New[string, int]().OnError(func(err error) { errs = append(errs, err) })
+	follower.Set("stale", 0)
+
+	stop := follower.ReplicateFrom(server.URL, nil)
+	defer stop()
+
+	// Starts from a snapshot
+	assert.Eventually(t, func() bool {
+		_, stale := follower.Get("stale")
+		return len(follower.GetAll()) == 2 && !stale
+	}, time.Second, 5*time.Millisecond)
+
+	// Then follows the changes
+	primary.Set("item3", 3)
+	primary.Delete("item1")
+
+	assert.Eventually(t, func() bool {
+		value, ok := follower.Get("item3")
+		_, deleted := follower.Get("item1")
+
+		return ok && value == 3 && !deleted
+	}, time.Second, 5*time.Millisecond)
+
+	// Resumes from the last change after the connection drops
+	server.CloseClientConnections()
+	primary.Set("item4", 4)
+
+	assert.Eventually(t, func() bool {
+		value, ok := follower.Get("item4")
+		return ok && value == 4
+	}, 3*time.Second, 10*time.Millisecond)
+
+	stop()
+	assert.NotEmpty(t, errs)
+}
@@ -0,0 +1,145 @@
+package simplecache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Limits on what a peer may announce, as Redis' proto-max-bulk-len and its bounds on multibulk and inline commands
+const (
+	maxRESPBulk  = 512 << 20
+	maxRESPArray = 1 << 20
+	maxRESPLine  = 64 << 10
+
+	// Replies nest arrays only a few levels deep
+	maxRESPDepth = 32
+)
+
+// errRESPProtocol is returned for input breaking the protocol or its limits, the connection can't be read further
+var errRESPProtocol = errors.New("simplecache: RESP protocol error")
+
+// RESPError is an error reply of the Redis protocol, e.g. "WRONGTYPE ..."
+type RESPError string
+
+func (e RESPError) Error() string {
+	return string(e)
+}
+
+// writeRESP writes args as an array of bulk strings, the form commands are sent in
+func writeRESP(w *bufio.Writer, args ...[]byte) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n", len(arg))
+		w.Write(arg)
+		w.WriteString("\r\n")
+	}
+
+	return w.Flush()
+}
+
+// readRESP reads a reply, returning a string, RESPError, int64, []byte, []any or nil for null replies
+func readRESP(r *bufio.Reader) (any, error) {
+	return readRESPNested(r, 0)
+}
+
+func readRESPNested(r *bufio.Reader, depth int) (any, error) {
+	line, err := readRESPLine(r, maxRESPLine)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, errors.New("simplecache: empty RESP reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+
+	case '-':
+		return RESPError(line[1:]), nil
+
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+
+	case '$':
+		size, err := respLength(line, maxRESPBulk)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+
+		return readRESPBulk(r, size)
+
+	case '*':
+		if depth == maxRESPDepth {
+			return nil, fmt.Errorf("%w: arrays nested over %d levels", errRESPProtocol, maxRESPDepth)
+		}
+
+		size, err := respLength(line, maxRESPArray)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+
+		res := make([]any, 0, min(size, 1024))
+		for range size {
+			part, err := readRESPNested(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, part)
+		}
+
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("simplecache: unexpected RESP reply %q", line)
+}
+
+// readRESPBulk reads the size bytes of a bulk string and the CRLF ending it
+func readRESPBulk(r *bufio.Reader, size int) ([]byte, error) {
+	// Read as it arrives rather than allocating what the peer announced up front
+	data, err := io.ReadAll(io.LimitReader(r, int64(size)+2))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < size+2 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return data[:size], nil
+}
+
+// respLength parses the length of a bulk string or array header, -1 being null
+func respLength(line string, limit int) (int, error) {
+	size, err := strconv.Atoi(line[1:])
+	switch {
+	case err != nil || size < -1:
+		return 0, fmt.Errorf("%w: invalid length %q", errRESPProtocol, line)
+	case size > limit:
+		return 0, fmt.Errorf("%w: length %d over the limit of %d", errRESPProtocol, size, limit)
+	}
+
+	return size, nil
+}
+
+func readRESPLine(r *bufio.Reader, limit int) (string, error) {
+	line, err := readLine(r, limit)
+	if errors.Is(err, errLineTooLong) {
+		return "", fmt.Errorf("%w: line over %d bytes", errRESPProtocol, limit)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("%w: malformed line %q", errRESPProtocol, line)
+	}
+
+	return string(line[:len(line)-2]), nil
+}
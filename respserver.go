@@ -0,0 +1,372 @@
+package simplecache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// respLimits bound a command read from a client
+type respLimits struct {
+	args, bytes, line int
+}
+
+var (
+	respCommandLimits = respLimits{args: maxRESPArray, bytes: maxRESPBulk, line: maxRESPLine}
+
+	// Until AUTH succeeds only AUTH [username] password is expected, anything bigger closes the connection
+	respAuthLimits = respLimits{args: 3, bytes: 4 << 10, line: 4 << 10}
+)
+
+// maxRESPAuthFailures is the number of commands refused before AUTH succeeds after which the connection is closed
+const maxRESPAuthFailures = 3
+
+// respArity is the number of arguments each served command needs at least
+var respArity = map[string]int{"PING": 0, "COMMAND": 0, "GET": 1, "SET": 2, "DEL": 1, "EXPIRE": 2, "TTL": 1}
+
+// ListenRESP serves GET, SET (with NX, XX, EX, PX and KEEPTTL), DEL, EXPIRE and TTL over the Redis protocol on addr, so redis-cli and other clients can use the cache.
+// Values are encoded by the codec set with WithCodec (StringCodec for plain strings), keys need to be strings.
+// Writes go through SetE and Delete like any other, Close the returned server to stop serving.
+func (c *Cache[K, T]) ListenRESP(addr string) (*Server, error) {
+	listener, err := c.listen(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return serve(listener, c.serveRESP), nil
+}
+
+func (c *Cache[K, T]) serveRESP(conn net.Conn) {
+	creds, err := connCredentials(conn)
+	if err != nil {
+		return
+	}
+
+	// A client certificate may be enough, otherwise the client has to send AUTH first
+	authenticated := c.serverAuth == nil || c.serverAuth(creds) == nil
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	failures := 0
+	for {
+		limits := respCommandLimits
+		if !authenticated {
+			limits = respAuthLimits
+		}
+
+		args, err := readRESPCommand(r, limits)
+		if errors.Is(err, errRESPProtocol) {
+			writeRESPReply(w, RESPError("ERR Protocol error: "+strings.TrimPrefix(err.Error(), errRESPProtocol.Error()+": ")))
+			return
+		}
+
+		if err != nil {
+			return
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		name := strings.ToUpper(args[0])
+		if name == "QUIT" {
+			writeRESPReply(w, "OK")
+			return
+		}
+
+		var reply any
+		switch {
+		case name == "AUTH":
+			reply, authenticated = c.authRESP(creds, args[1:])
+		case !authenticated:
+			reply = RESPError("NOAUTH Authentication required.")
+		default:
+			reply = c.execRESP(name, args[1:])
+		}
+
+		if !authenticated {
+			failures++
+		}
+
+		if writeRESPReply(w, reply) != nil || failures == maxRESPAuthFailures {
+			return
+		}
+	}
+}
+
+// authRESP checks the password of AUTH [username] password, usernames aren't checked
+func (c *Cache[K, T]) authRESP(creds Credentials, args []string) (any, bool) {
+	switch {
+	case len(args) == 0 || len(args) > 2:
+		return RESPError("ERR wrong number of arguments for 'auth' command"), false
+	case c.serverAuth == nil:
+		return RESPError("ERR AUTH called without any password configured"), true
+	}
+
+	creds.Token = args[len(args)-1]
+	if c.serverAuth(creds) != nil {
+		return RESPError("WRONGPASS invalid username-password pair or user is disabled."), false
+	}
+
+	return "OK", true
+}
+
+// execRESP runs a command, returning the reply as understood by writeRESPReply
+func (c *Cache[K, T]) execRESP(name string, args []string) any {
+	n, known := respArity[name]
+	if !known {
+		return RESPError(fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(name)))
+	}
+
+	if len(args) < n {
+		return RESPError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+	}
+
+	switch name {
+	case "PING":
+		return "PONG"
+
+	// Sent by redis-cli on connect
+	case "COMMAND":
+		return []any{}
+	}
+
+	key, ok := any(args[0]).(K)
+	if !ok {
+		return RESPError("ERR keys must be strings")
+	}
+
+	now := time.Now()
+
+	switch name {
+	case "GET":
+		value, ok := c.Get(key)
+		if !ok {
+			return nil
+		}
+
+		data, err := c.valueCodec().Encode(value)
+		if err != nil {
+			return RESPError("ERR " + err.Error())
+		}
+
+		return data
+
+	case "SET":
+		value, err := c.valueCodec().Decode([]byte(args[1]))
+		if err != nil {
+			return RESPError("ERR " + err.Error())
+		}
+
+		opts, reply := parseRESPSet(args[2:], now)
+		if reply != nil {
+			return reply
+		}
+
+		// NX and XX are checked before writing, a concurrent writer may get in between
+		current, exists := c.lookup(key, now)
+		if (opts.nx && exists) || (opts.xx && !exists) {
+			return nil
+		}
+
+		expires := opts.expires
+		if opts.keepTTL && exists {
+			expires = current.Expires
+		}
+
+		if err := c.SetE(key, value, expires); err != nil {
+			return RESPError("ERR " + err.Error())
+		}
+
+		return "OK"
+
+	case "DEL":
+		deleted := int64(0)
+		for _, arg := range args {
+			key, _ := any(arg).(K)
+			if _, exists := c.lookup(key, now); exists {
+				c.Delete(key)
+				deleted++
+			}
+		}
+
+		return deleted
+
+	case "EXPIRE":
+		seconds, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return RESPError("ERR value is not an integer or out of range")
+		}
+
+		item, exists := c.lookup(key, now)
+		if !exists {
+			return int64(0)
+		}
+
+		// Like Redis, a non-positive timeout deletes the key
+		if seconds <= 0 {
+			c.Delete(key)
+			return int64(1)
+		}
+
+		if err := c.SetE(key, item.Value, now.Add(time.Duration(seconds)*time.Second)); err != nil {
+			return RESPError("ERR " + err.Error())
+		}
+
+		return int64(1)
+
+	case "TTL":
+		item, exists := c.lookup(key, now)
+		switch {
+		case !exists:
+			return int64(-2)
+		case item.Expires.IsZero():
+			return int64(-1)
+		}
+
+		return int64(math.Ceil(item.Expires.Sub(now).Seconds()))
+	}
+
+	return nil
+}
+
+// respSetOptions are the options of SET key value [NX | XX] [EX seconds | PX milliseconds | KEEPTTL]
+type respSetOptions struct {
+	nx, xx, keepTTL bool
+	expires         time.Time
+}
+
+// parseRESPSet parses the options of SET, returning the error reply for options it doesn't understand
+func parseRESPSet(args []string, now time.Time) (respSetOptions, any) {
+	var opts respSetOptions
+	var expiry bool
+
+	for i := 0; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i]); option {
+		case "NX", "XX":
+			if opts.nx || opts.xx {
+				return opts, RESPError("ERR syntax error")
+			}
+
+			opts.nx, opts.xx = option == "NX", option == "XX"
+
+		case "KEEPTTL":
+			if expiry {
+				return opts, RESPError("ERR syntax error")
+			}
+
+			opts.keepTTL, expiry = true, true
+
+		case "EX", "PX":
+			if expiry || i+1 == len(args) {
+				return opts, RESPError("ERR syntax error")
+			}
+
+			i++
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil || n <= 0 {
+				return opts, RESPError("ERR invalid expire time in 'set' command")
+			}
+
+			unit := time.Second
+			if option == "PX" {
+				unit = time.Millisecond
+			}
+
+			opts.expires, expiry = now.Add(time.Duration(n)*unit), true
+
+		default:
+			return opts, RESPError("ERR syntax error")
+		}
+	}
+
+	return opts, nil
+}
+
+// readRESPCommand reads a command sent as a flat array of bulk strings, or inline as a line of words, within limits.
+// Anything else in the array, a nested one included, is refused rather than recursed into.
+func readRESPCommand(r *bufio.Reader, limits respLimits) ([]string, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := readRESPLine(r, limits.line)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	size, err := respLength(line, limits.args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The arguments of one command share the bulk limit
+	budget := limits.bytes
+
+	args := make([]string, 0, min(max(size, 0), 16))
+	for range size {
+		line, err := readRESPLine(r, limits.line)
+		if err != nil {
+			return nil, err
+		}
+
+		if line == "" || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got %q", errRESPProtocol, line)
+		}
+
+		n, err := respLength(line, budget)
+		if err == nil && n < 0 {
+			err = fmt.Errorf("%w: invalid bulk length", errRESPProtocol)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := readRESPBulk(r, n)
+		if err != nil {
+			return nil, err
+		}
+
+		budget -= n
+		args = append(args, string(data))
+	}
+
+	return args, nil
+}
+
+// writeRESPReply writes a string as a simple string, []byte as a bulk string and nil as a null bulk string
+func writeRESPReply(w *bufio.Writer, reply any) error {
+	switch reply := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case string:
+		w.WriteString("+" + reply + "\r\n")
+	case RESPError:
+		w.WriteString("-" + string(reply) + "\r\n")
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", reply)
+	case []byte:
+		fmt.Fprintf(w, "$%d\r\n", len(reply))
+		w.Write(reply)
+		w.WriteString("\r\n")
+	case []any:
+		fmt.Fprintf(w, "*%d\r\n", len(reply))
+		for _, item := range reply {
+			writeRESPReply(w, item)
+		}
+	}
+
+	return w.Flush()
+}
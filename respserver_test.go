@@ -0,0 +1,168 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// respClient sends commands as RESP arrays and returns the raw replies
+type respClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (rc *respClient) call(t *testing.T, args ...string) string {
+	fmt.Fprintf(rc.conn, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rc.conn, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	line, err := rc.r.ReadString('\n')
+	assert.NoError(t, err)
+
+	// Bulk strings carry their data on the next line
+	if strings.HasPrefix(line, "$") && line != "$-1\r\n" {
+		data, err := rc.r.ReadString('\n')
+		assert.NoError(t, err)
+
+		line += data
+	}
+
+	return line
+}
+
+func TestListenRESP(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{})
+	c.Set("item1", "Alice")
+
+	server, err := c.ListenRESP("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	rc := &respClient{conn: conn, r: bufio.NewReader(conn)}
+
+	assert.Equal(t, "+PONG\r\n", rc.call(t, "PING"))
+	assert.Equal(t, "$5\r\nAlice\r\n", rc.call(t, "GET", "item1"))
+	assert.Equal(t, "$-1\r\n", rc.call(t, "GET", "item2"))
+
+	assert.Equal(t, "+OK\r\n", rc.call(t, "SET", "item2", "Bob", "EX", "100"))
+	assert.Equal(t, ":100\r\n", rc.call(t, "TTL", "item2"))
+	assert.Equal(t, ":-1\r\n", rc.call(t, "TTL", "item1"))
+	assert.Equal(t, ":-2\r\n", rc.call(t, "TTL", "item3"))
+
+	assert.Equal(t, ":1\r\n", rc.call(t, "EXPIRE", "item1", "50"))
+	assert.Equal(t, ":50\r\n", rc.call(t, "TTL", "item1"))
+	assert.Equal(t, ":0\r\n", rc.call(t, "EXPIRE", "item3", "50"))
+
+	assert.Equal(t, "$-1\r\n", rc.call(t, "SET", "item2", "Carol", "NX"))
+	assert.Equal(t, "$-1\r\n", rc.call(t, "SET", "item3", "Carol", "XX"))
+	assert.Equal(t, "+OK\r\n", rc.call(t, "SET", "item2", "Bob", "XX", "KEEPTTL"))
+	assert.Equal(t, ":100\r\n", rc.call(t, "TTL", "item2"))
+	assert.Equal(t, "-ERR syntax error\r\n", rc.call(t, "SET", "item2", "Carol", "NX", "XX"))
+	assert.Equal(t, "-ERR syntax error\r\n", rc.call(t, "SET", "item2", "Carol", "GARBAGE"))
+	assert.Equal(t, "-ERR syntax error\r\n", rc.call(t, "SET", "item2", "Carol", "EX"))
+
+	assert.Equal(t, ":1\r\n", rc.call(t, "DEL", "item1", "item3"))
+	assert.Equal(t, "-ERR unknown command 'incr'\r\n", rc.call(t, "INCR", "item2"))
+
+	bob, ok := c.Get("item2")
+	assert.True(t, ok)
+	assert.Equal(t, "Bob", bob)
+
+	_, ok = c.Get("item1")
+	assert.False(t, ok)
+
+	// Inline commands as typed into telnet
+	fmt.Fprint(conn, "GET item2\r\n")
+	assert.Equal(t, "$3\r\n", mustReadLine(t, rc.r))
+}
+
+func TestListenRESPLimitsBeforeAuth(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{}).WithServerSecurity(nil, cache.TokenAuth("secret"))
+
+	server, err := c.ListenRESP("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	rc := &respClient{conn: conn, r: bufio.NewReader(conn)}
+
+	// A value fine once authenticated is too big before
+	assert.True(t, strings.HasPrefix(rc.call(t, "SET", "item1", strings.Repeat("A", 8<<10)), "-ERR Protocol error"))
+
+	conn, err = net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// The connection is closed after a few refused commands
+	rc = &respClient{conn: conn, r: bufio.NewReader(conn)}
+	assert.Equal(t, "-NOAUTH Authentication required.\r\n", rc.call(t, "PING"))
+	assert.True(t, strings.HasPrefix(rc.call(t, "AUTH", "wrong"), "-WRONGPASS"))
+	assert.True(t, strings.HasPrefix(rc.call(t, "AUTH", "wrong"), "-WRONGPASS"))
+
+	_, err = rc.r.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+
+	conn, err = net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	rc = &respClient{conn: conn, r: bufio.NewReader(conn)}
+	assert.Equal(t, "+OK\r\n", rc.call(t, "AUTH", "secret"))
+	assert.Equal(t, "+OK\r\n", rc.call(t, "SET", "item1", strings.Repeat("A", 8<<10)))
+}
+
+func mustReadLine(t *testing.T, r *bufio.Reader) string {
+	line, err := r.ReadString('\n')
+	assert.NoError(t, err)
+
+	return line
+}
+
+func TestListenRESPRejectsOversizedHeaders(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{}).WithServerSecurity(nil, cache.TokenAuth("secret"))
+
+	server, err := c.ListenRESP("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	for _, header := range []string{
+		"*1\r\n$9223372036854775807\r\n",
+		"*1\r\n$-5\r\n",
+		"*9223372036854775807\r\n",
+		strings.Repeat("A", 100<<10) + "\r\n",
+		strings.Repeat("*1\r\n", 1000),
+		"*1\r\n:1\r\n",
+	} {
+		conn, err := net.Dial("tcp", server.Addr().String())
+		assert.NoError(t, err)
+
+		fmt.Fprint(conn, header)
+		assert.True(t, strings.HasPrefix(mustReadLine(t, bufio.NewReader(conn)), "-ERR Protocol error"))
+
+		conn.Close()
+	}
+
+	// The server survived
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	rc := &respClient{conn: conn, r: bufio.NewReader(conn)}
+	assert.Equal(t, "+OK\r\n", rc.call(t, "AUTH", "secret"))
+	assert.Equal(t, "+PONG\r\n", rc.call(t, "PING"))
+}
@@ -0,0 +1,106 @@
+package simplecache
+
+import (
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+const defaultVirtualNodes = 100
+
+// HashRing places nodes on a consistent hash ring with virtual nodes, adding or removing a node only moves the keys next to its points
+type HashRing struct {
+	virtualNodes int
+	points       []uint32
+	owners       map[uint32]string
+	nodes        []string
+}
+
+// NewHashRing makes a ring with virtualNodes points per node, 100 when not positive
+func NewHashRing(virtualNodes int, nodes ...string) *HashRing {
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+
+	r := &HashRing{virtualNodes: virtualNodes, owners: make(map[uint32]string)}
+	r.Add(nodes...)
+
+	return r
+}
+
+func (r *HashRing) Add(nodes ...string) {
+	for _, node := range nodes {
+		if slices.Contains(r.nodes, node) {
+			continue
+		}
+
+		r.nodes = append(r.nodes, node)
+
+		for i := range r.virtualNodes {
+			point := ringHash(node + "#" + strconv.Itoa(i))
+			// On the rare collision the first node keeps the point
+			if _, taken := r.owners[point]; !taken {
+				r.owners[point] = node
+				r.points = append(r.points, point)
+			}
+		}
+	}
+
+	slices.Sort(r.points)
+}
+
+func (r *HashRing) Remove(nodes ...string) {
+	for _, node := range nodes {
+		r.nodes = slices.DeleteFunc(r.nodes, func(n string) bool { return n == node })
+	}
+
+	r.points = slices.DeleteFunc(r.points, func(point uint32) bool {
+		if slices.Contains(nodes, r.owners[point]) {
+			delete(r.owners, point)
+			return true
+		}
+
+		return false
+	})
+}
+
+// Node returns the node owning key, the empty string when the ring is empty
+func (r *HashRing) Node(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	i, _ := slices.BinarySearch(r.points, ringHash(key))
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.owners[r.points[i]]
+}
+
+func (r *HashRing) Nodes() []string {
+	return slices.Clone(r.nodes)
+}
+
+func (r *HashRing) clone() *HashRing {
+	return &HashRing{
+		virtualNodes: r.virtualNodes,
+		points:       slices.Clone(r.points),
+		owners:       maps.Clone(r.owners),
+		nodes:        slices.Clone(r.nodes),
+	}
+}
+
+// ringHash is FNV-1a followed by the splitmix64 finalizer, plain FNV clusters similar strings such as addresses differing in port
+func ringHash(s string) uint32 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+
+	x := h.Sum64()
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return uint32(x >> 32)
+}
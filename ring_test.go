@@ -0,0 +1,41 @@
+package simplecache_test
+
+import (
+	"fmt"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashRing(t *testing.T) {
+	ring := cache.NewHashRing(0, "a", "b", "c")
+	assert.Equal(t, []string{"a", "b", "c"}, ring.Nodes())
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := range 3000 {
+		key := fmt.Sprint("key", i)
+		owners[key] = ring.Node(key)
+		counts[owners[key]]++
+	}
+
+	for _, node := range ring.Nodes() {
+		assert.Greater(t, counts[node], 500, node)
+	}
+
+	// Only keys taken over by the new node move
+	ring.Add("d")
+	for key, owner := range owners {
+		if node := ring.Node(key); node != owner {
+			assert.Equal(t, "d", node)
+		}
+	}
+
+	ring.Remove("d")
+	for key, owner := range owners {
+		assert.Equal(t, owner, ring.Node(key))
+	}
+
+	assert.Equal(t, "", cache.NewHashRing(10).Node("key"))
+}
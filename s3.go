@@ -0,0 +1,171 @@
+package simplecache
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// S3Target is a SnapshotTarget storing the snapshot as an object in an S3-compatible bucket
+// (AWS S3, MinIO, or GCS through its interoperability API with HMAC keys). Requests are signed with AWS Signature V4.
+type S3Target struct {
+	// Endpoint is the base URL, e.g. "https://s3.eu-west-1.amazonaws.com" or "https://storage.googleapis.com"
+	Endpoint string
+	Region   string
+	Bucket   string
+	Key      string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// Write buffers the snapshot and uploads it in one request, the object is only replaced once the snapshot is complete
+func (s *S3Target) Write(fn func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := fn(&buf); err != nil {
+		return err
+	}
+
+	res, err := s.do(http.MethodPut, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return s.check(res)
+}
+
+func (s *S3Target) Read(fn func(io.Reader) error) error {
+	res, err := s.do(http.MethodGet, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := s.check(res); err != nil {
+		return err
+	}
+
+	return fn(res.Body)
+}
+
+func (s *S3Target) check(res *http.Response) error {
+	if res.StatusCode/100 == 2 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	err := fmt.Errorf("simplecache: s3 %s/%s: %s: %s", s.Bucket, s.Key, res.Status, bytes.TrimSpace(body))
+
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+	}
+
+	return err
+}
+
+func (s *S3Target) do(method string, body []byte) (*http.Response, error) {
+	path := "/" + s.Bucket + "/" + s3Escape(s.Key)
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(s.Endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	s.sign(req, path, body, time.Now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
+
+// sign adds an AWS Signature V4 Authorization header
+func (s *S3Target) sign(req *http.Request, path string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := []string{req.URL.Host, payloadHash, amzDate}
+
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+
+		headers = append(headers, "x-amz-security-token")
+		values = append(values, s.SessionToken)
+	}
+
+	var canonicalHeaders strings.Builder
+	for i, h := range headers {
+		canonicalHeaders.WriteString(h + ":" + values[i] + "\n")
+	}
+
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + s.SecretAccessKey)
+	for _, part := range []string{date, s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// s3Escape encodes an object key the way Signature V4 expects, everything but unreserved characters and "/"
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		if 'A' <= ch && ch <= 'Z' || 'a' <= ch && ch <= 'z' || '0' <= ch && ch <= '9' || strings.IndexByte("-_.~/", ch) >= 0 {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
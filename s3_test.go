@@ -0,0 +1,77 @@
+package simplecache_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 stores objects by path and checks the signing headers are present and consistent
+func fakeS3(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Date"))
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			sum := sha256.Sum256(body)
+			assert.Equal(t, hex.EncodeToString(sum[:]), r.Header.Get("X-Amz-Content-Sha256"))
+
+			objects[r.URL.EscapedPath()] = body
+
+		case http.MethodGet:
+			body, ok := objects[r.URL.EscapedPath()]
+			if !ok {
+				http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+				return
+			}
+
+			w.Write(body)
+		}
+	}))
+}
+
+func TestS3Target(t *testing.T) {
+	server := fakeS3(t)
+	defer server.Close()
+
+	target := &cache.S3Target{
+		Endpoint:        server.URL,
+		Region:          "eu-west-1",
+		Bucket:          "snapshots",
+		Key:             "service/cache 1.gob",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	}
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.SaveTo(target))
+
+	restored := cache.New[string, TestStruct]()
+	assert.NoError(t, restored.LoadFrom(target))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+
+	target.Key = "missing.gob"
+	assert.ErrorIs(t, restored.LoadFrom(target), fs.ErrNotExist)
+}
@@ -0,0 +1,131 @@
+package simplecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// OperationSamples describes the sampled calls of one operation, see WithOperationSampling
+type OperationSamples struct {
+	Samples int64 `json:"samples"`
+
+	// LockWait is the time waiting for the cache lock, LockHeld the time the map work held it
+	AvgLockWait time.Duration `json:"avgLockWait"`
+	MaxLockWait time.Duration `json:"maxLockWait"`
+	AvgLockHeld time.Duration `json:"avgLockHeld"`
+	MaxLockHeld time.Duration `json:"maxLockHeld"`
+}
+
+type operation int
+
+const (
+	opGet operation = iota
+	opSet
+	opDelete
+	opCount
+)
+
+// WithOperationSampling times every nth Get, Set and Delete, reported as GetSamples, SetSamples and DeleteSamples
+// of Stats, to diagnose contention in production without pprof. The other calls only increment a counter.
+func (c *Cache[K, T]) WithOperationSampling(n int) *Cache[K, T] {
+	c.sampler = &sampler{every: int64(max(n, 1))}
+
+	return c
+}
+
+type sampler struct {
+	every int64
+	calls atomic.Int64
+	ops   [opCount]operationSampler
+}
+
+type operationSampler struct {
+	samples     atomic.Int64
+	lockWait    atomic.Int64
+	lockHeld    atomic.Int64
+	maxLockWait atomic.Int64
+	maxLockHeld atomic.Int64
+}
+
+// probe times a sampled call, its methods do nothing for the calls not sampled
+type probe struct {
+	op     *operationSampler
+	start  time.Time
+	locked time.Time
+}
+
+// probe starts timing op when the call is sampled, before the lock is requested
+func (c *Cache[K, T]) probe(op operation) probe {
+	if c.sampler == nil || c.sampler.calls.Add(1)%c.sampler.every != 0 {
+		return probe{}
+	}
+
+	return probe{op: &c.sampler.ops[op], start: time.Now()}
+}
+
+// acquired marks the lock as taken
+func (p *probe) acquired() {
+	if p.op != nil {
+		p.locked = time.Now()
+	}
+}
+
+// released records the call once the lock is given back
+func (p *probe) released() {
+	if p.op == nil {
+		return
+	}
+
+	wait, held := p.locked.Sub(p.start), time.Since(p.locked)
+
+	p.op.samples.Add(1)
+	p.op.lockWait.Add(int64(wait))
+	p.op.lockHeld.Add(int64(held))
+	storeMax(&p.op.maxLockWait, int64(wait))
+	storeMax(&p.op.maxLockHeld, int64(held))
+}
+
+func storeMax(v *atomic.Int64, value int64) {
+	for {
+		current := v.Load()
+		if value <= current || v.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+func (s *operationSampler) snapshot() OperationSamples {
+	samples := OperationSamples{
+		Samples:     s.samples.Load(),
+		MaxLockWait: time.Duration(s.maxLockWait.Load()),
+		MaxLockHeld: time.Duration(s.maxLockHeld.Load()),
+	}
+
+	if samples.Samples > 0 {
+		samples.AvgLockWait = time.Duration(s.lockWait.Load() / samples.Samples)
+		samples.AvgLockHeld = time.Duration(s.lockHeld.Load() / samples.Samples)
+	}
+
+	return samples
+}
+
+func (s *operationSampler) reset() {
+	s.samples.Store(0)
+	s.lockWait.Store(0)
+	s.lockHeld.Store(0)
+	s.maxLockWait.Store(0)
+	s.maxLockHeld.Store(0)
+}
+
+// withSamples fills the sampled operations of stats
+func (c *Cache[K, T]) withSamples(stats Stats) Stats {
+	if c.sampler == nil {
+		return stats
+	}
+
+	stats.GetSamples = c.sampler.ops[opGet].snapshot()
+	stats.SetSamples = c.sampler.ops[opSet].snapshot()
+	stats.DeleteSamples = c.sampler.ops[opDelete].snapshot()
+
+	return stats
+}
@@ -0,0 +1,55 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationSampling(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithOperationSampling(2)
+
+	for range 10 {
+		c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	}
+
+	for range 4 {
+		c.Get("item1")
+	}
+
+	c.Delete("item1")
+	c.Delete("item1")
+
+	stats := c.Stats()
+	assert.Equal(t, int64(5), stats.SetSamples.Samples)
+	assert.Equal(t, int64(2), stats.GetSamples.Samples)
+	assert.Equal(t, int64(1), stats.DeleteSamples.Samples)
+	assert.GreaterOrEqual(t, stats.SetSamples.MaxLockHeld, stats.SetSamples.AvgLockHeld)
+
+	c.ResetStats()
+	assert.Zero(t, c.Stats().SetSamples)
+}
+
+func TestOperationSamplingLockWait(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithOperationSampling(1)
+
+	c.Lock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	c.Unlock()
+	<-done
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.SetSamples.Samples)
+	assert.GreaterOrEqual(t, stats.SetSamples.MaxLockWait, 20*time.Millisecond)
+	assert.Equal(t, stats.SetSamples.MaxLockWait, stats.SetSamples.AvgLockWait)
+	assert.Zero(t, stats.GetSamples)
+}
@@ -0,0 +1,149 @@
+package simplecache
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Credentials are what a client presented, a token and the verified certificate of a TLS client
+type Credentials struct {
+	Token       string
+	Certificate *x509.Certificate
+}
+
+// Authenticator decides whether a client may use the cache, a returned error rejects it
+type Authenticator func(Credentials) error
+
+var errAuthRequired = errors.New("simplecache: authentication required")
+
+// errUnauthorized is the reply of the HTTP handlers to rejected requests
+var errUnauthorized = errors.New("unauthorized")
+
+// TokenAuth accepts clients presenting one of tokens
+func TokenAuth(tokens ...string) Authenticator {
+	return func(creds Credentials) error {
+		for _, token := range tokens {
+			if subtle.ConstantTimeCompare([]byte(creds.Token), []byte(token)) == 1 {
+				return nil
+			}
+		}
+
+		return errAuthRequired
+	}
+}
+
+// ClientCertAuth accepts TLS clients whose verified certificate has one of names as common name or DNS name, any
+// verified certificate without names. It needs a tls.Config verifying client certificates (ClientAuth and ClientCAs).
+func ClientCertAuth(names ...string) Authenticator {
+	return func(creds Credentials) error {
+		cert := creds.Certificate
+		if cert == nil {
+			return errAuthRequired
+		}
+
+		if len(names) == 0 || slices.Contains(names, cert.Subject.CommonName) {
+			return nil
+		}
+
+		for _, name := range cert.DNSNames {
+			if slices.Contains(names, name) {
+				return nil
+			}
+		}
+
+		return errAuthRequired
+	}
+}
+
+// AnyAuth accepts clients accepted by one of auths, e.g. mTLS services or token-carrying users
+func AnyAuth(auths ...Authenticator) Authenticator {
+	return func(creds Credentials) error {
+		err := errAuthRequired
+		for _, auth := range auths {
+			if err = auth(creds); err == nil {
+				return nil
+			}
+		}
+
+		return err
+	}
+}
+
+// WithServerSecurity secures every network-facing mode, config (may be nil) makes ListenRESP and ListenMemcached serve TLS
+// and auth (may be nil) is checked by them and by the HTTP, WebSocket, SSE and replication handlers. HTTP handlers get TLS from
+// their http.Server, e.g. with the same config. Over HTTP tokens come as "Authorization: Bearer" or, for browsers' WebSocket
+// and EventSource, an access_token query parameter. RESP clients send AUTH, memcached has no auth command and needs mTLS.
+func (c *Cache[K, T]) WithServerSecurity(config *tls.Config, auth Authenticator) *Cache[K, T] {
+	c.serverTLS = config
+	c.serverAuth = auth
+
+	return c
+}
+
+// listen opens a listener for the protocol servers, with TLS when configured
+func (c *Cache[K, T]) listen(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil || c.serverTLS == nil {
+		return listener, err
+	}
+
+	return tls.NewListener(listener, c.serverTLS), nil
+}
+
+// connCredentials completes the TLS handshake of conn and returns its client certificate
+func connCredentials(conn net.Conn) (Credentials, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return Credentials{}, nil
+	}
+
+	if err := tlsConn.Handshake(); err != nil {
+		return Credentials{}, err
+	}
+
+	return Credentials{Certificate: verifiedCertificate(tlsConn.ConnectionState())}, nil
+}
+
+func verifiedCertificate(state tls.ConnectionState) *x509.Certificate {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return state.VerifiedChains[0][0]
+}
+
+// authorizeHTTP checks a request against the Authenticator, passing when none is set
+func (c *Cache[K, T]) authorizeHTTP(r *http.Request) error {
+	if c.serverAuth == nil {
+		return nil
+	}
+
+	creds := Credentials{Token: r.URL.Query().Get("access_token")}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		creds.Token = token
+	}
+
+	if r.TLS != nil {
+		creds.Certificate = verifiedCertificate(*r.TLS)
+	}
+
+	return c.serverAuth(creds)
+}
+
+// secured rejects requests the Authenticator doesn't accept with 401 Unauthorized
+func (c *Cache[K, T]) secured(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := c.authorizeHTTP(r); err != nil {
+			writeHTTPError(w, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
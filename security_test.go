@@ -0,0 +1,190 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPKI is a CA with a server certificate for 127.0.0.1 and a client certificate named "svc"
+type testPKI struct {
+	pool   *x509.CertPool
+	server tls.Certificate
+	client tls.Certificate
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	ca, err := x509.ParseCertificate(caDER)
+	assert.NoError(t, err)
+
+	issue := func(serial int64, template *x509.Certificate) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(time.Hour)
+
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		assert.NoError(t, err)
+
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	return testPKI{
+		pool: pool,
+		server: issue(2, &x509.Certificate{
+			IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}),
+		client: issue(3, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "svc"},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}),
+	}
+}
+
+func (p testPKI) serverConfig() *tls.Config {
+	return &tls.Config{Certificates: []tls.Certificate{p.server}, ClientCAs: p.pool, ClientAuth: tls.VerifyClientCertIfGiven}
+}
+
+func TestRESPTokenAuth(t *testing.T) {
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{}).WithServerSecurity(nil, cache.TokenAuth("secret"))
+	c.Set("key", "value")
+
+	server, err := c.ListenRESP("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	rc := &respClient{conn: conn, r: bufio.NewReader(conn)}
+	assert.Equal(t, "-NOAUTH Authentication required.\r\n", rc.call(t, "GET", "key"))
+	assert.Contains(t, rc.call(t, "AUTH", "wrong"), "-WRONGPASS")
+	assert.Equal(t, "+OK\r\n", rc.call(t, "AUTH", "default", "secret"))
+	assert.Equal(t, "$5\r\nvalue\r\n", rc.call(t, "GET", "key"))
+
+	// The partitioned client authenticates with its password
+	client := &cache.PartitionedClient[string]{Codec: cache.StringCodec{}, Password: "secret"}
+	defer client.Close()
+	client.AddNode(server.Addr().String())
+
+	value, ok, err := client.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+}
+
+func TestServersMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	c := cache.New[string, string]().WithCodec(cache.StringCodec{}).
+		WithServerSecurity(pki.serverConfig(), cache.AnyAuth(cache.ClientCertAuth("svc"), cache.TokenAuth("secret")))
+	c.Set("key", "value")
+
+	resp, err := c.ListenRESP("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer resp.Close()
+
+	memcached, err := c.ListenMemcached("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer memcached.Close()
+
+	withCert := &tls.Config{RootCAs: pki.pool, Certificates: []tls.Certificate{pki.client}}
+	withoutCert := &tls.Config{RootCAs: pki.pool}
+
+	// A client certificate is enough
+	client := &cache.PartitionedClient[string]{Codec: cache.StringCodec{}, TLS: withCert}
+	defer client.Close()
+	client.AddNode(resp.Addr().String())
+
+	value, ok, err := client.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	// Otherwise a token is needed
+	client = &cache.PartitionedClient[string]{Codec: cache.StringCodec{}, TLS: withoutCert}
+	defer client.Close()
+	client.AddNode(resp.Addr().String())
+
+	_, _, err = client.Get("key")
+	assert.ErrorContains(t, err, "NOAUTH")
+
+	client = &cache.PartitionedClient[string]{Codec: cache.StringCodec{}, TLS: withoutCert, Password: "secret"}
+	defer client.Close()
+	client.AddNode(resp.Addr().String())
+
+	_, ok, err = client.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Memcached clients can only use certificates
+	conn, err := tls.Dial("tcp", memcached.Addr().String(), withoutCert)
+	assert.NoError(t, err)
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	assert.Equal(t, "SERVER_ERROR authentication required\r\n", line)
+	conn.Close()
+
+	conn, err = tls.Dial("tcp", memcached.Addr().String(), withCert)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.Write([]byte("get key\r\n"))
+	line, _ = bufio.NewReader(conn).ReadString('\n')
+	assert.Equal(t, "VALUE key 0 5\r\n", line)
+}
+
+func TestHTTPHandlersAuth(t *testing.T) {
+	c := cache.New[string, int]().WithServerSecurity(nil, cache.TokenAuth("secret"))
+
+	for _, h := range []http.Handler{c.HTTPHandler(), c.SSEHandler(), c.WebSocketHandler(), c.ReplicationHandler()} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	c.HTTPHandler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	c.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?access_token=secret", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
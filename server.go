@@ -0,0 +1,126 @@
+package simplecache
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+var errLineTooLong = errors.New("simplecache: line too long")
+
+// readLine reads up to and including the next newline, failing with errLineTooLong past limit bytes
+// so a peer can't make the server buffer without bound
+func readLine(r *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if len(line) > limit {
+			return nil, errLineTooLong
+		}
+
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// Server serves a cache over a network protocol, see ListenRESP
+type Server struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// serve accepts connections on listener in the background, handling each on its own goroutine
+func serve(listener net.Listener, handle func(net.Conn)) *Server {
+	s := &Server{listener: listener, conns: make(map[net.Conn]struct{})}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		var delay time.Duration
+		for {
+			conn, err := listener.Accept()
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			// Running out of file descriptors and the like passes, back off as net/http does
+			if err != nil {
+				delay = min(max(2*delay, 5*time.Millisecond), time.Second)
+				time.Sleep(delay)
+				continue
+			}
+
+			delay = 0
+
+			if !s.track(conn) {
+				conn.Close()
+				return
+			}
+
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer s.untrack(conn)
+
+				handle(conn)
+			}()
+		}
+	}()
+
+	return s
+}
+
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops accepting connections, closes the open ones and waits for their handlers to return
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+
+	conn.Close()
+}
@@ -0,0 +1,119 @@
+package simplecache
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Sizer returns the bytes held by a value, including what it points to
+type Sizer[T any] func(T) int
+
+// WithSizer sets how the size of values is measured, by default values are walked with reflection by DeepSize
+func (c *Cache[K, T]) WithSizer(sizer Sizer[T]) *Cache[K, T] {
+	c.sizer = sizer
+
+	return c
+}
+
+// itemSize is the size of an item with its value measured by the sizer
+func (c *Cache[K, T]) itemSize(item Item[T]) int {
+	size := int(unsafe.Sizeof(item.Expires))
+	if c.sizer != nil {
+		return size + c.sizer(item.Value)
+	}
+
+	return size + DeepSize(item.Value)
+}
+
+// DeepSize estimates the bytes held by v: its own size plus the strings, slices, maps and pointers it reaches, each counted once.
+// Map sizes leave out the buckets' overhead, channels and functions count as a pointer.
+func DeepSize(v any) int {
+	if v == nil {
+		return 0
+	}
+
+	value := reflect.ValueOf(v)
+	seen := make(map[uintptr]struct{})
+
+	return int(value.Type().Size()) + indirectSize(value, seen)
+}
+
+// indirectSize is what v refers to beyond its own size
+func indirectSize(v reflect.Value, seen map[uintptr]struct{}) int {
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() == 0 || visited(uintptr(unsafe.Pointer(unsafe.StringData(v.String()))), seen) {
+			return 0
+		}
+
+		return v.Len()
+
+	case reflect.Pointer:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+
+		return int(v.Type().Elem().Size()) + indirectSize(v.Elem(), seen)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+
+		elem := v.Elem()
+		return int(elem.Type().Size()) + indirectSize(elem, seen)
+
+	case reflect.Slice:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+
+		size := v.Cap() * int(v.Type().Elem().Size())
+		for i := range v.Len() {
+			size += indirectSize(v.Index(i), seen)
+		}
+
+		return size
+
+	case reflect.Array:
+		size := 0
+		for i := range v.Len() {
+			size += indirectSize(v.Index(i), seen)
+		}
+
+		return size
+
+	case reflect.Map:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+
+		size := v.Len() * int(v.Type().Key().Size()+v.Type().Elem().Size())
+		for iter := v.MapRange(); iter.Next(); {
+			size += indirectSize(iter.Key(), seen) + indirectSize(iter.Value(), seen)
+		}
+
+		return size
+
+	case reflect.Struct:
+		size := 0
+		for i := range v.NumField() {
+			size += indirectSize(v.Field(i), seen)
+		}
+
+		return size
+	}
+
+	return 0
+}
+
+// visited reports whether p was seen already and marks it, shared and cyclic data is counted once
+func visited(p uintptr, seen map[uintptr]struct{}) bool {
+	if _, ok := seen[p]; ok {
+		return true
+	}
+
+	seen[p] = struct{}{}
+
+	return false
+}
@@ -0,0 +1,34 @@
+package simplecache_test
+
+import (
+	"testing"
+	"unsafe"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	Name string
+	Tags []string
+	Next *node
+}
+
+func TestDeepSize(t *testing.T) {
+	assert.Equal(t, 8, cache.DeepSize(int64(1)))
+	assert.Equal(t, int(unsafe.Sizeof(""))+5, cache.DeepSize("hello"))
+
+	tags := []string{"a", "bc"}
+	n := node{Name: "abc", Tags: tags}
+
+	nodeSize := int(unsafe.Sizeof(node{}))
+	tagsSize := 2*int(unsafe.Sizeof("")) + 3
+	assert.Equal(t, nodeSize+3+tagsSize, cache.DeepSize(n))
+
+	// Cycles and shared data are counted once
+	n.Next = &n
+	assert.Equal(t, 2*nodeSize+3+tagsSize, cache.DeepSize(n))
+
+	m := map[string]int{"key": 1}
+	assert.Equal(t, int(unsafe.Sizeof(m))+int(unsafe.Sizeof("")+unsafe.Sizeof(0))+3, cache.DeepSize(m))
+}
@@ -0,0 +1,150 @@
+package simplecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// snapshotFormat is the layout written by Save, bumped when the snapshot layout itself changes
+const snapshotFormat = 1
+
+var ErrSnapshotMismatch = errors.New("simplecache: snapshot was written for a different value type")
+
+// CorruptSnapshotError is returned when a snapshot doesn't match its checksum, e.g. after a crash mid-save
+type CorruptSnapshotError struct {
+	Reason string
+}
+
+func (e *CorruptSnapshotError) Error() string {
+	return "simplecache: corrupt snapshot: " + e.Reason
+}
+
+// verifyChecksum checks the SHA-256 trailer written after every snapshot and returns the data before it
+func verifyChecksum(data []byte) ([]byte, error) {
+	if len(data) < sha256.Size {
+		return nil, &CorruptSnapshotError{Reason: fmt.Sprintf("%d bytes is too short", len(data))}
+	}
+
+	body, sum := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if expected := sha256.Sum256(body); !bytes.Equal(sum, expected[:]) {
+		return nil, &CorruptSnapshotError{Reason: "checksum mismatch"}
+	}
+
+	return body, nil
+}
+
+func appendChecksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+
+	return append(data, sum[:]...)
+}
+
+// SnapshotHeader is written at the start of every snapshot
+type SnapshotHeader struct {
+	Format int
+
+	// Version is the value schema version set with WithSnapshotVersion
+	Version int
+
+	// Fingerprint is derived from the structure of the value type
+	Fingerprint string
+	Time        time.Time
+}
+
+// Migration decodes a value written under an older header into the current value type
+type Migration[T any] func(header SnapshotHeader, value []byte) (T, error)
+
+// WithSnapshotVersion sets the schema version of the value type stored in snapshots, bump it together with a migration when T changes
+func (c *Cache[K, T]) WithSnapshotVersion(version int) *Cache[K, T] {
+	c.snapshotVersion = version
+
+	return c
+}
+
+// WithMigration loads snapshots whose version or type fingerprint doesn't match the cache, they fail with ErrSnapshotMismatch otherwise
+func (c *Cache[K, T]) WithMigration(m Migration[T]) *Cache[K, T] {
+	c.migration = m
+
+	return c
+}
+
+func (c *Cache[K, T]) snapshotHeader() SnapshotHeader {
+	return SnapshotHeader{
+		Format:      snapshotFormat,
+		Version:     c.snapshotVersion,
+		Fingerprint: fingerprint(reflect.TypeFor[T]()),
+		Time:        time.Now(),
+	}
+}
+
+// valueDecoder returns how values written under header are decoded
+func (c *Cache[K, T]) valueDecoder(header SnapshotHeader) (func([]byte) (T, error), error) {
+	if header.Format > snapshotFormat {
+		return nil, fmt.Errorf("simplecache: unsupported snapshot format %d", header.Format)
+	}
+
+	current := c.snapshotHeader()
+	if header.Version == current.Version && header.Fingerprint == current.Fingerprint {
+		return c.valueCodec().Decode, nil
+	}
+
+	if c.migration == nil {
+		return nil, fmt.Errorf("%w: version %d (%s), expected %d (%s)",
+			ErrSnapshotMismatch, header.Version, header.Fingerprint, current.Version, current.Fingerprint)
+	}
+
+	return func(value []byte) (T, error) {
+		return c.migration(header, value)
+	}, nil
+}
+
+// fingerprint hashes the names and types of the fields reachable from t
+func fingerprint(t reflect.Type) string {
+	var b strings.Builder
+	describe(&b, t, make(map[reflect.Type]bool))
+
+	sum := sha256.Sum256([]byte(b.String()))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func describe(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	if seen[t] {
+		b.WriteString(t.String())
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		seen[t] = true
+
+		b.WriteString(t.String() + "{")
+		for i := range t.NumField() {
+			field := t.Field(i)
+
+			b.WriteString(field.Name + " ")
+			describe(b, field.Type, seen)
+			b.WriteString(";")
+		}
+		b.WriteString("}")
+
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		b.WriteString(t.Kind().String() + " ")
+		describe(b, t.Elem(), seen)
+
+	case reflect.Map:
+		b.WriteString("map[")
+		describe(b, t.Key(), seen)
+		b.WriteString("]")
+		describe(b, t.Elem(), seen)
+
+	default:
+		b.WriteString(t.String())
+	}
+}
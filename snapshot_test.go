@@ -0,0 +1,79 @@
+package simplecache_test
+
+import (
+	"bytes"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStructV2 struct {
+	FullName string
+	Age      int
+}
+
+func TestSnapshotMigration(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.Save(&buf))
+	snapshot := buf.Bytes()
+
+	assert.ErrorIs(t, cache.New[string, TestStructV2]().Load(bytes.NewReader(snapshot)), cache.ErrSnapshotMismatch)
+
+	migrated := cache.New[string, TestStructV2]().WithSnapshotVersion(2).
+		WithMigration(func(header cache.SnapshotHeader, value []byte) (TestStructV2, error) {
+			assert.Equal(t, 0, header.Version)
+
+			old, err := cache.GobCodec[TestStruct]{}.Decode(value)
+
+			return TestStructV2{FullName: old.Name, Age: old.Age}, err
+		})
+	assert.NoError(t, migrated.Load(bytes.NewReader(snapshot)))
+
+	item1, ok := migrated.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStructV2{FullName: "Alice", Age: 30}, item1)
+}
+
+func TestSnapshotVersion(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]().WithSnapshotVersion(1)
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.Save(&buf))
+
+	// Same type but a newer schema version
+	err := cache.New[string, TestStruct]().WithSnapshotVersion(2).Load(bytes.NewReader(buf.Bytes()))
+	assert.ErrorIs(t, err, cache.ErrSnapshotMismatch)
+
+	assert.NoError(t, cache.New[string, TestStruct]().WithSnapshotVersion(1).Load(bytes.NewReader(buf.Bytes())))
+}
+
+func TestSnapshotChecksum(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.Save(&buf))
+	snapshot := buf.Bytes()
+
+	var corrupt *cache.CorruptSnapshotError
+
+	truncated := cache.New[string, TestStruct]()
+	assert.ErrorAs(t, truncated.Load(bytes.NewReader(snapshot[:len(snapshot)-10])), &corrupt)
+	assert.Empty(t, truncated.GetAll())
+
+	flipped := bytes.Clone(snapshot)
+	flipped[len(flipped)/2] ^= 0xff
+	assert.ErrorAs(t, cache.New[string, TestStruct]().Load(bytes.NewReader(flipped)), &corrupt)
+
+	restored := cache.New[string, TestStruct]()
+	assert.NoError(t, restored.Load(bytes.NewReader(snapshot)))
+
+	item1, ok := restored.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
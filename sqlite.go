@@ -0,0 +1,153 @@
+package simplecache
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLiteStore is a Store keeping the items in a SQLite table, so they survive restarts without extra infrastructure.
+// It works on a *sql.DB opened with any SQLite driver. Expirations are kept in an indexed column, letting Maintain find expired items without a full scan.
+type SQLiteStore[K comparable, T any] struct {
+	db    *sql.DB
+	table string
+
+	// Codec defaults to GobCodec
+	Codec Codec[T]
+
+	// OnError receives failed queries, Store methods can't return them. A failed Get is a miss.
+	OnError func(error)
+}
+
+// NewSQLiteStore switches db to WAL mode and creates table unless it exists
+func NewSQLiteStore[K comparable, T any](db *sql.DB, table string) (*SQLiteStore[K, T], error) {
+	s := &SQLiteStore[K, T]{db: db, table: `"` + strings.ReplaceAll(table, `"`, `""`) + `"`}
+
+	index := `"` + strings.ReplaceAll(table+"_expires", `"`, `""`) + `"`
+
+	statements := []string{
+		"PRAGMA journal_mode=WAL",
+		"CREATE TABLE IF NOT EXISTS " + s.table + " (key TEXT PRIMARY KEY, data BLOB NOT NULL, expires INTEGER NOT NULL DEFAULT 0)",
+		"CREATE INDEX IF NOT EXISTS " + index + " ON " + s.table + " (expires) WHERE expires > 0",
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return nil, fmt.Errorf("simplecache: sqlite: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *SQLiteStore[K, T]) Get(key K) (Item[T], bool) {
+	var data []byte
+
+	err := s.db.QueryRow("SELECT data FROM "+s.table+" WHERE key = ?", keyString(key)).Scan(&data)
+	if err == sql.ErrNoRows {
+		return Item[T]{}, false
+	}
+
+	if err != nil {
+		s.report(err)
+		return Item[T]{}, false
+	}
+
+	_, item, err := decodeItem[K](s.codec(), data)
+	if err != nil {
+		s.report(err)
+		return Item[T]{}, false
+	}
+
+	return item, true
+}
+
+func (s *SQLiteStore[K, T]) Set(key K, item Item[T]) {
+	data, err := encodeItem(s.codec(), key, item)
+	if err == nil {
+		_, err = s.db.Exec("INSERT INTO "+s.table+" (key, data, expires) VALUES (?, ?, ?) "+
+			"ON CONFLICT (key) DO UPDATE SET data = excluded.data, expires = excluded.expires",
+			keyString(key), data, expiresColumn(item.Expires))
+	}
+
+	s.report(err)
+}
+
+func (s *SQLiteStore[K, T]) Delete(key K) {
+	_, err := s.db.Exec("DELETE FROM "+s.table+" WHERE key = ?", keyString(key))
+	s.report(err)
+}
+
+func (s *SQLiteStore[K, T]) Iterate(yield func(K, Item[T]) bool) {
+	s.query(yield, "SELECT data FROM "+s.table)
+}
+
+// IterateExpired yields the items expired at now using the expires index, see ExpiringStore
+func (s *SQLiteStore[K, T]) IterateExpired(now time.Time, yield func(K, Item[T]) bool) {
+	s.query(yield, "SELECT data FROM "+s.table+" WHERE expires > 0 AND expires < ?", now.UnixNano())
+}
+
+func (s *SQLiteStore[K, T]) Len() int {
+	var n int
+	s.report(s.db.QueryRow("SELECT COUNT(*) FROM " + s.table).Scan(&n))
+
+	return n
+}
+
+func (s *SQLiteStore[K, T]) Clear() {
+	_, err := s.db.Exec("DELETE FROM " + s.table)
+	s.report(err)
+}
+
+func (s *SQLiteStore[K, T]) query(yield func(K, Item[T]) bool, query string, args ...any) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		s.report(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			s.report(err)
+			return
+		}
+
+		key, item, err := decodeItem[K](s.codec(), data)
+		if err != nil {
+			s.report(err)
+			continue
+		}
+
+		if !yield(key, item) {
+			return
+		}
+	}
+
+	s.report(rows.Err())
+}
+
+func (s *SQLiteStore[K, T]) codec() Codec[T] {
+	if s.Codec == nil {
+		return GobCodec[T]{}
+	}
+
+	return s.Codec
+}
+
+func (s *SQLiteStore[K, T]) report(err error) {
+	if err != nil && s.OnError != nil {
+		s.OnError(fmt.Errorf("simplecache: sqlite: %w", err))
+	}
+}
+
+// expiresColumn stores an expiration as Unix nanoseconds, 0 for items that don't expire
+func expiresColumn(expires time.Time) int64 {
+	if expires.IsZero() {
+		return 0
+	}
+
+	return expires.UnixNano()
+}
@@ -0,0 +1,50 @@
+package simplecache_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store, err := cache.NewSQLiteStore[string, TestStruct](db, "items")
+	assert.NoError(t, err)
+
+	expired := make(chan string, 1)
+
+	c := cache.New[string, TestStruct]().WithStore(store).WithInterval(50 * time.Millisecond).
+		OnExpiry(func(key string, _ cache.Item[TestStruct]) {
+			expired <- key
+		})
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, time.Now().Add(10*time.Millisecond))
+
+	assert.Equal(t, "item2", <-expired)
+	c.Stop()
+
+	// A new cache on the same file picks up where the previous one left off
+	store, err = cache.NewSQLiteStore[string, TestStruct](db, "items")
+	assert.NoError(t, err)
+
+	restarted := cache.New[string, TestStruct]().WithStore(store)
+	assert.Equal(t, int64(1), restarted.Stats().Items)
+
+	item1, ok := restarted.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+
+	_, ok = restarted.Get("item2")
+	assert.False(t, ok)
+}
@@ -0,0 +1,87 @@
+package simplecache
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// SSEHandler streams changes as Server-Sent Events, each carrying its sequence number as id, its type as event name
+// and the same JSON as WebSocketHandler as data. Keys are picked with the key, prefix and pattern query parameters.
+// A reconnecting EventSource sends Last-Event-ID and gets the changes it missed, as far as the change feed retains them
+// (see WithChangeFeed). When it doesn't, a "truncated" event tells the listener to reload before live changes follow.
+func (c *Cache[K, T]) SSEHandler() http.Handler {
+	return c.secured(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match, err := eventFilter[K, T](r)
+		if err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeHTTPError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+			return
+		}
+
+		since := c.LastSeq()
+		if id := r.Header.Get("Last-Event-ID"); id != "" {
+			if since, err = strconv.ParseUint(id, 10, 64); err != nil {
+				writeHTTPError(w, http.StatusBadRequest, errors.New("invalid Last-Event-ID"))
+				return
+			}
+		}
+
+		events, cancel, err := c.streamSince(since)
+
+		truncated := errors.Is(err, ErrChangesTruncated)
+		if truncated {
+			events, cancel, err = c.streamSince(c.LastSeq())
+		}
+
+		if err != nil {
+			writeHTTPError(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		if truncated {
+			fmt.Fprint(w, "event: truncated\ndata: {}\n\n")
+		}
+
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+
+				if !match(event.Key, event.Value) {
+					continue
+				}
+
+				data, err := eventJSON(event)
+				if err != nil {
+					c.reportError(err)
+					continue
+				}
+
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data); err != nil {
+					return
+				}
+
+				flusher.Flush()
+			}
+		}
+	}))
+}
@@ -0,0 +1,137 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// readSSE reads the next event as its field lines
+func readSSE(t *testing.T, r *bufio.Reader) []string {
+	var fields []string
+	for {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+
+		if line == "\n" {
+			return fields
+		}
+
+		fields = append(fields, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func openSSE(t *testing.T, ctx context.Context, url, lastEventID string) *bufio.Reader {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return bufio.NewReader(resp.Body)
+}
+
+func TestSSEHandler(t *testing.T) {
+	c := cache.New[string, int]().WithImmediateNotifications().WithChangeFeedRetention(4)
+
+	server := httptest.NewServer(c.SSEHandler())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := openSSE(t, ctx, server.URL+"?prefix=user:", "")
+
+	c.Set("other", 1)
+	c.Set("user:1", 1)
+
+	assert.Equal(t, []string{"id: 2", "event: created", `data: {"seq":2,"type":"created","key":"user:1","value":1,"time":`}, trimData(readSSE(t, r)))
+
+	// Resumes after the last event seen
+	c.Delete("user:1")
+	r = openSSE(t, ctx, server.URL, "1")
+
+	assert.Equal(t, "id: 2", readSSE(t, r)[0])
+	assert.Equal(t, []string{"id: 3", "event: deleted"}, readSSE(t, r)[:2])
+
+	// Missed changes no longer retained
+	for range 5 {
+		c.Set("item", 1)
+		c.Delete("item")
+	}
+
+	r = openSSE(t, ctx, server.URL, "1")
+	assert.Equal(t, "event: truncated", readSSE(t, r)[0])
+
+	// Seen before a restart, ahead of the feed
+	r = openSSE(t, ctx, server.URL, "1000")
+	assert.Equal(t, "event: truncated", readSSE(t, r)[0])
+}
+
+// trimData cuts the data line before the timestamp
+func trimData(fields []string) []string {
+	last := fields[len(fields)-1]
+	fields[len(fields)-1] = last[:strings.Index(last, `"time":`)+len(`"time":`)]
+
+	return fields
+}
+
+// openFlowControlled serves the events of a cache whose streams follow policy with a single event in flight
+func openFlowControlled(t *testing.T, policy cache.SlowConsumerPolicy) (*cache.Cache[string, int], *bufio.Reader) {
+	c := cache.New[string, int]().WithImmediateNotifications().WithStreamFlowControl(1, policy)
+
+	server := httptest.NewServer(c.SSEHandler())
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return c, openSSE(t, ctx, server.URL, "")
+}
+
+func TestStreamFlowControl(t *testing.T) {
+	// A batch doesn't wait for the stream to take its events
+	batch := make(map[string]cache.Item[int])
+	for i := range 20 {
+		batch[strconv.Itoa(i)] = cache.Item[int]{Value: i}
+	}
+
+	c, r := openFlowControlled(t, cache.SlowConsumerDisconnect)
+	c.LoadMany(batch, true)
+
+	received := 0
+	for {
+		if _, err := r.ReadString('\n'); err != nil {
+			break
+		}
+		received++
+	}
+
+	assert.Less(t, received, 20*4)
+	assert.Equal(t, int64(1), c.Stats().SlowConsumerDisconnects)
+
+	c, r = openFlowControlled(t, cache.SlowConsumerBuffer)
+	c.LoadMany(batch, true)
+
+	for i := range 20 {
+		assert.Equal(t, "id: "+strconv.Itoa(i+1), readSSE(t, r)[0])
+	}
+	assert.Positive(t, c.Stats().SlowConsumerBuffered)
+
+	c, r = openFlowControlled(t, cache.SlowConsumerDrop)
+	c.LoadMany(batch, true)
+
+	assert.Equal(t, "id: 1", readSSE(t, r)[0])
+	assert.Positive(t, c.Stats().DroppedEvents)
+}
@@ -0,0 +1,312 @@
+package simplecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the cache's counters, the lock figures stay zero without WithLockMetrics
+type Stats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Sets        int64 `json:"sets"`
+	Items       int64 `json:"items"`
+	MemoryBytes int64 `json:"memoryUsageBytes"`
+
+	// MemoryDrift is the correction of MemoryBytes by the latest recount of WithAccurateMemory
+	MemoryDrift int64 `json:"memoryDrift"`
+
+	// HitRatio is hits over lookups since the start, the rates are per second over the last 1 and 5 minutes
+	// of Maintain ticks, or since Maintain started while it ran for less than that; zero without Maintain
+	HitRatio  float64 `json:"hitRatio"`
+	HitRate1m float64 `json:"hitRate1m"`
+	HitRate5m float64 `json:"hitRate5m"`
+	SetRate1m float64 `json:"setRate1m"`
+	SetRate5m float64 `json:"setRate5m"`
+
+	// Why items left: Deletes counts Delete and DeleteAll, Evictions the items spilled to the overflow tier,
+	// Expirations the ones Maintain removed after expiring
+	Deletes      int64 `json:"deletes"`
+	Evictions    int64 `json:"evictions"`
+	Expirations  int64 `json:"expirations"`
+	OverflowHits int64 `json:"overflowHits"`
+
+	DroppedEvents           int64 `json:"droppedEvents"`
+	MiddlewarePanics        int64 `json:"middlewarePanics"`
+	DeadLetters             int64 `json:"deadLetters"`
+	SlowConsumerBuffered    int64 `json:"slowConsumerBuffered"`
+	SlowConsumerDisconnects int64 `json:"slowConsumerDisconnects"`
+
+	Leases        int64 `json:"leases"`
+	Loads         int64 `json:"loads"`
+	PeerLoads     int64 `json:"peerLoads"`
+	Promotions    int64 `json:"promotions"`
+	OriginFetches int64 `json:"originFetches"`
+	NegativeHits  int64 `json:"negativeHits"`
+
+	// Capacity held by the per-tick change buffers
+	CreatedBufferCap int64 `json:"createdBufferCap"`
+	UpdatedBufferCap int64 `json:"updatedBufferCap"`
+	DeletedBufferCap int64 `json:"deletedBufferCap"`
+	ExpiredBufferCap int64 `json:"expiredBufferCap"`
+
+	// The latest Maintain tick, MissedTicks counts the ticks overdue since, which keeps growing when
+	// Maintain died instead of being stopped; all zero before Maintain starts and after Stop
+	LastTick         time.Time     `json:"lastTick"`
+	LastTickDuration time.Duration `json:"lastTickDuration"`
+	LastTickScanned  int64         `json:"lastTickScanned"`
+	MissedTicks      int64         `json:"missedTicks"`
+
+	// Every nth call of each operation with WithOperationSampling
+	GetSamples    OperationSamples `json:"getSamples"`
+	SetSamples    OperationSamples `json:"setSamples"`
+	DeleteSamples OperationSamples `json:"deleteSamples"`
+
+	ReadLocks     int64         `json:"readLocks"`
+	ReadLockWait  time.Duration `json:"readLockWait"`
+	WriteLocks    int64         `json:"writeLocks"`
+	WriteLockWait time.Duration `json:"writeLockWait"`
+}
+
+type metric int
+
+const (
+	metricHits metric = iota
+	metricMisses
+	metricSets
+	metricItems
+	metricMemoryBytes
+	metricMemoryDrift
+	metricDeletes
+	metricEvictions
+	metricExpirations
+	metricOverflowHits
+	metricDroppedEvents
+	metricMiddlewarePanics
+	metricDeadLetters
+	metricSlowConsumerBuffered
+	metricSlowConsumerDisconnects
+	metricLeases
+	metricLoads
+	metricPeerLoads
+	metricPromotions
+	metricOriginFetches
+	metricNegativeHits
+	metricCreatedBufferCap
+	metricUpdatedBufferCap
+	metricDeletedBufferCap
+	metricExpiredBufferCap
+
+	metricCount
+)
+
+// Names as reported to Telemetry and by MetricsSnapshot
+var metricNames = [metricCount]string{
+	metricHits:                    "hits",
+	metricMisses:                  "misses",
+	metricSets:                    "sets",
+	metricItems:                   "items",
+	metricMemoryBytes:             "memoryUsageBytes",
+	metricMemoryDrift:             "memoryDrift",
+	metricDeletes:                 "deletes",
+	metricEvictions:               "evictions",
+	metricExpirations:             "expirations",
+	metricOverflowHits:            "overflowHits",
+	metricDroppedEvents:           "droppedEvents",
+	metricMiddlewarePanics:        "middlewarePanics",
+	metricDeadLetters:             "deadLetters",
+	metricSlowConsumerBuffered:    "slowConsumerBuffered",
+	metricSlowConsumerDisconnects: "slowConsumerDisconnects",
+	metricLeases:                  "leases",
+	metricLoads:                   "loads",
+	metricPeerLoads:               "peerLoads",
+	metricPromotions:              "promotions",
+	metricOriginFetches:           "originFetches",
+	metricNegativeHits:            "negativeHits",
+	metricCreatedBufferCap:        "createdBufferCap",
+	metricUpdatedBufferCap:        "updatedBufferCap",
+	metricDeletedBufferCap:        "deletedBufferCap",
+	metricExpiredBufferCap:        "expiredBufferCap",
+}
+
+// gauge reports whether m holds a current value rather than a running count
+func (m metric) gauge() bool {
+	switch m {
+	case metricItems, metricMemoryBytes, metricMemoryDrift, metricCreatedBufferCap, metricUpdatedBufferCap, metricDeletedBufferCap, metricExpiredBufferCap:
+		return true
+	}
+
+	return false
+}
+
+type lockStats struct {
+	readLocks     atomic.Int64
+	readLockWait  atomic.Int64
+	writeLocks    atomic.Int64
+	writeLockWait atomic.Int64
+}
+
+// Stats returns the counters of the cache without changing any state. Each counter is read atomically but
+// on its own without stopping writers, so figures can be a few operations apart from one another.
+func (c *Cache[K, T]) Stats() Stats {
+	m := &c.metrics
+
+	stats := Stats{
+		Hits:        m[metricHits].Load(),
+		Misses:      m[metricMisses].Load(),
+		Sets:        m[metricSets].Load(),
+		Items:       m[metricItems].Load(),
+		MemoryBytes: m[metricMemoryBytes].Load(),
+		MemoryDrift: m[metricMemoryDrift].Load(),
+
+		Deletes:      m[metricDeletes].Load(),
+		Evictions:    m[metricEvictions].Load(),
+		Expirations:  m[metricExpirations].Load(),
+		OverflowHits: m[metricOverflowHits].Load(),
+
+		DroppedEvents:           m[metricDroppedEvents].Load(),
+		MiddlewarePanics:        m[metricMiddlewarePanics].Load(),
+		DeadLetters:             m[metricDeadLetters].Load(),
+		SlowConsumerBuffered:    m[metricSlowConsumerBuffered].Load(),
+		SlowConsumerDisconnects: m[metricSlowConsumerDisconnects].Load(),
+
+		Leases:        m[metricLeases].Load(),
+		Loads:         m[metricLoads].Load(),
+		PeerLoads:     m[metricPeerLoads].Load(),
+		Promotions:    m[metricPromotions].Load(),
+		OriginFetches: m[metricOriginFetches].Load(),
+		NegativeHits:  m[metricNegativeHits].Load(),
+
+		CreatedBufferCap: m[metricCreatedBufferCap].Load(),
+		UpdatedBufferCap: m[metricUpdatedBufferCap].Load(),
+		DeletedBufferCap: m[metricDeletedBufferCap].Load(),
+		ExpiredBufferCap: m[metricExpiredBufferCap].Load(),
+
+		ReadLocks:     c.lockStats.readLocks.Load(),
+		ReadLockWait:  time.Duration(c.lockStats.readLockWait.Load()),
+		WriteLocks:    c.lockStats.writeLocks.Load(),
+		WriteLockWait: time.Duration(c.lockStats.writeLockWait.Load()),
+	}
+
+	return c.withSamples(c.withJanitor(c.withRates(stats), time.Now()))
+}
+
+// ResetStats zeroes the counters of Stats, lock stats and rates included, to start a new measurement window.
+// Gauges describing the contents such as items and memory are kept, and so is the data.
+func (c *Cache[K, T]) ResetStats() {
+	for m := range metricCount {
+		if !m.gauge() {
+			c.metrics[m].Store(0)
+		}
+	}
+
+	c.lockStats.readLocks.Store(0)
+	c.lockStats.readLockWait.Store(0)
+	c.lockStats.writeLocks.Store(0)
+	c.lockStats.writeLockWait.Store(0)
+
+	c.rates.reset()
+
+	if c.sampler != nil {
+		for op := range opCount {
+			c.sampler.ops[op].reset()
+		}
+	}
+
+	if c.namespaceStats != nil {
+		c.namespaceStats.reset()
+	}
+}
+
+// StatsMiddleware receives the snapshots pushed by OnStats
+type StatsMiddleware func(Stats)
+
+type statsReporter struct {
+	interval time.Duration
+	fn       StatsMiddleware
+}
+
+// OnStats pushes a Stats snapshot to fn every interval while Maintain runs, for shipping metrics to custom sinks
+func (c *Cache[K, T]) OnStats(interval time.Duration, fn StatsMiddleware) *Cache[K, T] {
+	c.statsReporters = append(c.statsReporters, statsReporter{interval: interval, fn: fn})
+
+	return c
+}
+
+func (c *Cache[K, T]) runStatsReporter(r statsReporter, done <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			stats := c.Stats()
+			c.safely(func() { r.fn(stats) })
+
+		case <-done:
+			return
+		}
+	}
+}
+
+// MetricsSnapshot returns the counters by name, as the Metrics map used to hold them.
+//
+// Deprecated: use Stats.
+func (c *Cache[K, T]) MetricsSnapshot() map[string]int {
+	metrics := make(map[string]int, metricCount)
+	for m, name := range metricNames {
+		metrics[name] = int(c.metrics[m].Load())
+	}
+
+	return metrics
+}
+
+func (c *Cache[K, T]) addMetric(m metric, delta int) {
+	value := c.metrics[m].Add(int64(delta))
+
+	if c.telemetry == nil {
+		return
+	}
+
+	if m.gauge() {
+		c.telemetry.Gauge(metricNames[m], int(value))
+	} else {
+		c.telemetry.Count(metricNames[m], delta)
+	}
+}
+
+func (c *Cache[K, T]) setMetric(m metric, value int) {
+	c.metrics[m].Store(int64(value))
+
+	if c.telemetry != nil {
+		c.telemetry.Gauge(metricNames[m], value)
+	}
+}
+
+// lock acquires the write lock, measuring the wait when lock metrics are enabled
+func (c *Cache[K, T]) lock() {
+	if !c.lockMetrics {
+		c.Lock()
+		return
+	}
+
+	start := time.Now()
+	c.Lock()
+
+	c.lockStats.writeLockWait.Add(int64(time.Since(start)))
+	c.lockStats.writeLocks.Add(1)
+}
+
+// rlock acquires the read lock, measuring the wait when lock metrics are enabled
+func (c *Cache[K, T]) rlock() {
+	if !c.lockMetrics {
+		c.RLock()
+		return
+	}
+
+	start := time.Now()
+	c.RLock()
+
+	c.lockStats.readLockWait.Add(int64(time.Since(start)))
+	c.lockStats.readLocks.Add(1)
+}
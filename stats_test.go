@@ -0,0 +1,182 @@
+package simplecache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockMetrics(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithLockMetrics()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Get("item1")
+	c.GetAll()
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.WriteLocks)
+	assert.Equal(t, int64(2), stats.ReadLocks)
+
+	// Hold the write lock so the reader has to wait
+	c.Lock()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		c.Get("item1")
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	c.Unlock()
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, c.Stats().ReadLockWait, 50*time.Millisecond)
+}
+
+func TestLockMetricsDisabled(t *testing.T) {
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Get("item1")
+
+	stats := c.Stats()
+	assert.Zero(t, stats.ReadLocks)
+	assert.Zero(t, stats.ReadLockWait)
+	assert.Zero(t, stats.WriteLocks)
+	assert.Zero(t, stats.WriteLockWait)
+}
+
+func TestStats(t *testing.T) {
+	ticks := make(chan cache.TickStats, 16)
+
+	c := cache.New[string, TestStruct]().WithInterval(50 * time.Millisecond).
+		OnTickStats(func(stats cache.TickStats) { ticks <- stats })
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, time.Now().Add(10*time.Millisecond))
+	c.Get("item1")
+	c.Get("missing")
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(2), stats.Items)
+	assert.Positive(t, stats.MemoryBytes)
+
+	go c.Maintain()
+	defer c.Stop()
+	<-ticks
+
+	assert.Equal(t, int64(1), c.Stats().Expirations)
+	assert.Equal(t, int64(1), c.Stats().Items)
+	assert.Equal(t, 1, c.MetricsSnapshot()["expirations"])
+}
+
+func TestOnStats(t *testing.T) {
+	pushed := make(chan cache.Stats, 16)
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).OnStats(20*time.Millisecond, func(stats cache.Stats) { pushed <- stats })
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Get("item1")
+
+	go c.Maintain()
+
+	select {
+	case stats := <-pushed:
+		assert.Equal(t, int64(1), stats.Hits)
+		assert.Equal(t, int64(1), stats.Items)
+	case <-time.After(time.Second):
+		t.Fatal("stats not pushed")
+	}
+
+	c.Stop()
+
+	for len(pushed) > 0 {
+		<-pushed
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Empty(t, pushed)
+}
+
+func TestRemovalStats(t *testing.T) {
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("item3", TestStruct{Name: "Carol", Age: 41})
+
+	c.Delete("item1")
+	c.Delete("missing")
+	assert.Equal(t, int64(1), c.Stats().Deletes)
+
+	c.DeleteAll()
+	stats := c.Stats()
+	assert.Equal(t, int64(3), stats.Deletes)
+	assert.Zero(t, stats.Expirations)
+	assert.Zero(t, stats.Evictions)
+}
+
+func TestHitRatioAndRates(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithInterval(time.Hour)
+	assert.Zero(t, c.Stats().HitRatio)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	for range 3 {
+		c.Get("item1")
+	}
+	c.Get("missing")
+
+	// Not sampled without Maintain, Stats included
+	time.Sleep(20 * time.Millisecond)
+	stats := c.Stats()
+	assert.Equal(t, 0.75, stats.HitRatio)
+	assert.Zero(t, stats.HitRate1m)
+	assert.Zero(t, c.Stats().HitRate1m)
+
+	go c.Maintain()
+	defer c.Stop()
+
+	assert.Eventually(t, func() bool { return !c.Stats().LastTick.IsZero() }, time.Second, time.Millisecond)
+
+	for range 3 {
+		c.Get("item1")
+	}
+	c.Set("item1", TestStruct{Name: "Bob", Age: 40})
+
+	time.Sleep(100 * time.Millisecond)
+
+	stats = c.Stats()
+	assert.Equal(t, int64(2), stats.Sets)
+
+	// Measured since Maintain started, 3 hits in a little more than 100ms
+	assert.LessOrEqual(t, stats.HitRate1m, 30.0)
+	assert.Greater(t, stats.HitRate1m, 10.0)
+	assert.Equal(t, stats.HitRate1m, stats.HitRate5m)
+	assert.InDelta(t, stats.HitRate1m/3, stats.SetRate1m, 1e-9)
+}
+
+func TestResetStats(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithLockMetrics().WithNamespaceStats()
+	c.Set("acme:item1", TestStruct{Name: "Alice", Age: 30})
+	c.Get("acme:item1")
+	c.Get("missing")
+
+	c.ResetStats()
+
+	stats := c.Stats()
+	assert.Zero(t, stats.Hits)
+	assert.Zero(t, stats.Misses)
+	assert.Zero(t, stats.Sets)
+	assert.Zero(t, stats.HitRatio)
+	assert.Zero(t, stats.WriteLocks)
+	assert.Equal(t, int64(1), stats.Items)
+	assert.Positive(t, stats.MemoryBytes)
+	assert.Equal(t, cache.NamespaceStats{Items: 1, MemoryBytes: stats.MemoryBytes}, c.NamespaceStats()["acme"])
+
+	item, ok := c.Get("acme:item1")
+	assert.True(t, ok)
+	assert.Equal(t, "Alice", item.Name)
+	assert.Equal(t, int64(1), c.Stats().Hits)
+}
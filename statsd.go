@@ -0,0 +1,157 @@
+package simplecache
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultStatsDPrefix = "simplecache"
+
+	// Keeps a packet within the usual MTU after IP and UDP headers
+	statsDPacketSize = 1432
+)
+
+// StatsD ships Stats to a StatsD or DogStatsD agent over UDP, pass its Send to OnStats:
+//
+//	c.OnStats(10*time.Second, (&simplecache.StatsD{Addr: "127.0.0.1:8125", Tags: []string{"service:api"}}).Send)
+//
+// Counters are sent as the increase since the previous Send, gauges as their current value.
+type StatsD struct {
+	Addr string
+
+	// Prefix starts every metric name, defaults to "simplecache"
+	Prefix string
+
+	// Tags are appended in the DogStatsD format (|#env:prod,service:api), leave empty for plain StatsD
+	Tags []string
+
+	// OnError receives dial and write errors
+	OnError func(error)
+
+	mu   sync.Mutex
+	conn net.Conn
+	prev Stats
+}
+
+type statsDMetric struct {
+	name  string
+	kind  string
+	value string
+}
+
+// Send writes stats to the agent, dialing it on first use
+func (s *StatsD) Send(stats Stats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.Dial("udp", s.Addr)
+		if err != nil {
+			s.report(fmt.Errorf("simplecache: statsd: %w", err))
+			return
+		}
+
+		s.conn = conn
+	}
+
+	var packet strings.Builder
+	for _, m := range s.metrics(stats) {
+		line := s.prefix() + "." + m.name + ":" + m.value + "|" + m.kind
+		if len(s.Tags) > 0 {
+			line += "|#" + strings.Join(s.Tags, ",")
+		}
+
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsDPacketSize {
+			s.write(packet.String())
+			packet.Reset()
+		}
+
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+
+		packet.WriteString(line)
+	}
+
+	if packet.Len() > 0 {
+		s.write(packet.String())
+	}
+
+	s.prev = stats
+}
+
+// Close closes the socket, the next Send dials again
+func (s *StatsD) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+func (s *StatsD) metrics(stats Stats) []statsDMetric {
+	counter := func(name string, value, prev int64) statsDMetric {
+		// ResetStats zeroed the counter since the previous Send
+		if value < prev {
+			prev = 0
+		}
+
+		return statsDMetric{name: name, kind: "c", value: strconv.FormatInt(value-prev, 10)}
+	}
+
+	gauge := func(name string, value int64) statsDMetric {
+		return statsDMetric{name: name, kind: "g", value: strconv.FormatInt(value, 10)}
+	}
+
+	prev := s.prev
+
+	return []statsDMetric{
+		counter("hits", stats.Hits, prev.Hits),
+		counter("misses", stats.Misses, prev.Misses),
+		counter("sets", stats.Sets, prev.Sets),
+		counter("deletes", stats.Deletes, prev.Deletes),
+		counter("evictions", stats.Evictions, prev.Evictions),
+		counter("expirations", stats.Expirations, prev.Expirations),
+		counter("overflow_hits", stats.OverflowHits, prev.OverflowHits),
+		counter("dropped_events", stats.DroppedEvents, prev.DroppedEvents),
+		counter("middleware_panics", stats.MiddlewarePanics, prev.MiddlewarePanics),
+		counter("dead_letters", stats.DeadLetters, prev.DeadLetters),
+		counter("loads", stats.Loads, prev.Loads),
+		counter("origin_fetches", stats.OriginFetches, prev.OriginFetches),
+		gauge("items", stats.Items),
+		gauge("memory_bytes", stats.MemoryBytes),
+		gauge("missed_ticks", stats.MissedTicks),
+		{name: "hit_ratio", kind: "g", value: strconv.FormatFloat(stats.HitRatio, 'f', -1, 64)},
+		{name: "last_tick_duration", kind: "ms", value: strconv.FormatFloat(float64(stats.LastTickDuration.Microseconds())/1000, 'f', -1, 64)},
+	}
+}
+
+func (s *StatsD) write(packet string) {
+	if _, err := s.conn.Write([]byte(packet)); err != nil {
+		s.report(fmt.Errorf("simplecache: statsd: %w", err))
+	}
+}
+
+func (s *StatsD) prefix() string {
+	if s.Prefix == "" {
+		return defaultStatsDPrefix
+	}
+
+	return s.Prefix
+}
+
+func (s *StatsD) report(err error) {
+	if err != nil && s.OnError != nil {
+		s.OnError(err)
+	}
+}
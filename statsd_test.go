@@ -0,0 +1,58 @@
+package simplecache_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	receive := func() []string {
+		buf := make([]byte, 2048)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+
+		n, _, err := conn.ReadFrom(buf)
+		assert.NoError(t, err)
+
+		return strings.Split(string(buf[:n]), "\n")
+	}
+
+	var errs []error
+	exporter := &cache.StatsD{Addr: conn.LocalAddr().String(), Prefix: "app.cache", Tags: []string{"env:test"}, OnError: func(err error) { errs = append(errs, err) }}
+	defer exporter.Close()
+
+	c := cache.New[string, TestStruct]()
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Get("item1")
+	c.Get("item1")
+
+	exporter.Send(c.Stats())
+	lines := receive()
+	assert.Contains(t, lines, "app.cache.hits:2|c|#env:test")
+	assert.Contains(t, lines, "app.cache.items:1|g|#env:test")
+	assert.Contains(t, lines, "app.cache.hit_ratio:1|g|#env:test")
+
+	c.Get("item1")
+	c.Delete("item1")
+
+	exporter.Send(c.Stats())
+	lines = receive()
+	assert.Contains(t, lines, "app.cache.hits:1|c|#env:test")
+	assert.Contains(t, lines, "app.cache.deletes:1|c|#env:test")
+	assert.Contains(t, lines, "app.cache.items:0|g|#env:test")
+
+	c.ResetStats()
+
+	exporter.Send(c.Stats())
+	assert.Contains(t, receive(), "app.cache.hits:0|c|#env:test")
+
+	assert.Empty(t, errs)
+}
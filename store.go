@@ -0,0 +1,124 @@
+package simplecache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"time"
+)
+
+// Store holds the cached items, a MapStore unless WithStore sets another one.
+// The cache serializes writes, Get and Iterate may run concurrently with each other under the read lock.
+type Store[K comparable, T any] interface {
+	Get(key K) (Item[T], bool)
+	Set(key K, item Item[T])
+	Delete(key K)
+	// Iterate calls yield for every item until it returns false, usable with range
+	Iterate(yield func(K, Item[T]) bool)
+	Len() int
+	Clear()
+}
+
+// ExpiringStore is a Store able to list its expired items itself, e.g. from an indexed column, sparing Maintain a full scan
+type ExpiringStore[K comparable, T any] interface {
+	Store[K, T]
+	IterateExpired(now time.Time, yield func(K, Item[T]) bool)
+}
+
+// MapStore is the default Store, a plain map
+type MapStore[K comparable, T any] map[K]Item[T]
+
+func (m MapStore[K, T]) Get(key K) (Item[T], bool) {
+	item, ok := m[key]
+	return item, ok
+}
+
+func (m MapStore[K, T]) Set(key K, item Item[T]) {
+	m[key] = item
+}
+
+func (m MapStore[K, T]) Delete(key K) {
+	delete(m, key)
+}
+
+func (m MapStore[K, T]) Iterate(yield func(K, Item[T]) bool) {
+	for key, item := range m {
+		if !yield(key, item) {
+			return
+		}
+	}
+}
+
+func (m MapStore[K, T]) Len() int {
+	return len(m)
+}
+
+func (m MapStore[K, T]) Clear() {
+	clear(m)
+}
+
+var errCopyOnWriteStore = errors.New("simplecache: copy-on-write mode needs a MapStore")
+
+// WithStore keeps the items in store instead of a map, items already in it are served by the cache.
+// Copy-on-write mode only works with a MapStore, combining it with another store is reported to OnError once Maintain starts.
+func (c *Cache[K, T]) WithStore(store Store[K, T]) *Cache[K, T] {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := store.(MapStore[K, T]); c.copyOnWrite && !ok {
+		c.startErrs = append(c.startErrs, errCopyOnWriteStore)
+		return c
+	}
+
+	c.commit(store)
+	c.setMetric(metricItems, store.Len())
+
+	// Items already in the store aren't reported as created by the first tick
+	c.prev = make(map[K]Item[T])
+	for key, item := range store.Iterate {
+		c.prev[key] = item
+	}
+
+	return c
+}
+
+// encodeItem serializes an item along with its key for stores keeping it outside the process
+func encodeItem[K comparable, T any](codec Codec[T], key K, item Item[T]) ([]byte, error) {
+	value, err := codec.Encode(item.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = gob.NewEncoder(&buf).Encode(record[K]{Key: key, Value: value, Expires: item.Expires})
+
+	return buf.Bytes(), err
+}
+
+func decodeItem[K comparable, T any](codec Codec[T], data []byte) (K, Item[T], error) {
+	var rec record[K]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rec); err != nil {
+		return rec.Key, Item[T]{}, err
+	}
+
+	value, err := codec.Decode(rec.Value)
+
+	return rec.Key, Item[T]{Value: value, Expires: rec.Expires}, err
+}
+
+// expiredItems yields the expired items, asking the store when it is an ExpiringStore
+func (c *Cache[K, T]) expiredItems(now time.Time) func(yield func(K, Item[T]) bool) {
+	if store, ok := c.data.(ExpiringStore[K, T]); ok {
+		return func(yield func(K, Item[T]) bool) {
+			store.IterateExpired(now, yield)
+		}
+	}
+
+	return func(yield func(K, Item[T]) bool) {
+		for key, item := range c.data.Iterate {
+			if item.expired(now) && !yield(key, item) {
+				return
+			}
+		}
+	}
+}
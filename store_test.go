@@ -0,0 +1,112 @@
+package simplecache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceStore is a Store keeping items in insertion order
+type sliceStore struct {
+	keys  []string
+	items []cache.Item[TestStruct]
+}
+
+func (s *sliceStore) Get(key string) (cache.Item[TestStruct], bool) {
+	for i, k := range s.keys {
+		if k == key {
+			return s.items[i], true
+		}
+	}
+
+	return cache.Item[TestStruct]{}, false
+}
+
+func (s *sliceStore) Set(key string, item cache.Item[TestStruct]) {
+	for i, k := range s.keys {
+		if k == key {
+			s.items[i] = item
+			return
+		}
+	}
+
+	s.keys = append(s.keys, key)
+	s.items = append(s.items, item)
+}
+
+func (s *sliceStore) Delete(key string) {
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			s.items = append(s.items[:i], s.items[i+1:]...)
+
+			return
+		}
+	}
+}
+
+func (s *sliceStore) Iterate(yield func(string, cache.Item[TestStruct]) bool) {
+	for i, k := range s.keys {
+		if !yield(k, s.items[i]) {
+			return
+		}
+	}
+}
+
+func (s *sliceStore) Len() int {
+	return len(s.keys)
+}
+
+func (s *sliceStore) Clear() {
+	s.keys, s.items = nil, nil
+}
+
+func TestWithStore(t *testing.T) {
+	store := &sliceStore{}
+	expired := make(chan string, 1)
+
+	c := cache.New[string, TestStruct]().WithStore(store).WithInterval(50 * time.Millisecond).
+		OnExpiry(func(key string, _ cache.Item[TestStruct]) {
+			expired <- key
+		})
+
+	go c.Maintain()
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, time.Now().Add(10*time.Millisecond))
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40})
+	c.Delete("item3")
+
+	assert.Equal(t, "item2", <-expired)
+	c.Stop()
+
+	assert.Equal(t, []string{"item1"}, store.keys)
+	assert.Equal(t, []TestStruct{{Name: "Alice", Age: 30}}, c.GetAll())
+
+	item1, ok := c.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
+
+func TestWithStoreCopyOnWrite(t *testing.T) {
+	var mu sync.Mutex
+	var errs []error
+
+	c := cache.New[string, TestStruct]().WithCopyOnWrite().WithStore(&sliceStore{}).WithInterval(time.Second).
+		OnError(func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		})
+
+	go c.Maintain()
+	c.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Len(t, errs, 1)
+}
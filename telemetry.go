@@ -0,0 +1,68 @@
+package simplecache
+
+import (
+	"context"
+	"time"
+)
+
+// Telemetry instruments the cache, otelcache.New adapts OpenTelemetry meter and tracer providers
+type Telemetry interface {
+	// Count adds delta to a counter of Stats, named as in MetricsSnapshot, e.g. hits
+	Count(name string, delta int)
+
+	// Gauge reports the current value of a gauge of Stats, e.g. items or memoryUsageBytes
+	Gauge(name string, value int)
+
+	// Observe records the duration of a tick, an origin fetch, a group load or a middleware dispatch
+	Observe(name string, d time.Duration)
+
+	// Start begins a span around the same operations, end finishes it with their error
+	Start(ctx context.Context, name string) (_ context.Context, end func(error))
+}
+
+// SpanLinker is implemented by a Telemetry able to link spans. The dispatch of WithAsyncDispatch runs after the
+// write returned, so its span starts a trace of its own linked to the write's rather than a child of it.
+type SpanLinker interface {
+	// StartLinked begins a span in a new trace, linked to the span of ctx
+	StartLinked(ctx context.Context, name string) (_ context.Context, end func(error))
+}
+
+// WithTelemetry reports every metric change to t and traces loader calls and middleware dispatch
+func (c *Cache[K, T]) WithTelemetry(t Telemetry) *Cache[K, T] {
+	c.telemetry = t
+
+	return c
+}
+
+func endNothing(error) {}
+
+// linkedSpan is span linked to the span of ctx instead of a child of it when the Telemetry is a SpanLinker
+func (c *Cache[K, T]) linkedSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	linker, ok := c.telemetry.(SpanLinker)
+	if !ok {
+		return c.span(ctx, name)
+	}
+
+	start := time.Now()
+	ctx, end := linker.StartLinked(ctx, name)
+
+	return ctx, func(err error) {
+		c.telemetry.Observe(name, time.Since(start))
+		end(err)
+	}
+}
+
+// span starts a span named name and records its duration when it ends, a no-op without telemetry
+func (c *Cache[K, T]) span(ctx context.Context, name string) (context.Context, func(error)) {
+	if c.telemetry == nil {
+		return ctx, endNothing
+	}
+
+	start := time.Now()
+	ctx, end := c.telemetry.Start(ctx, name)
+
+	return ctx, func(err error) {
+		c.telemetry.Observe(name, time.Since(start))
+		end(err)
+	}
+}
@@ -0,0 +1,61 @@
+package simplecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// TickStats describes a single Maintain tick, created/updated/deleted stay zero with WithImmediateNotifications
+type TickStats struct {
+	Start    time.Time
+	Duration time.Duration
+	Scanned  int
+	Expired  int
+	Created  int
+	Updated  int
+	Deleted  int
+
+	// LockWait is the time spent acquiring the cache lock, LockHeld how long it was held
+	LockWait time.Duration
+	LockHeld time.Duration
+}
+
+type TickStatsMiddleware func(TickStats)
+
+// OnTickStats is triggered after each Maintain tick, after OnAfterTick, with statistics about the tick
+func (c *Cache[K, T]) OnTickStats(m TickStatsMiddleware) *Cache[K, T] {
+	c.Subscribe(Middlewares[K, T]{OnTickStats: m})
+
+	return c
+}
+
+// janitorHealth is the latest tick of Maintain, reported by Stats
+type janitorHealth struct {
+	lastTick     atomic.Int64
+	lastDuration atomic.Int64
+	lastScanned  atomic.Int64
+}
+
+func (j *janitorHealth) record(stats TickStats) {
+	j.lastTick.Store(stats.Start.UnixNano())
+	j.lastDuration.Store(int64(stats.Duration))
+	j.lastScanned.Store(int64(stats.Scanned))
+}
+
+// withJanitor fills the tick figures of stats, ticks count as missed once more than an interval late
+func (c *Cache[K, T]) withJanitor(stats Stats, now time.Time) Stats {
+	last := c.janitor.lastTick.Load()
+	if last == 0 {
+		return stats
+	}
+
+	stats.LastTick = time.Unix(0, last)
+	stats.LastTickDuration = time.Duration(c.janitor.lastDuration.Load())
+	stats.LastTickScanned = c.janitor.lastScanned.Load()
+
+	if c.interval > 0 {
+		stats.MissedTicks = max(int64(now.Sub(stats.LastTick)/c.interval)-1, 0)
+	}
+
+	return stats
+}
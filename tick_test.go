@@ -0,0 +1,78 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTickStats(t *testing.T) {
+	ticks := make(chan cache.TickStats, 16)
+
+	c := cache.New[string, TestStruct]().WithInterval(100 * time.Millisecond).
+		Equals(func(a, b TestStruct) bool { return a == b }).
+		OnTickStats(func(stats cache.TickStats) { ticks <- stats })
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25}, time.Now().Add(50*time.Millisecond))
+
+	go c.Maintain()
+	defer c.Stop()
+
+	stats := <-ticks
+	assert.Equal(t, 2, stats.Scanned)
+	assert.Equal(t, 1, stats.Expired)
+	assert.Equal(t, 1, stats.Created)
+	assert.Equal(t, 0, stats.Updated)
+	assert.False(t, stats.Start.IsZero())
+	assert.GreaterOrEqual(t, stats.Duration, stats.LockHeld)
+
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+
+	stats = <-ticks
+	assert.Equal(t, 1, stats.Scanned)
+	assert.Equal(t, 0, stats.Expired)
+	assert.Equal(t, 1, stats.Updated)
+}
+
+func TestJanitorHealth(t *testing.T) {
+	ticks := make(chan cache.TickStats, 16)
+
+	c := cache.New[string, int]().WithInterval(20 * time.Millisecond).
+		OnTickStats(func(stats cache.TickStats) { ticks <- stats })
+	c.Set("item1", 1)
+
+	assert.True(t, c.Stats().LastTick.IsZero())
+
+	go c.Maintain()
+	tick := <-ticks
+
+	stats := c.Stats()
+	assert.Equal(t, tick.Start.UnixNano(), stats.LastTick.UnixNano())
+	assert.Equal(t, tick.Duration, stats.LastTickDuration)
+	assert.Equal(t, int64(1), stats.LastTickScanned)
+	assert.Zero(t, stats.MissedTicks)
+
+	c.Stop()
+	assert.Equal(t, cache.Stats{}.LastTick, c.Stats().LastTick)
+}
+
+func TestJanitorHealthDeadMaintain(t *testing.T) {
+	c := cache.New[string, int]().WithInterval(20 * time.Millisecond).
+		Equals(func(a, b int) bool { panic("broken comparison") })
+	c.Set("item1", 1)
+
+	// The first tick sees item1 as created, the second one compares it and dies
+	go func() {
+		defer func() { recover() }()
+		c.Maintain()
+	}()
+
+	time.Sleep(150 * time.Millisecond)
+
+	stats := c.Stats()
+	assert.False(t, stats.LastTick.IsZero())
+	assert.GreaterOrEqual(t, stats.MissedTicks, int64(3))
+}
@@ -0,0 +1,80 @@
+package simplecache
+
+import "time"
+
+// TieredCache reads through and writes through a fast local cache (L1) to a shared or remote Store (L2)
+type TieredCache[K comparable, T any] struct {
+	l1    *Cache[K, T]
+	l2    Store[K, T]
+	l1TTL time.Duration
+	l2TTL time.Duration
+}
+
+// Tiered composes l1 in front of l2, which needs to be safe for concurrent use (e.g. a RedisStore shared by several replicas).
+// Nothing else should write to l1 or its copies of L2 items go stale.
+func Tiered[K comparable, T any](l1 *Cache[K, T], l2 Store[K, T]) *TieredCache[K, T] {
+	return &TieredCache[K, T]{l1: l1, l2: l2}
+}
+
+// WithTTLs caps how long items live in each tier, 0 keeps them until their own expiry.
+// A short L1 TTL bounds how stale a replica can be after another one wrote to L2.
+func (t *TieredCache[K, T]) WithTTLs(l1, l2 time.Duration) *TieredCache[K, T] {
+	t.l1TTL = l1
+	t.l2TTL = l2
+
+	return t
+}
+
+// Get reads L1, then L2, promoting an L2 hit to L1
+func (t *TieredCache[K, T]) Get(key K) (T, bool) {
+	if value, ok := t.l1.Get(key); ok {
+		return value, true
+	}
+
+	now := time.Now()
+
+	item, ok := t.l2.Get(key)
+	if !ok || item.expired(now) {
+		var zero T
+		return zero, false
+	}
+
+	t.l1.addMetric(metricPromotions, 1)
+
+	// A rejected promotion is still a hit
+	t.l1.SetE(key, item.Value, capExpiry(item.Expires, now, t.l1TTL))
+
+	return item.Value, true
+}
+
+// Set writes L2 first so L1 never holds a value the other replicas can't see
+func (t *TieredCache[K, T]) Set(key K, value T, expires ...time.Time) error {
+	var expiry time.Time
+	if len(expires) > 0 {
+		expiry = expires[0]
+	}
+
+	now := time.Now()
+
+	t.l2.Set(key, Item[T]{Value: value, Expires: capExpiry(expiry, now, t.l2TTL)})
+
+	return t.l1.SetE(key, value, capExpiry(expiry, now, t.l1TTL))
+}
+
+func (t *TieredCache[K, T]) Delete(key K) {
+	t.l2.Delete(key)
+	t.l1.Delete(key)
+}
+
+// capExpiry returns the earlier of expires and now+ttl, a zero expires or ttl doesn't limit
+func capExpiry(expires, now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return expires
+	}
+
+	if capped := now.Add(ttl); expires.IsZero() || capped.Before(expires) {
+		return capped
+	}
+
+	return expires
+}
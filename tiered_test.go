@@ -0,0 +1,47 @@
+package simplecache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiered(t *testing.T) {
+	l2 := cache.MapStore[string, int]{}
+	l1 := cache.New[string, int]()
+
+	tiered := cache.Tiered(l1, l2).WithTTLs(time.Minute, time.Hour)
+
+	assert.NoError(t, tiered.Set("item1", 1))
+
+	// Written through with each tier's ttl
+	assert.WithinDuration(t, time.Now().Add(time.Hour), l2["item1"].Expires, time.Second)
+	value, ok := l1.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	// Promoted on an L2 hit, L1 never outlives the item
+	expires := time.Now().Add(10 * time.Second)
+	l2["item2"] = cache.Item[int]{Value: 2, Expires: expires}
+
+	value, ok = tiered.Get("item2")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, int64(1), l1.Stats().Promotions)
+
+	delete(l2, "item2")
+	value, ok = tiered.Get("item2")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+
+	l2["item3"] = cache.Item[int]{Value: 3, Expires: time.Now().Add(-time.Second)}
+	_, ok = tiered.Get("item3")
+	assert.False(t, ok)
+
+	tiered.Delete("item1")
+	_, ok = tiered.Get("item1")
+	assert.False(t, ok)
+	assert.NotContains(t, l2, "item1")
+}
@@ -0,0 +1,93 @@
+package simplecache
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// EntryStat is the measured size of an entry in bytes and its hits, see TopBySize and TopByHits
+type EntryStat[K comparable] struct {
+	Key  K
+	Size int
+	Hits int64
+}
+
+// itemHits counts the hits of each key while WithItemHits is on
+type itemHits[K comparable] struct {
+	mu   sync.Mutex
+	hits map[K]int64
+}
+
+// WithItemHits counts the hits of every entry for TopByHits, an update keeps the count, a deletion or expiry drops it
+func (c *Cache[K, T]) WithItemHits() *Cache[K, T] {
+	c.itemHits = &itemHits[K]{hits: make(map[K]int64)}
+
+	return c
+}
+
+// TopBySize returns up to n of the largest entries as measured by the sizer, largest first
+func (c *Cache[K, T]) TopBySize(n int) []EntryStat[K] {
+	return c.topEntries(n, func(a, b EntryStat[K]) int { return cmp.Compare(b.Size, a.Size) })
+}
+
+// TopByHits returns up to n of the entries hit the most since they were set, nil without WithItemHits
+func (c *Cache[K, T]) TopByHits(n int) []EntryStat[K] {
+	if c.itemHits == nil {
+		return nil
+	}
+
+	return c.topEntries(n, func(a, b EntryStat[K]) int { return cmp.Compare(b.Hits, a.Hits) })
+}
+
+func (c *Cache[K, T]) topEntries(n int, order func(a, b EntryStat[K]) int) []EntryStat[K] {
+	entries := c.entries()
+	hits := c.itemHits.counts()
+
+	stats := make([]EntryStat[K], len(entries))
+	for i, e := range entries {
+		stats[i] = EntryStat[K]{Key: e.Key, Size: c.itemSize(Item[T]{Value: e.Value, Expires: e.Expires}), Hits: hits[e.Key]}
+	}
+
+	slices.SortFunc(stats, order)
+
+	return stats[:min(n, len(stats))]
+}
+
+// hit counts a hit of key
+func (h *itemHits[K]) hit(key K) {
+	h.mu.Lock()
+	h.hits[key]++
+	h.mu.Unlock()
+}
+
+// counts copies the counts, nil for a nil h
+func (h *itemHits[K]) counts() map[K]int64 {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return maps.Clone(h.hits)
+}
+
+// forget drops the counts of keys that left the cache, all of them without keys
+func (h *itemHits[K]) forget(keys ...K) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(keys) == 0 {
+		clear(h.hits)
+	}
+
+	for _, key := range keys {
+		delete(h.hits, key)
+	}
+}
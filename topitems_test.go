@@ -0,0 +1,50 @@
+package simplecache_test
+
+import (
+	"strings"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopBySizeAndHits(t *testing.T) {
+	c := cache.New[string, string]().WithItemHits()
+
+	c.Set("small", "a")
+	c.Set("large", strings.Repeat("a", 1000))
+	c.Set("medium", strings.Repeat("a", 100))
+
+	for range 3 {
+		c.Get("small")
+	}
+	c.Get("medium")
+	c.Get("missing")
+
+	top := c.TopBySize(2)
+	assert.Equal(t, []string{"large", "medium"}, []string{top[0].Key, top[1].Key})
+	assert.Greater(t, top[0].Size, 1000)
+	assert.Equal(t, int64(1), top[1].Hits)
+
+	top = c.TopByHits(5)
+	assert.Len(t, top, 3)
+	assert.Equal(t, cache.EntryStat[string]{Key: "small", Size: top[0].Size, Hits: 3}, top[0])
+
+	// Updates keep the count, deletions drop it
+	c.Set("small", "b")
+	assert.Equal(t, int64(3), c.TopByHits(1)[0].Hits)
+
+	c.Delete("small")
+	c.Set("small", "c")
+	assert.Equal(t, "medium", c.TopByHits(1)[0].Key)
+}
+
+func TestTopBySizeWithSizer(t *testing.T) {
+	c := cache.New[string, string]().WithSizer(func(v string) int { return 1000 - len(v) })
+
+	c.Set("short", "a")
+	c.Set("long", "aaaa")
+
+	assert.Equal(t, "short", c.TopBySize(1)[0].Key)
+	assert.Nil(t, c.TopByHits(1))
+}
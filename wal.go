@@ -0,0 +1,332 @@
+package simplecache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type walOp uint8
+
+const (
+	walSet walOp = iota + 1
+	walDelete
+	walDeleteAll
+)
+
+// maxWALFrame bounds a record, a larger length in the log can only come from corruption and is cut off like a torn write
+const maxWALFrame = 64 << 20
+
+var errWALFrameTooLarge = fmt.Errorf("simplecache: WAL record over %d bytes", maxWALFrame)
+
+// walRecord is a single mutation in the write-ahead log, framed by its length
+type walRecord[K comparable] struct {
+	Op      walOp
+	Time    time.Time
+	Key     K
+	Value   []byte
+	Expires time.Time
+}
+
+// WithWAL appends every Set, Delete and DeleteAll to the log at path once Open has replayed it, so a restarted cache recovers its exact state.
+// Values are encoded by the codec set with WithCodec, errors opening or replaying the log are returned by Open or reported to OnError by Maintain.
+func (c *Cache[K, T]) WithWAL(path string) *Cache[K, T] {
+	c.walPath = path
+
+	return c
+}
+
+// openWAL replays the log and starts appending to it, called by Open after the snapshot is restored
+func (c *Cache[K, T]) openWAL() error {
+	file, err := os.OpenFile(c.walPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if err := c.replayWAL(file); err != nil {
+		file.Close()
+		return fmt.Errorf("simplecache: replay %s: %w", c.walPath, err)
+	}
+
+	c.Lock()
+	c.wal = file
+	c.Unlock()
+
+	return nil
+}
+
+// CloseWAL stops logging mutations and closes the log
+func (c *Cache[K, T]) CloseWAL() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.wal == nil {
+		return nil
+	}
+
+	err := c.wal.Close()
+	c.wal = nil
+
+	return err
+}
+
+// appendWAL logs a mutation, called with the cache lock held so the log order matches the map
+func (c *Cache[K, T]) appendWAL(op walOp, key K, item Item[T]) error {
+	if c.wal == nil {
+		return nil
+	}
+
+	payload, err := c.walPayload(op, key, item)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxWALFrame {
+		return errWALFrameTooLarge
+	}
+
+	c.walWrites++
+
+	// A running compaction copies what was logged meanwhile into the compacted log
+	if c.walCompacting {
+		c.walPending = append(c.walPending, payload)
+	}
+
+	return writeFrame(c.wal, payload)
+}
+
+func (c *Cache[K, T]) walPayload(op walOp, key K, item Item[T]) ([]byte, error) {
+	rec := walRecord[K]{Op: op, Time: time.Now(), Key: key, Expires: item.Expires}
+	if op == walSet {
+		value, err := c.valueCodec().Encode(c.saving(key, item.Value))
+		if err != nil {
+			return nil, fmt.Errorf("simplecache: encode %v: %w", key, err)
+		}
+
+		rec.Value = value
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rec); err != nil {
+		return nil, err
+	}
+
+	return c.pack(buf.Bytes())
+}
+
+// writeFrame writes payload prefixed by its length in a single write
+func writeFrame(w io.Writer, payload []byte) error {
+	if len(payload) > maxWALFrame {
+		return errWALFrameTooLarge
+	}
+
+	frame := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+
+	_, err := w.Write(append(frame, payload...))
+
+	return err
+}
+
+// replayWAL applies the logged mutations, a frame torn by a crash mid-write or with a corrupt length is cut off
+func (c *Cache[K, T]) replayWAL(file *os.File) error {
+	r := bufio.NewReader(file)
+	codec := c.valueCodec()
+	now := time.Now()
+
+	var offset int64
+	var errs []error
+	discarded := 0
+	for {
+		var size uint32
+		err := binary.Read(r, binary.BigEndian, &size)
+		if err == io.EOF {
+			break
+		}
+
+		var payload []byte
+		switch {
+		case err == nil && size > maxWALFrame:
+			err = errWALFrameTooLarge
+		case err == nil:
+			payload = make([]byte, size)
+			_, err = io.ReadFull(r, payload)
+		}
+
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errWALFrameTooLarge) {
+			if err := file.Truncate(offset); err != nil {
+				return err
+			}
+
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		payload, err = c.unpack(payload)
+		if err != nil {
+			return fmt.Errorf("record at offset %d: %w", offset, err)
+		}
+
+		var rec walRecord[K]
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&rec); err != nil {
+			return fmt.Errorf("record at offset %d: %w", offset, err)
+		}
+
+		offset += 4 + int64(size)
+
+		// Applied directly, interceptors and invalidations already ran when the mutation was made
+		item := Item[T]{Expires: rec.Expires}
+		switch {
+		case rec.Op == walSet && item.expired(now):
+			rec.Op = walDelete
+			discarded++
+
+		case rec.Op == walSet:
+			value, err := codec.Decode(rec.Value)
+			if err != nil {
+				return fmt.Errorf("decode %v: %w", rec.Key, err)
+			}
+
+			item.Value = c.loaded(rec.Key, value)
+		}
+
+		if err := c.applyRecovered(rec.Op, rec.Key, item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	c.reportDiscarded(discarded)
+
+	return errors.Join(errs...)
+}
+
+// WithWALCompaction compacts the log every interval while Maintain runs, skipping rounds without new writes
+func (c *Cache[K, T]) WithWALCompaction(interval time.Duration) *Cache[K, T] {
+	c.walCompactInterval = interval
+
+	return c
+}
+
+// CompactWAL rewrites the log with a single set per live item, dropping overwritten, deleted and expired entries.
+// The compacted log is written without holding the lock, writes made meanwhile are carried over before it replaces the old one.
+func (c *Cache[K, T]) CompactWAL() error {
+	c.Lock()
+
+	if c.wal == nil || c.walCompacting {
+		c.Unlock()
+		return nil
+	}
+
+	path := c.walPath
+	data := c.frozen()
+	c.walCompacting = true
+	c.walWrites = 0
+
+	c.Unlock()
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		c.Lock()
+		c.walCompacting = false
+		c.walPending = nil
+		c.Unlock()
+
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	for _, e := range entriesOf(data) {
+		var payload []byte
+		if payload, err = c.walPayload(walSet, e.Key, Item[T]{Value: e.Value, Expires: e.Expires}); err == nil {
+			err = writeFrame(file, payload)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	pending := c.walPending
+	c.walCompacting = false
+	c.walPending = nil
+
+	// Closed while compacting
+	if c.wal == nil {
+		file.Close()
+		return nil
+	}
+
+	for _, payload := range pending {
+		if err != nil {
+			break
+		}
+
+		err = writeFrame(file, payload)
+	}
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	// Swap over to the compacted log, the old handle still points at the replaced file
+	compacted, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	c.wal.Close()
+	c.wal = compacted
+
+	return nil
+}
+
+func (c *Cache[K, T]) runWALCompaction(done <-chan struct{}) {
+	ticker := time.NewTicker(c.walCompactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.RLock()
+			writes := c.walWrites
+			c.RUnlock()
+
+			if writes == 0 {
+				continue
+			}
+
+			if err := c.CompactWAL(); err != nil {
+				c.reportError(err)
+			}
+
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package simplecache_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, c.Open())
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Set("item1", TestStruct{Name: "Alice", Age: 31})
+	c.Delete("item2")
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40}, time.Now().Add(50*time.Millisecond))
+	assert.NoError(t, c.CloseWAL())
+
+	time.Sleep(100 * time.Millisecond)
+
+	recovered := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	item1, ok := recovered.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 31}, item1)
+
+	_, ok = recovered.Get("item2")
+	assert.False(t, ok)
+
+	_, ok = recovered.Get("item3")
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), recovered.Stats().Items)
+
+	// DeleteAll is logged too
+	recovered.DeleteAll()
+	assert.NoError(t, recovered.CloseWAL())
+
+	empty := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, empty.Open())
+	defer empty.CloseWAL()
+
+	assert.Empty(t, empty.GetAll())
+}
+
+func TestWALTornWrite(t *testing.T) {
+	// A crash halfway through the next record, and a corrupt length that would allocate 4GB
+	for _, tail := range [][]byte{{0, 0, 0, 64, 1, 2}, {0xff, 0xff, 0xff, 0xff, 1, 2}} {
+		path := filepath.Join(t.TempDir(), "cache.wal")
+
+		c := cache.New[string, TestStruct]().WithWAL(path)
+		assert.NoError(t, c.Open())
+		c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+		assert.NoError(t, c.CloseWAL())
+
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		assert.NoError(t, err)
+		_, err = file.Write(tail)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+
+		recovered := cache.New[string, TestStruct]().WithWAL(path)
+		assert.NoError(t, recovered.Open())
+
+		item1, ok := recovered.Get("item1")
+		assert.True(t, ok)
+		assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+		assert.NoError(t, recovered.CloseWAL())
+
+		truncated, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, info.Size(), truncated.Size())
+	}
+}
+
+func TestWALAppliesLaterOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithWAL(path).WithEncryption(testKey)
+	assert.NoError(t, c.Open())
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	assert.NoError(t, c.CloseWAL())
+
+	// Replayed with the key set after WithWAL
+	recovered := cache.New[string, TestStruct]().WithWAL(path).WithEncryption(testKey)
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	item1, ok := recovered.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+}
+
+func TestWALReplayBypassesWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, c.Open())
+	c.Set("item1", TestStruct{Name: "Alice", Age: 30})
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Delete("item2")
+	assert.NoError(t, c.CloseWAL())
+
+	// Recovered data was accepted when it was written, it isn't intercepted or reported again
+	intercepted := 0
+	recovered := cache.New[string, TestStruct]().WithWAL(path).
+		InterceptSet(func(key string, value TestStruct) (TestStruct, error) {
+			intercepted++
+			return value, errors.New("rejected")
+		})
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	item1, ok := recovered.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+	assert.Zero(t, intercepted)
+	assert.Equal(t, int64(1), recovered.Stats().Items)
+	assert.Zero(t, recovered.Stats().Sets)
+	assert.Zero(t, recovered.Stats().Deletes)
+}
+
+func TestCompactWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, c.Open())
+	defer c.CloseWAL()
+
+	for age := range 100 {
+		c.Set("item1", TestStruct{Name: "Alice", Age: age})
+	}
+
+	c.Set("item2", TestStruct{Name: "Bob", Age: 25})
+	c.Delete("item2")
+
+	before, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.CompactWAL())
+
+	after, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size()/50)
+
+	// Writes after compaction land in the compacted log
+	c.Set("item3", TestStruct{Name: "Carol", Age: 40})
+	assert.NoError(t, c.CloseWAL())
+
+	recovered := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	assert.ElementsMatch(t, []TestStruct{{Name: "Alice", Age: 99}, {Name: "Carol", Age: 40}}, recovered.GetAll())
+}
+
+func TestWALCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).
+		WithWAL(path).
+		WithWALCompaction(50 * time.Millisecond)
+	defer c.CloseWAL()
+	assert.NoError(t, c.Open())
+
+	for age := range 100 {
+		c.Set("item1", TestStruct{Name: "Alice", Age: age})
+	}
+
+	before, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	go c.Maintain()
+	time.Sleep(150 * time.Millisecond)
+	c.Stop()
+
+	after, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size()/50)
+}
+
+func TestCompactWALConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	c := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, c.Open())
+
+	for i := range 1000 {
+		c.Set(fmt.Sprintf("item%d", i), TestStruct{Name: "Alice", Age: i})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := range 1000 {
+			c.Set(fmt.Sprintf("item%d", i), TestStruct{Name: "Bob", Age: i})
+			if i%2 == 0 {
+				c.Delete(fmt.Sprintf("item%d", i))
+			}
+		}
+	}()
+
+	for range 5 {
+		assert.NoError(t, c.CompactWAL())
+	}
+
+	<-done
+	assert.NoError(t, c.CloseWAL())
+
+	recovered := cache.New[string, TestStruct]().WithWAL(path)
+	assert.NoError(t, recovered.Open())
+	defer recovered.CloseWAL()
+
+	assert.ElementsMatch(t, c.GetAll(), recovered.GetAll())
+	assert.Len(t, recovered.GetAll(), 500)
+}
@@ -0,0 +1,79 @@
+package simplecache
+
+import (
+	"errors"
+	"time"
+)
+
+// WithWarmup runs load in the background once Open is called and installs what it returns, Ready is closed once it is done.
+// Expired items are skipped, a failed warmup is reported to OnError and by WarmupErr.
+func (c *Cache[K, T]) WithWarmup(load func() (map[K]Item[T], error)) *Cache[K, T] {
+	c.ready = make(chan struct{})
+	c.warmupLoad = load
+
+	return c
+}
+
+// startWarmup runs the warmup in the background, called by Open after the snapshot and the WAL are restored
+func (c *Cache[K, T]) startWarmup() {
+	go func() {
+		defer close(c.ready)
+
+		err := c.warmup(c.warmupLoad)
+		if err != nil {
+			c.warmupErr.Store(&err)
+			c.reportError(err)
+		}
+	}()
+}
+
+// Ready is closed once the cache is warmed up, right away without WithWarmup
+func (c *Cache[K, T]) Ready() <-chan struct{} {
+	if c.ready == nil {
+		return closedChan
+	}
+
+	return c.ready
+}
+
+// WarmupErr returns why the warmup failed, nil while it is running or if it succeeded
+func (c *Cache[K, T]) WarmupErr() error {
+	if err := c.warmupErr.Load(); err != nil {
+		return *err
+	}
+
+	return nil
+}
+
+func (c *Cache[K, T]) warmup(load func() (map[K]Item[T], error)) error {
+	items, err := load()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var errs []error
+	discarded := 0
+	for key, item := range items {
+		if item.expired(now) {
+			discarded++
+			continue
+		}
+
+		if err := c.SetE(key, item.Value, item.Expires); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	c.reportDiscarded(discarded)
+
+	return errors.Join(errs...)
+}
+
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+
+	return ch
+}()
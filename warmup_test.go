@@ -0,0 +1,84 @@
+package simplecache_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmup(t *testing.T) {
+	release := make(chan struct{})
+
+	c := cache.New[string, TestStruct]().WithWarmup(func() (map[string]cache.Item[TestStruct], error) {
+		<-release
+
+		return map[string]cache.Item[TestStruct]{
+			"item1": {Value: TestStruct{Name: "Alice", Age: 30}},
+			"item2": {Value: TestStruct{Name: "Bob", Age: 25}, Expires: time.Now().Add(-time.Minute)},
+		}, nil
+	})
+
+	// Started by Open, once every option is applied
+	select {
+	case <-c.Ready():
+		t.Fatal("ready before warmup started")
+	default:
+	}
+
+	assert.NoError(t, c.Open())
+
+	select {
+	case <-c.Ready():
+		t.Fatal("ready before warmup finished")
+	default:
+	}
+
+	close(release)
+	<-c.Ready()
+
+	item1, ok := c.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "Alice", Age: 30}, item1)
+
+	_, ok = c.Get("item2")
+	assert.False(t, ok)
+	assert.NoError(t, c.WarmupErr())
+}
+
+func TestWarmupError(t *testing.T) {
+	errDB := errors.New("database unavailable")
+
+	c := cache.New[string, TestStruct]().WithWarmup(func() (map[string]cache.Item[TestStruct], error) {
+		return nil, errDB
+	})
+	assert.NoError(t, c.Open())
+
+	<-c.Ready()
+	assert.ErrorIs(t, c.WarmupErr(), errDB)
+
+	// Ready right away without a warmup
+	<-cache.New[string, TestStruct]().Ready()
+}
+
+func TestWarmupAppliesLaterOptions(t *testing.T) {
+	c := cache.New[string, TestStruct]().WithInterval(time.Second).
+		WithWarmup(func() (map[string]cache.Item[TestStruct], error) {
+			return map[string]cache.Item[TestStruct]{"item1": {Value: TestStruct{Name: "alice", Age: 30}}}, nil
+		}).
+		InterceptSet(func(key string, value TestStruct) (TestStruct, error) {
+			value.Name = strings.ToUpper(value.Name)
+			return value, nil
+		})
+
+	go c.Maintain()
+	<-c.Ready()
+	c.Stop()
+
+	item1, ok := c.Get("item1")
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{Name: "ALICE", Age: 30}, item1)
+}
@@ -0,0 +1,143 @@
+package simplecache
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultWebhookSource = "simplecache"
+
+	// Without async dispatch deliveries run in the tick, a hung endpoint must not hold it for long
+	defaultWebhookTimeout = 10 * time.Second
+
+	// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body under the webhook's secret
+	WebhookSignatureHeader = "X-Simplecache-Signature"
+)
+
+// Webhook is an endpoint receiving batches of changes as CloudEvents
+type Webhook struct {
+	URL string
+
+	// Secret signs the requests when set, see WebhookSignatureHeader
+	Secret []byte
+
+	// Source identifies the cache in the events, defaults to "simplecache"
+	Source string
+
+	// Client defaults to one timing out requests after 10 seconds
+	Client *http.Client
+}
+
+var defaultWebhookClient = &http.Client{Timeout: defaultWebhookTimeout}
+
+// cloudEvent is a CloudEvents 1.0 event in its JSON format
+type cloudEvent[K comparable, T any] struct {
+	SpecVersion     string            `json:"specversion"`
+	ID              string            `json:"id"`
+	Source          string            `json:"source"`
+	Type            string            `json:"type"`
+	Subject         string            `json:"subject"`
+	Time            time.Time         `json:"time"`
+	DataContentType string            `json:"datacontenttype"`
+	Data            eventRecord[K, T] `json:"data"`
+}
+
+// NotifyWebhooks POSTs every batch of changes to each webhook as a JSON array of CloudEvents (batched content mode),
+// typed "simplecache.created", "simplecache.updated" and so on with the key as subject. Each webhook is retried and
+// dead-lettered on its own like with OnChangesE, a response other than 2xx counts as failed. Returns a func that stops notifying.
+func (c *Cache[K, T]) NotifyWebhooks(hooks ...Webhook) func() {
+	var stops []func()
+	for _, hook := range hooks {
+		stops = append(stops, c.Subscribe(Middlewares[K, T]{OnChanges: func(changes ChangeSet[K, T]) {
+			events := eventsOf(changes, time.Now())
+			if len(events) == 0 {
+				return
+			}
+
+			// Encoded once so retries carry the same event ids
+			body, err := cloudEvents(hook, events)
+
+			c.handle(func(ChangeSet[K, T]) error {
+				if err != nil {
+					return err
+				}
+
+				return postWebhook(hook, body)
+			}, changes)
+		}}))
+	}
+
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
+
+func cloudEvents[K comparable, T any](hook Webhook, events []Event[K, T]) ([]byte, error) {
+	source := hook.Source
+	if source == "" {
+		source = defaultWebhookSource
+	}
+
+	batch := make([]cloudEvent[K, T], len(events))
+	for i, event := range events {
+		// Sequence numbers restart with the process, the random part keeps ids unique per source
+		nonce := make([]byte, 8)
+		rand.Read(nonce)
+
+		batch[i] = cloudEvent[K, T]{
+			SpecVersion:     "1.0",
+			ID:              strconv.FormatUint(event.Seq, 10) + "-" + hex.EncodeToString(nonce),
+			Source:          source,
+			Type:            "simplecache." + event.Type.String(),
+			Subject:         keyString(event.Key),
+			Time:            event.Time,
+			DataContentType: "application/json",
+			Data:            newEventRecord(event),
+		}
+	}
+
+	return json.Marshal(batch)
+}
+
+func postWebhook(hook Webhook, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/cloudevents-batch+json")
+
+	if hook.Secret != nil {
+		mac := hmac.New(sha256.New, hook.Secret)
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := hook.Client
+	if client == nil {
+		client = defaultWebhookClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("simplecache: webhook %s: %w", hook.URL, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("simplecache: webhook %s: %s", hook.URL, resp.Status)
+	}
+
+	return nil
+}
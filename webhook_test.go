@@ -0,0 +1,68 @@
+package simplecache_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyWebhooks(t *testing.T) {
+	secret := []byte("secret")
+
+	batches := make(chan []map[string]any, 1)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(cache.WebhookSignatureHeader))
+		assert.Equal(t, "application/cloudevents-batch+json", r.Header.Get("Content-Type"))
+
+		var batch []map[string]any
+		assert.NoError(t, json.Unmarshal(body, &batch))
+		batches <- batch
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	deadLetters := make(chan cache.DeadLetter[string, int], 1)
+
+	c := cache.New[string, int]().WithImmediateNotifications().
+		WithRetry(2, time.Millisecond).
+		OnDeadLetter(func(dl cache.DeadLetter[string, int]) { deadLetters <- dl })
+
+	stop := c.NotifyWebhooks(
+		cache.Webhook{URL: ok.URL, Secret: secret, Source: "/caches/users"},
+		cache.Webhook{URL: failing.URL},
+	)
+	defer stop()
+
+	c.Set("user:1", 1)
+
+	batch := <-batches
+	assert.Len(t, batch, 1)
+	assert.Equal(t, "1.0", batch[0]["specversion"])
+	assert.Equal(t, "/caches/users", batch[0]["source"])
+	assert.Equal(t, "simplecache.created", batch[0]["type"])
+	assert.Equal(t, "user:1", batch[0]["subject"])
+	assert.NotEmpty(t, batch[0]["id"])
+	assert.Equal(t, float64(1), batch[0]["data"].(map[string]any)["value"])
+
+	// Only the failing webhook's batch is dead-lettered
+	dl := <-deadLetters
+	assert.Equal(t, 2, dl.Attempts)
+	assert.ErrorContains(t, dl.Err, "503")
+}
@@ -0,0 +1,247 @@
+package simplecache
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is appended to the client's key to prove the handshake, see RFC 6455
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+// maxWebSocketFrame bounds what listeners may send, only control frames are expected
+const maxWebSocketFrame = 1 << 16
+
+// WebSocketHandler upgrades to a WebSocket streaming every change as a JSON text message, shaped like
+// {"seq", "type", "key", "value", "previous", "time"}. Each connection picks its keys with query parameters:
+// key (repeatable) for exact keys, prefix or pattern (as in WatchPattern), all keys without any.
+// Like Events, changes are dropped for a listener whose buffer is full.
+func (c *Cache[K, T]) WebSocketHandler() http.Handler {
+	return c.secured(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match, err := eventFilter[K, T](r)
+		if err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+			writeHTTPError(w, http.StatusBadRequest, errors.New("websocket upgrade expected"))
+			return
+		}
+
+		if r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+			writeHTTPError(w, http.StatusUpgradeRequired, errors.New("unsupported websocket version"))
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			writeHTTPError(w, http.StatusInternalServerError, errors.New("websocket not supported"))
+			return
+		}
+
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Watching before answering, so changes made once the listener sees the handshake are streamed
+		events, cancel := c.streamWatch(match)
+		defer cancel()
+
+		sum := sha1.Sum([]byte(key + websocketGUID))
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		if rw.Flush() != nil {
+			return
+		}
+
+		ws := &wsConn{w: rw.Writer}
+
+		// Listeners only send control frames, a close or a broken connection ends the stream
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			ws.readControl(rw.Reader)
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+
+				data, err := eventJSON(event)
+				if err != nil {
+					c.reportError(err)
+					continue
+				}
+
+				if ws.write(wsText, data) != nil {
+					return
+				}
+			}
+		}
+	}))
+}
+
+// eventFilter builds the key filter of a streaming request from its key, prefix and pattern parameters
+func eventFilter[K comparable, T any](r *http.Request) (func(K, T) bool, error) {
+	query := r.URL.Query()
+	keys, prefix, pattern := query["key"], query.Get("prefix"), query.Get("pattern")
+
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(key K, _ T) bool {
+		s := keyString(key)
+
+		if len(keys) > 0 && !slices.Contains(keys, s) {
+			return false
+		}
+
+		if pattern != "" {
+			if matched, _ := path.Match(pattern, s); !matched {
+				return false
+			}
+		}
+
+		return strings.HasPrefix(s, prefix)
+	}, nil
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// wsConn writes unmasked frames as a server does, pongs and events may be written concurrently
+type wsConn struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+func (ws *wsConn) write(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	header := []byte{0x80 | opcode}
+
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+
+	ws.w.Write(header)
+	ws.w.Write(payload)
+
+	return ws.w.Flush()
+}
+
+// readControl answers pings until the listener closes the connection
+func (ws *wsConn) readControl(r *bufio.Reader) {
+	for {
+		opcode, payload, err := readWebSocketFrame(r)
+		if err != nil {
+			return
+		}
+
+		switch opcode {
+		case wsPing:
+			if ws.write(wsPong, payload) != nil {
+				return
+			}
+
+		case wsClose:
+			ws.write(wsClose, payload)
+			return
+		}
+	}
+}
+
+// readWebSocketFrame reads one masked client frame, fragments are returned as they come
+func readWebSocketFrame(r *bufio.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+
+	if head[1]&0x80 == 0 {
+		return 0, nil, errors.New("simplecache: unmasked websocket frame")
+	}
+
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if n > maxWebSocketFrame {
+		return 0, nil, errors.New("simplecache: websocket frame too large")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return head[0] & 0x0F, payload, nil
+}
@@ -0,0 +1,104 @@
+package simplecache_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cache "github.com/kamludwinski2/simplecache"
+	"github.com/stretchr/testify/assert"
+)
+
+// dialWebSocket does the client handshake and returns the connection with its reader
+func dialWebSocket(t *testing.T, url string) (net.Conn, *bufio.Reader) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+
+	conn, err := net.Dial("tcp", req.URL.Host)
+	assert.NoError(t, err)
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	assert.NoError(t, req.Write(conn))
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	return conn, r
+}
+
+// readTextFrame reads a short unmasked server frame
+func readTextFrame(t *testing.T, r *bufio.Reader) map[string]any {
+	var head [2]byte
+	_, err := io.ReadFull(r, head[:])
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x81), head[0])
+
+	payload := make([]byte, head[1])
+	_, err = io.ReadFull(r, payload)
+	assert.NoError(t, err)
+
+	var event map[string]any
+	assert.NoError(t, json.Unmarshal(payload, &event))
+
+	return event
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	c := cache.New[string, int]().WithImmediateNotifications()
+
+	server := httptest.NewServer(c.WebSocketHandler())
+	defer server.Close()
+
+	conn, r := dialWebSocket(t, server.URL+"/?prefix=user:")
+	defer conn.Close()
+
+	c.Set("other", 1)
+	c.Set("user:1", 1)
+	c.Set("user:1", 2)
+	c.Delete("user:1")
+
+	event := readTextFrame(t, r)
+	assert.Equal(t, "created", event["type"])
+	assert.Equal(t, "user:1", event["key"])
+	assert.Equal(t, float64(1), event["value"])
+
+	event = readTextFrame(t, r)
+	assert.Equal(t, "updated", event["type"])
+	assert.Equal(t, float64(1), event["previous"])
+
+	assert.Equal(t, "deleted", readTextFrame(t, r)["type"])
+
+	// A masked ping is answered, a close is echoed
+	conn.Write([]byte{0x89, 0x80 | 2, 1, 2, 3, 4, 'h' ^ 1, 'i' ^ 2})
+	var pong [4]byte
+	_, err := io.ReadFull(r, pong[:])
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x8A, 2, 'h', 'i'}, pong[:])
+
+	conn.Write([]byte{0x88, 0x80, 0, 0, 0, 0})
+	var closing [2]byte
+	_, err = io.ReadFull(r, closing[:])
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x88), closing[0])
+}
+
+func TestWebSocketHandlerRejectsPlainRequests(t *testing.T) {
+	c := cache.New[string, int]()
+
+	rec := httptest.NewRecorder()
+	c.WebSocketHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	c.WebSocketHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?pattern=[", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}